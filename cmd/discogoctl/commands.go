@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tahakara/discogo/pkg/discogo"
+)
+
+// tagFlags collects repeated -tag key=value flags.
+type tagFlags map[string]string
+
+func (t tagFlags) String() string {
+	var parts []string
+	for k, v := range t {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (t tagFlags) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("tag must be in key=value form")
+	}
+	t[k] = v
+	return nil
+}
+
+func runRegister(args []string) error {
+	fs := flag.NewFlagSet("register", flag.ContinueOnError)
+	opts := commonFlags(fs, false)
+
+	var req discogo.RegisterRequest
+	tags := tagFlags{}
+	file := fs.String("f", "", "read the registration payload from a JSON file ('-' for stdin)")
+	fs.StringVar(&req.Name, "name", "", "service name")
+	fs.StringVar(&req.Type, "type", "", "service type short name")
+	fs.StringVar(&req.Version, "version", "", "service version")
+	fs.StringVar(&req.Provider, "provider", "", "provider short name")
+	fs.StringVar(&req.Region, "region", "", "region")
+	fs.StringVar(&req.Zone, "zone", "", "zone")
+	fs.StringVar(&req.Cluster, "cluster", "", "cluster")
+	fs.StringVar(&req.InstanceID, "instance", "", "instance ID")
+	fs.StringVar(&req.NetworkID, "network", "", "network ID")
+	fs.StringVar(&req.SubnetID, "subnet", "", "subnet ID")
+	fs.StringVar(&req.NetworkDomain, "domain", "", "network domain")
+	fs.StringVar(&req.Addr4, "addr4", "", "IPv4 address")
+	fs.IntVar(&req.Port4, "port4", 0, "IPv4 port")
+	fs.StringVar(&req.Addr6, "addr6", "", "IPv6 address")
+	fs.IntVar(&req.Port6, "port6", 0, "IPv6 port")
+	fs.Var(tags, "tag", "tag in key=value form (repeatable)")
+	var check discogo.Check
+	fs.StringVar(&check.Type, "check", "", "active health check: http, tcp or grpc")
+	fs.StringVar(&check.Path, "check-path", "", "HTTP check path (default /)")
+	fs.IntVar(&check.ExpectedStatus, "check-status", 0, "HTTP check expected status (default 200)")
+	fs.StringVar(&check.Service, "check-service", "", "gRPC check service name")
+	fs.IntVar(&check.Interval, "check-interval", 0, "check interval in seconds (default: server setting)")
+	var regOpts discogo.RegisterOptions
+	fs.BoolVar(&regOpts.Replace, "replace", false, "replace an existing registration of the same identity")
+	fs.StringVar(&regOpts.IdempotencyKey, "idempotency-key", "", "return the first registration when retried with the same key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	if *file != "" {
+		var data []byte
+		var err error
+		if *file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(*file)
+		}
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid registration payload: %w", err)
+		}
+	}
+	if len(tags) > 0 {
+		if req.Tags == nil {
+			req.Tags = map[string]string{}
+		}
+		for k, v := range tags {
+			req.Tags[k] = v
+		}
+	}
+	if check.Type != "" {
+		req.Check = &check
+	}
+
+	resp, err := opts.client().RegisterWithOptions(context.Background(), req, regOpts)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, opts.output, resp, &table{
+		Headers: []string{"SERVICE UUID", "HEALTH CHECK CYCLE", "EXISTING", "REPLACED"},
+		Rows:    [][]string{{resp.ServiceUUID, strconv.Itoa(resp.HealthCheckCycle) + "s", strconv.FormatBool(resp.Existing), resp.ReplacedUUID}},
+	})
+}
+
+func runHeartbeat(args []string) error {
+	fs := flag.NewFlagSet("heartbeat", flag.ContinueOnError)
+	opts := commonFlags(fs, false)
+
+	var req discogo.HeartbeatRequest
+	meta := tagFlags{}
+	cpu := fs.Float64("cpu", 0, "CPU load in percent")
+	mem := fs.Float64("mem", 0, "memory load in percent")
+	inFlight := fs.Int64("inflight", 0, "requests in flight")
+	fs.StringVar(&req.Status, "status", "", "self-reported status: ok, degraded or failing")
+	fs.Var(meta, "meta", "metadata key=value to merge, an empty value removes the key (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: discogoctl heartbeat [flags] <uuid>")
+	}
+	uuid := fs.Arg(0)
+
+	// Only the flags that were given are reported
+	var payload *discogo.HeartbeatRequest
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "cpu":
+			req.CPULoad = cpu
+		case "mem":
+			req.MemoryLoad = mem
+		case "inflight":
+			req.InFlight = inFlight
+		case "status", "meta":
+		default:
+			return
+		}
+		payload = &req
+	})
+	if len(meta) > 0 {
+		req.Metadata = meta
+	}
+
+	resp, err := opts.client().HeartbeatWithPayload(context.Background(), uuid, payload)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, opts.output, resp, &table{
+		Headers: []string{"SERVICE UUID", "STATUS"},
+		Rows:    [][]string{{uuid, resp.Status}},
+	})
+}
+
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	opts := commonFlags(fs, false)
+
+	var req discogo.ReportRequest
+	fs.StringVar(&req.ReporterUUID, "reporter", "", "UUID of the reporting service")
+	fs.StringVar(&req.Reason, "reason", "", "why the service is reported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || req.ReporterUUID == "" {
+		return errors.New("usage: discogoctl report -reporter <uuid> [flags] <uuid>")
+	}
+	req.ServiceUUID = fs.Arg(0)
+
+	resp, err := opts.client().Report(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, opts.output, resp, &table{
+		Headers: []string{"SERVICE UUID", "STATUS", "REPORTERS", "SCORE", "THRESHOLD"},
+		Rows:    [][]string{{resp.ServiceUUID, resp.ServiceStatus, strconv.Itoa(resp.Reporters), strconv.FormatFloat(resp.Score, 'f', 2, 64), strconv.FormatInt(resp.Threshold, 10)}},
+	})
+}
+
+func runOutcomes(args []string) error {
+	fs := flag.NewFlagSet("outcomes", flag.ContinueOnError)
+	opts := commonFlags(fs, false)
+
+	var req discogo.OutcomesRequest
+	var outcome discogo.Outcome
+	fs.StringVar(&req.ReporterUUID, "reporter", "", "UUID of the reporting service")
+	fs.Int64Var(&outcome.Successes, "ok", 0, "successful requests")
+	fs.Int64Var(&outcome.Failures, "fail", 0, "failed requests")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || req.ReporterUUID == "" {
+		return errors.New("usage: discogoctl outcomes -reporter <uuid> [-ok n] [-fail n] [flags] <uuid>")
+	}
+	outcome.ServiceUUID = fs.Arg(0)
+	req.Outcomes = []discogo.Outcome{outcome}
+
+	resp, err := opts.client().ReportOutcomes(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, opts.output, resp, &table{
+		Headers: []string{"STATUS", "ACCEPTED", "INTERVAL"},
+		Rows:    [][]string{{resp.Status, strconv.Itoa(resp.Accepted), strconv.Itoa(resp.Interval) + "s"}},
+	})
+}
+
+func runDeregister(args []string) error {
+	fs := flag.NewFlagSet("deregister", flag.ContinueOnError)
+	opts := commonFlags(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: discogoctl deregister [flags] <uuid>")
+	}
+	uuid := fs.Arg(0)
+
+	resp, err := opts.client().Deregister(context.Background(), uuid)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, opts.output, resp, &table{
+		Headers: []string{"SERVICE UUID", "STATUS", "MESSAGE"},
+		Rows:    [][]string{{uuid, resp.Status, resp.Message}},
+	})
+}
+
+// discoverFlags registers the discovery filters shared by discover and resolve.
+func discoverFlags(fs *flag.FlagSet, defaultStatus string) *discogo.DiscoverQuery {
+	q := &discogo.DiscoverQuery{}
+	fs.StringVar(&q.ServiceType, "type", "", "service type short name (required)")
+	fs.StringVar(&q.Status, "status", defaultStatus, "status filter")
+	fs.StringVar(&q.Provider, "provider", "", "provider filter")
+	fs.StringVar(&q.Region, "region", "", "region filter")
+	fs.StringVar(&q.Zone, "zone", "", "zone filter")
+	fs.StringVar(&q.NetworkID, "network", "", "network ID filter")
+	fs.StringVar(&q.SubnetID, "subnet", "", "subnet ID filter")
+	fs.StringVar(&q.InstanceID, "instance", "", "instance ID filter")
+	fs.StringVar(&q.Version, "version", "", "version filter")
+	fs.IntVar(&q.PageSize, "page-size", 0, "results per page (1-10)")
+	fs.IntVar(&q.PageOffset, "page-offset", 0, "page offset")
+	return q
+}
+
+func runDiscover(args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	opts := commonFlags(fs, true)
+	query := discoverFlags(fs, "*")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if query.ServiceType == "" {
+		return errors.New("-type is required")
+	}
+
+	client := opts.client()
+	return runWatched(opts, func() error {
+		resp, err := client.Discover(context.Background(), *query)
+		if err != nil {
+			return err
+		}
+		services := resp.Services
+		if services == nil {
+			services = []discogo.ServiceInfo{}
+		}
+		tbl := &table{Headers: []string{"SERVICE ID", "ADDRESS"}}
+		for _, svc := range services {
+			tbl.Rows = append(tbl.Rows, []string{svc.ServiceID, svc.ServiceAddr})
+		}
+		return render(os.Stdout, opts.output, services, tbl)
+	})
+}
+
+func runResolve(args []string) error {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	opts := commonFlags(fs, true)
+	query := discoverFlags(fs, "healthy")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if query.ServiceType == "" {
+		return errors.New("-type is required")
+	}
+
+	client := opts.client()
+	return runWatched(opts, func() error {
+		resp, err := client.Discover(context.Background(), *query)
+		if err != nil {
+			return err
+		}
+		if len(resp.Services) == 0 {
+			return fmt.Errorf("no %s instance of type %q found", query.Status, query.ServiceType)
+		}
+		// Of two random instances the less loaded one is preferred
+		svc := resp.Services[rand.Intn(len(resp.Services))]
+		if other := resp.Services[rand.Intn(len(resp.Services))]; discogo.LessLoaded(other, svc) {
+			svc = other
+		}
+		return render(os.Stdout, opts.output, svc, &table{
+			Headers: []string{"SERVICE ID", "ADDRESS"},
+			Rows:    [][]string{{svc.ServiceID, svc.ServiceAddr}},
+		})
+	})
+}
+
+func runHealth(args []string) error {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	opts := commonFlags(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	client := opts.client()
+	return runWatched(opts, func() error {
+		resp, err := client.Health(context.Background())
+		if resp == nil {
+			return err
+		}
+		if renderErr := render(os.Stdout, opts.output, resp, &table{
+			Headers: []string{"STATUS"},
+			Rows:    [][]string{{resp.Status}},
+		}); renderErr != nil {
+			return renderErr
+		}
+		return err
+	})
+}
+
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	opts := commonFlags(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	client := opts.client()
+	return runWatched(opts, func() error {
+		resp, err := client.Version(context.Background())
+		if err != nil {
+			return err
+		}
+		return render(os.Stdout, opts.output, resp, &table{
+			Headers: []string{"NAME", "VERSION", "VERSION NAME"},
+			Rows:    [][]string{{resp.Name, resp.Version, resp.VersionName}},
+		})
+	})
+}
+
+// catalogStatuses is the column order of per-status instance counts.
+var catalogStatuses = []string{"healthy", "degraded", "registered", "unknown", "suspicious", "deregistered"}
+
+func serviceTypeRow(t discogo.ServiceType) []string {
+	row := []string{t.Group, t.Short, t.Name}
+	for _, status := range catalogStatuses {
+		row = append(row, strconv.Itoa(t.Instances.ByStatus[status]))
+	}
+	return append(row, strconv.Itoa(t.Instances.Total))
+}
+
+func serviceTypeTable() *table {
+	headers := []string{"GROUP", "TYPE", "NAME"}
+	for _, status := range catalogStatuses {
+		headers = append(headers, strings.ToUpper(status))
+	}
+	return &table{Headers: append(headers, "TOTAL")}
+}
+
+func runCatalog(args []string) error {
+	fs := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	opts := commonFlags(fs, true)
+	providers := fs.Bool("providers", false, "list providers instead of service types")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: discogoctl catalog [-providers] [type]")
+	}
+
+	client := opts.client()
+	ctx := context.Background()
+	return runWatched(opts, func() error {
+		switch {
+		case *providers:
+			list, err := client.Providers(ctx)
+			if err != nil {
+				return err
+			}
+			tbl := &table{Headers: []string{"PROVIDER", "NAME"}}
+			for _, p := range list {
+				tbl.Rows = append(tbl.Rows, []string{p.Short, p.Name})
+			}
+			return render(os.Stdout, opts.output, list, tbl)
+		case fs.NArg() == 1:
+			t, err := client.ServiceType(ctx, fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			tbl := serviceTypeTable()
+			tbl.Rows = append(tbl.Rows, serviceTypeRow(*t))
+			return render(os.Stdout, opts.output, t, tbl)
+		default:
+			groups, err := client.ServiceTypes(ctx)
+			if err != nil {
+				return err
+			}
+			tbl := serviceTypeTable()
+			for _, g := range groups {
+				for _, t := range g.Services {
+					tbl.Rows = append(tbl.Rows, serviceTypeRow(t))
+				}
+			}
+			return render(os.Stdout, opts.output, groups, tbl)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tahakara/discogo/pkg/discogo"
+)
+
+const usage = `discogoctl talks to a DiscoGo server over its HTTP API.
+
+Usage:
+  discogoctl <command> [flags]
+
+Commands:
+  register     Register a new service instance
+  heartbeat    Send a heartbeat for a service UUID
+  report       Report a misbehaving service UUID
+  outcomes     Report request outcomes against a service UUID
+  deregister   Deregister a service UUID
+  discover     List services of a type
+  resolve      Pick a single healthy instance of a type
+  health       Show server health
+  version      Show server version
+  catalog      List service types with instance counts, or providers
+
+Common flags:
+  -server      DiscoGo address (default $DISCOGO_SERVER or http://127.0.0.1:8080)
+  -api-key     API key sent to rate limits (default $DISCOGO_API_KEY)
+  -o           Output format: table, json or yaml (default table)
+  -watch       Re-run read-only commands every -interval
+  -interval    Watch interval (default 2s)
+
+Run 'discogoctl <command> -h' for command specific flags.`
+
+const defaultServer = "http://127.0.0.1:8080"
+
+type options struct {
+	server   string
+	apiKey   string
+	output   string
+	watch    bool
+	interval time.Duration
+}
+
+// commonFlags registers the flags shared by every command. Watch flags are
+// only added to read-only commands.
+func commonFlags(fs *flag.FlagSet, watchable bool) *options {
+	opts := &options{}
+	server := os.Getenv("DISCOGO_SERVER")
+	if server == "" {
+		server = defaultServer
+	}
+	fs.StringVar(&opts.server, "server", server, "DiscoGo server address")
+	fs.StringVar(&opts.apiKey, "api-key", os.Getenv("DISCOGO_API_KEY"), "API key identifying this client to rate limits")
+	fs.StringVar(&opts.output, "o", outputTable, "output format: table, json or yaml")
+	if watchable {
+		fs.BoolVar(&opts.watch, "watch", false, "re-run the command every -interval")
+		fs.DurationVar(&opts.interval, "interval", 2*time.Second, "watch interval")
+	}
+	return opts
+}
+
+func (o *options) validate() error {
+	if !isValidOutput(o.output) {
+		return fmt.Errorf("invalid output format %q (must be table, json or yaml)", o.output)
+	}
+	if o.watch && o.interval <= 0 {
+		return fmt.Errorf("invalid watch interval %s", o.interval)
+	}
+	return nil
+}
+
+// client returns an SDK client for the selected server.
+func (o *options) client() *discogo.Client {
+	var clientOpts []discogo.ClientOption
+	if o.apiKey != "" {
+		clientOpts = append(clientOpts, discogo.WithAPIKey(o.apiKey))
+	}
+	return discogo.NewClient(o.server, clientOpts...)
+}
+
+type command func(args []string) error
+
+var commands = map[string]command{
+	"register":   runRegister,
+	"heartbeat":  runHeartbeat,
+	"report":     runReport,
+	"outcomes":   runOutcomes,
+	"deregister": runDeregister,
+	"discover":   runDiscover,
+	"resolve":    runResolve,
+	"health":     runHealth,
+	"version":    runVersion,
+	"catalog":    runCatalog,
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err := cmd(os.Args[2:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// runWatched executes fn once, or repeatedly when watch mode is enabled.
+func runWatched(opts *options, fn func() error) error {
+	if !opts.watch {
+		return fn()
+	}
+	for {
+		if opts.output == outputTable {
+			// Clear the terminal so the table is redrawn in place
+			fmt.Print("\033[H\033[2J")
+		}
+		fmt.Printf("Every %s: %s\n\n", opts.interval, time.Now().Format(time.RFC3339))
+		if err := fn(); err != nil {
+			printError(err)
+		}
+		time.Sleep(opts.interval)
+	}
+}
+
+// printError writes err to stderr, followed by the rejected fields and
+// accepted values of a server problem response.
+func printError(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+
+	var apiErr *discogo.APIError
+	if !errors.As(err, &apiErr) {
+		return
+	}
+	for _, fe := range apiErr.Errors {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", fe.Field, fe.Message)
+		if len(fe.Allowed) > 0 {
+			fmt.Fprintf(os.Stderr, "    allowed: %s\n", strings.Join(fe.Allowed, ", "))
+		}
+	}
+	if apiErr.Param != "" && len(apiErr.Allowed) > 0 {
+		fmt.Fprintf(os.Stderr, "  allowed %s: %s\n", apiErr.Param, strings.Join(apiErr.Allowed, ", "))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func isValidOutput(format string) bool {
+	return format == outputTable || format == outputJSON || format == outputYAML
+}
+
+// table is the tabular representation of a command result.
+type table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// render writes v in the requested format. Table output uses tbl, which may
+// be nil for results that have no tabular form.
+func render(w io.Writer, format string, v interface{}, tbl *table) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case outputYAML:
+		// Round-trip through JSON so YAML keys follow the API's json tags
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(generic)
+	default:
+		if tbl == nil {
+			return render(w, outputYAML, v, nil)
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(tbl.Headers, "\t"))
+		for _, row := range tbl.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
@@ -1,22 +1,42 @@
 package main
 
 import (
-	"time"
+	"fmt"
+	"os"
 
 	_ "github.com/tahakara/discogo/docs"
 	env "github.com/tahakara/discogo/internal/config"
 	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	"github.com/tahakara/discogo/internal/logger"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
 	"github.com/tahakara/discogo/internal/service"
+	"github.com/tahakara/discogo/internal/utils"
 )
 
 func main() {
-	env.LoadEnv()
-	if serviceconfigloader.LoadAllConfigs() != nil {
-		panic(true)
+	cfg, args, err := env.Load(os.Args[1:])
+	if err != nil {
+		logger.Fatal(err.Error(), 0)
 	}
+	logger.SetColorEnabled(cfg.Log.Color)
+	redishelper.SetAuditRetention(cfg.Admin.AuditRetention())
+	utils.SetMaxBodyBytes(cfg.HTTP.MaxBodyBytes)
 
-	client := service.StartRedisService()
-	client.Set("key", []byte("value"), 10*time.Minute)
+	if err := serviceconfigloader.LoadAllConfigs(cfg.Catalog.Path); err != nil {
+		logger.Fatal(err.Error(), 0)
+	}
+
+	if len(args) > 0 && args[0] == "snapshot" {
+		os.Exit(service.RunSnapshotCommand(cfg, args[1:]))
+	}
+
+	client := service.StartStorageService(cfg.Storage)
+	if client == nil {
+		logger.Fatal(fmt.Sprintf("Registry backend %q is unavailable, exiting", cfg.Storage.Backend), 0)
+	}
+	service.StartCatalogWatcher(cfg.Catalog, client)
+	service.StartHealthChecker(cfg.Probe, client)
+	service.StartOutlierDetector(cfg.Outlier, client)
 	// client.Close() // Ensure the Redis client is closed when the application exits
-	service.StartHTTPServer(client)
+	service.StartHTTPServer(cfg, client)
 }
@@ -15,284 +15,86 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/disco/discover": {
-            "get": {
-                "description": "Retrieves a list of services filtered by query parameters such as service type, provider, region, zone, network ID, subnet ID, instance ID, and version. Supports pagination.",
+        "/v1/admin/catalog/providers": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Adds a provider that is not defined in conf.json. It is stored in the registry backend and accepted for registration immediately.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "DiscoGo"
+                    "Admin"
                 ],
-                "summary": "Discover services",
+                "summary": "Create a custom provider",
                 "parameters": [
                     {
-                        "enum": [
-                            "mock",
-                            "test",
-                            "perftest",
-                            "loadgen",
-                            "staging",
-                            "dev",
-                            "debug",
-                            "mq",
-                            "eventbus",
-                            "notify",
-                            "email",
-                            "sms",
-                            "push",
-                            "inmsg",
-                            "chat",
-                            "monitor",
-                            "log",
-                            "alert",
-                            "health",
-                            "cb",
-                            "lb",
-                            "discovery",
-                            "config",
-                            "util",
-                            "helper",
-                            "migrate",
-                            "cleanup",
-                            "archive",
-                            "maint",
-                            "other",
-                            "stream",
-                            "audio",
-                            "live",
-                            "transcode",
-                            "abr",
-                            "drm",
-                            "quality",
-                            "user",
-                            "auth",
-                            "authz",
-                            "profile",
-                            "prefs",
-                            "social",
-                            "watchlist",
-                            "history",
-                            "web",
-                            "mobile",
-                            "admin",
-                            "cdn",
-                            "assets",
-                            "img",
-                            "video",
-                            "catalog",
-                            "recommend",
-                            "search",
-                            "personal",
-                            "ingest",
-                            "metadata",
-                            "subtitle",
-                            "thumb",
-                            "sub",
-                            "billing",
-                            "payment",
-                            "pricing",
-                            "trial",
-                            "entitle",
-                            "revenue",
-                            "secgw",
-                            "waf",
-                            "fraud",
-                            "audit",
-                            "encrypt",
-                            "kms",
-                            "comply",
-                            "threat",
-                            "workflow",
-                            "scheduler",
-                            "pipeline",
-                            "etl",
-                            "batch",
-                            "eventproc",
-                            "orchestrate",
-                            "3rdapi",
-                            "partner",
-                            "social",
-                            "paygate",
-                            "cdnint",
-                            "cloudstor",
-                            "tracker",
-                            "gw",
-                            "rest",
-                            "graphql",
-                            "grpc",
-                            "ws",
-                            "webhook",
-                            "ratelimit",
-                            "db",
-                            "analyticsdb",
-                            "cache",
-                            "file",
-                            "object",
-                            "datalake",
-                            "backup",
-                            "sync",
-                            "analytics",
-                            "rtanalytics",
-                            "abtest",
-                            "flags",
-                            "ml",
-                            "ds",
-                            "report",
-                            "metrics"
-                        ],
-                        "type": "string",
-                        "description": "Service type to discover",
-                        "name": "servicetype",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "enum": [
-                            "healthy",
-                            "unknown",
-                            "suspicious",
-                            "registered",
-                            "deregistered"
-                        ],
-                        "type": "string",
-                        "description": "Service status",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "provider1",
-                            "provider2",
-                            "..."
-                        ],
-                        "type": "string",
-                        "description": "Service provider",
-                        "name": "provider",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Region",
-                        "name": "region",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Zone",
-                        "name": "zone",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Network ID",
-                        "name": "networkid",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Subnet ID",
-                        "name": "subnetid",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Instance ID",
-                        "name": "instanceid",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Service version",
-                        "name": "version",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 10,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Number of results per page (1-10)",
-                        "name": "pagesize",
-                        "in": "query"
-                    },
-                    {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Page offset (\u003e= 0)",
-                        "name": "pageoffset",
-                        "in": "query"
+                        "description": "Provider",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.CreateCustomProviderRequestBody"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "List of discovered services",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/routes.DiscoverResponse"
+                            "$ref": "#/definitions/routes.CustomProviderResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request parameters",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/routes.DiscoverResponse"
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/routes.DiscoverResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/disco/health": {
-            "get": {
-                "description": "Returns the health status of the API and Redis connection",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "DiscoGo"
-                ],
-                "summary": "Health check endpoint",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/routes.HealthCheckResponse"
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/routes.HealthCheckResponse"
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             }
         },
-        "/disco/heartbeat": {
-            "get": {
-                "description": "Checks the health of a service by UUID and updates its status in Redis.",
-                "consumes": [
-                    "application/json"
+        "/v1/admin/catalog/providers/{short}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "Rejects new registrations with the provider. Existing instances stay discoverable until they expire.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "DiscoGo"
+                    "Admin"
                 ],
-                "summary": "Heartbeat endpoint",
+                "summary": "Retire a custom provider",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Service UUID",
-                        "name": "uuid",
-                        "in": "query",
+                        "description": "Provider short name",
+                        "name": "short",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -300,45 +102,56 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.HeartbeatResponse"
+                            "$ref": "#/definitions/routes.CustomProviderResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/routes.HeartbeatResponse"
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/routes.HeartbeatResponse"
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/disco/register": {
-            "post": {
-                "description": "Registers a new service instance with the discovery system.",
-                "consumes": [
+            },
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Changes the name of a custom provider, or retires / restores it.",
+                "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "DiscoGo"
+                    "Admin"
                 ],
-                "summary": "Register a new service",
+                "summary": "Update a custom provider",
                 "parameters": [
                     {
-                        "description": "Service registration payload",
+                        "type": "string",
+                        "description": "Provider short name",
+                        "name": "short",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requestdto.RegisterRequestDTO"
+                            "$ref": "#/definitions/routes.PatchCustomProviderRequestBody"
                         }
                     }
                 ],
@@ -346,111 +159,2948 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.RegisterResponse"
+                            "$ref": "#/definitions/routes.CustomProviderResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/routes.RegisterResponse"
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/catalog/types": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Adds a service type that is not defined in conf.json. It is stored in the registry backend and accepted for registration immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a custom service type",
+                "parameters": [
+                    {
+                        "description": "Service type",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.CreateCustomServiceTypeRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CustomServiceTypeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
                     "409": {
                         "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/routes.RegisterResponse"
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             }
         },
-        "/disco/version": {
-            "get": {
-                "description": "Retrieves the version information of the service",
+        "/v1/admin/catalog/types/{short}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Rejects new registrations of the type. Existing instances stay discoverable until they expire.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Retire a custom service type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type short name",
+                        "name": "short",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CustomServiceTypeResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Changes the name, description or group of a custom service type, or retires / restores it.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update a custom service type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type short name",
+                        "name": "short",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.PatchCustomServiceTypeRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CustomServiceTypeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/reload": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Re-reads the catalog file (DISCOGO_CONFIG_PATH) and swaps in the new service types and providers. On a parse or validation error the current catalog stays active.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reload the service catalog",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CatalogReloadResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Dumps every registered service entry with its remaining TTL as a versioned JSON snapshot.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Export registry snapshot",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/redishelper.Snapshot"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Restores a snapshot produced by the export endpoint. Entries that share a UUID or an instance identity with a stored entry are skipped or overwrite it according to mode.",
+                "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
-                    "DiscoGo"
+                    "Admin"
+                ],
+                "summary": "Import registry snapshot",
+                "parameters": [
+                    {
+                        "enum": [
+                            "skip",
+                            "overwrite"
+                        ],
+                        "type": "string",
+                        "description": "Conflict mode",
+                        "name": "mode",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Snapshot",
+                        "name": "snapshot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redishelper.Snapshot"
+                        }
+                    }
                 ],
-                "summary": "Get service version",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.VersionResponse"
+                            "$ref": "#/definitions/routes.SnapshotImportResponse"
                         }
                     },
-                    "406": {
-                        "description": "Not Acceptable",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "BODY_TOO_LARGE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/utils.JSONResponse"
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "requestdto.RegisterRequestDTO": {
-            "type": "object",
-            "required": [
-                "cluster",
-                "instanceID",
-                "name",
-                "networkDomain",
-                "networkID",
-                "provider",
-                "region",
-                "subnetID",
-                "type",
-                "version",
-                "zone"
-            ],
-            "properties": {
-                "addr4": {
+        },
+        "/v1/audit": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns registry mutations (register, status changes, reports, deregister, expiry and admin overrides) in chronological order.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Query the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the time range (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time range (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events of this service UUID. At most 10000 events of the range are scanned, oldest first; narrow it with from and to on long logs",
+                        "name": "uuid",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Maximum number of events (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.AuditResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/bulk/deregister": {
+            "post": {
+                "description": "Removes up to BULK_MAX_ITEMS instances in one request. Each item gets its own result; unknown UUIDs answer 404.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Deregister several services",
+                "parameters": [
+                    {
+                        "description": "Service UUIDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.BulkUUIDRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.BulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_BUSY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "BATCH_TOO_LARGE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/bulk/heartbeat": {
+            "post": {
+                "description": "Refreshes up to BULK_MAX_ITEMS instances in one request, given as serviceUUIDs or as heartbeats with a payload each. Each item behaves like POST /v1/heartbeat/{uuid} and gets its own result.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Send heartbeats for several services",
+                "parameters": [
+                    {
+                        "description": "Service UUIDs or heartbeats",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.BulkHeartbeatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.BulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_BUSY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "BATCH_TOO_LARGE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/bulk/register": {
+            "post": {
+                "description": "Registers up to BULK_MAX_ITEMS instances in one request. Each item behaves like POST /v1/register and gets its own result; the request itself only fails when the batch is malformed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Register several services",
+                "parameters": [
+                    {
+                        "description": "Registration payloads",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.BulkRegisterRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace existing registrations of the same identity",
+                        "name": "replace",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.BulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY or INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_BUSY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "BATCH_TOO_LARGE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/catalog/providers": {
+            "get": {
+                "description": "Returns every provider from conf.json and the custom providers managed through the admin API.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "List providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CatalogProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/catalog/types": {
+            "get": {
+                "description": "Returns every service type group from the catalog, including custom types, with names, descriptions and live instance counts per status.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "List service types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CatalogTypesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/catalog/types/{short}": {
+            "get": {
+                "description": "Returns the name, description, group and live instance counts per status of one service type.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Inspect a service type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type short name",
+                        "name": "short",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CatalogTypeResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/deregister": {
+            "post": {
+                "description": "Deregisters a service from the registry using its UUID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Deregister a service",
+                "parameters": [
+                    {
+                        "description": "Service UUID to deregister",
+                        "name": "DeregisterRequestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.DeregisterRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Service deregistered successfully",
+                        "schema": {
+                            "$ref": "#/definitions/routes.DeregisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY or INVALID_UUID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "SERVICE_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_BUSY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/discover": {
+            "get": {
+                "description": "Retrieves a list of services filtered by query parameters such as service type, provider, region, zone, network ID, subnet ID, instance ID, and version. Supports pagination.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Discover services",
+                "parameters": [
+                    {
+                        "enum": [
+                            "mock",
+                            "test",
+                            "perftest",
+                            "loadgen",
+                            "staging",
+                            "dev",
+                            "debug",
+                            "mq",
+                            "eventbus",
+                            "notify",
+                            "email",
+                            "sms",
+                            "push",
+                            "inmsg",
+                            "chat",
+                            "monitor",
+                            "log",
+                            "alert",
+                            "health",
+                            "cb",
+                            "lb",
+                            "discovery",
+                            "config",
+                            "util",
+                            "helper",
+                            "migrate",
+                            "cleanup",
+                            "archive",
+                            "maint",
+                            "other",
+                            "stream",
+                            "audio",
+                            "live",
+                            "transcode",
+                            "abr",
+                            "drm",
+                            "quality",
+                            "user",
+                            "auth",
+                            "authz",
+                            "profile",
+                            "prefs",
+                            "social",
+                            "watchlist",
+                            "history",
+                            "web",
+                            "mobile",
+                            "admin",
+                            "cdn",
+                            "assets",
+                            "img",
+                            "video",
+                            "catalog",
+                            "recommend",
+                            "search",
+                            "personal",
+                            "ingest",
+                            "metadata",
+                            "subtitle",
+                            "thumb",
+                            "sub",
+                            "billing",
+                            "payment",
+                            "pricing",
+                            "trial",
+                            "entitle",
+                            "revenue",
+                            "secgw",
+                            "waf",
+                            "fraud",
+                            "audit",
+                            "encrypt",
+                            "kms",
+                            "comply",
+                            "threat",
+                            "workflow",
+                            "scheduler",
+                            "pipeline",
+                            "etl",
+                            "batch",
+                            "eventproc",
+                            "orchestrate",
+                            "3rdapi",
+                            "partner",
+                            "sociallogin",
+                            "paygate",
+                            "cdnint",
+                            "cloudstor",
+                            "tracker",
+                            "gw",
+                            "rest",
+                            "graphql",
+                            "grpc",
+                            "ws",
+                            "webhook",
+                            "ratelimit",
+                            "db",
+                            "analyticsdb",
+                            "cache",
+                            "file",
+                            "object",
+                            "datalake",
+                            "backup",
+                            "sync",
+                            "analytics",
+                            "rtanalytics",
+                            "abtest",
+                            "flags",
+                            "ml",
+                            "ds",
+                            "report",
+                            "metrics"
+                        ],
+                        "type": "string",
+                        "description": "Service type to discover",
+                        "name": "servicetype",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "healthy",
+                            "degraded",
+                            "unknown",
+                            "suspicious",
+                            "registered",
+                            "deregistered"
+                        ],
+                        "type": "string",
+                        "description": "Service status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "provider1",
+                            "provider2",
+                            "..."
+                        ],
+                        "type": "string",
+                        "description": "Service provider",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Region",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Zone",
+                        "name": "zone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Network ID",
+                        "name": "networkid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subnet ID",
+                        "name": "subnetid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Instance ID",
+                        "name": "instanceid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service version",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of results per page (1-10)",
+                        "name": "pagesize",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Page offset (\u003e= 0)",
+                        "name": "pageoffset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of discovered services",
+                        "schema": {
+                            "$ref": "#/definitions/routes.DiscoverResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER, with the accepted values in allowed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/health": {
+            "get": {
+                "description": "Returns the health status of the API and its storage backend",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.HealthCheckResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/heartbeat/{uuid}": {
+            "post": {
+                "description": "Checks the health of a service by UUID and updates its status in Redis. The optional body reports load, a self-status (ok, degraded, failing) and metadata; degraded instances are discoverable with status=degraded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Heartbeat endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Load and health report",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/requestdto.HeartbeatRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.HeartbeatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_UUID, INVALID_BODY or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "SERVICE_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_SUSPICIOUS, ILLEGAL_TRANSITION or SERVICE_BUSY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/outcomes": {
+            "post": {
+                "description": "Adds the successful and failed requests a registered instance saw per target instance to the current aggregation interval. At the end of each interval the error rate of every instance is compared with the other instances of its type, and outliers are ejected from discovery for a growing time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Report request outcomes",
+                "parameters": [
+                    {
+                        "description": "Request outcomes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requestdto.OutcomesRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.OutcomesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "OUTLIERS_DISABLED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "BATCH_TOO_LARGE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "REPORTER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/register": {
+            "post": {
+                "description": "Registers a service instance with the discovery system. Registering an identity (type, provider, region, zone, network, subnet, instance, version) that is already registered returns the existing UUID unless replace=true. The optional Check declares an active HTTP, TCP or gRPC health check run by DiscoGo. Validation messages follow the Accept-Language header (en, tr).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Register a new service",
+                "parameters": [
+                    {
+                        "description": "Service registration payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requestdto.RegisterRequestDTO"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace an existing registration of the same identity",
+                        "name": "replace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Returns the original registration when a request is retried",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of validation messages (en, tr)",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.RegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "REGISTRATION_IN_PROGRESS",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "IDEMPOTENCY_KEY_REUSED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/report": {
+            "post": {
+                "description": "Records a report by one registered instance about another. Each reporter counts once within the report window and reports lose weight as they age; the instance becomes suspicious once the weighted number of reporters reaches the threshold.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Report a misbehaving service",
+                "parameters": [
+                    {
+                        "description": "Report",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requestdto.ReportRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "SERVICE_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_BUSY",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "REPORTER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/services": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Lists full service entries across all service types. Every filter is optional.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List service instances",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type",
+                        "name": "servicetype",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service provider",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Region",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Zone",
+                        "name": "zone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Network ID",
+                        "name": "networkid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subnet ID",
+                        "name": "subnetid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Instance ID",
+                        "name": "instanceid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service version",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of results per page (1-100)",
+                        "name": "pagesize",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Page offset (\u003e= 0)",
+                        "name": "pageoffset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.ServiceListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/services/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the complete service entry with its remaining TTL in seconds.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Inspect a service instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.ServiceDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Removes a service entry immediately, regardless of its status.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Force-evict a service instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.ServiceDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Overrides the status of a service and/or resets its report count. The remaining TTL is preserved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Override a service instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.PatchServiceRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.ServiceDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/services/{uuid}/history": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the latest status transitions of a service, oldest first, with their trigger, reason and time.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Status history of a service instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.ServiceHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/version": {
+            "get": {
+                "description": "Retrieves the version information of the service",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "DiscoGo"
+                ],
+                "summary": "Get service version",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.VersionResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/services/{type}/instances": {
+            "get": {
+                "description": "Lists the instances of a service type. Accepts the discover filters (status, provider, region, zone, networkid, subnetid, instanceid, version, pagesize, pageoffset) as query parameters.",
+                "produces": [
+                    "application/vnd.discogo.v2+json",
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "List instances of a service type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.InstanceListV2"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "UNKNOWN_SERVICE_TYPE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers an instance. The body is the v1 registration payload; Type may be omitted and defaults to the path. An instance with the same identity is returned with 200 unless replace=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/vnd.discogo.v2+json",
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Register an instance of a service type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Service registration payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requestdto.RegisterRequestDTO"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace an existing instance of the same identity",
+                        "name": "replace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Returns the original registration when a request is retried",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Existing instance",
+                        "schema": {
+                            "$ref": "#/definitions/routes.InstanceRegistrationV2"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/routes.InstanceRegistrationV2"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_BODY, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "UNKNOWN_SERVICE_TYPE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "REGISTRATION_IN_PROGRESS",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "IDEMPOTENCY_KEY_REUSED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "STORAGE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/services/{type}/instances/{uuid}": {
+            "get": {
+                "produces": [
+                    "application/vnd.discogo.v2+json",
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Get an instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.InstanceV2"
+                        }
+                    },
+                    "404": {
+                        "description": "UNKNOWN_SERVICE_TYPE or SERVICE_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Deregister an instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "UNKNOWN_SERVICE_TYPE or SERVICE_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/services/{type}/instances/{uuid}/heartbeat": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Send a heartbeat for an instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Load and health report",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/requestdto.HeartbeatRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "INVALID_BODY or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "UNKNOWN_SERVICE_TYPE or SERVICE_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "SERVICE_SUSPICIOUS or ILLEGAL_TRANSITION",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "redishelper.AuditAction": {
+            "type": "string",
+            "enum": [
+                "register",
+                "status-change",
+                "report",
+                "outlier-eject",
+                "deregister",
+                "expire",
+                "admin-override",
+                "admin-evict",
+                "snapshot-import",
+                "catalog-create",
+                "catalog-update",
+                "catalog-retire"
+            ],
+            "x-enum-varnames": [
+                "AuditRegister",
+                "AuditStatusChange",
+                "AuditReport",
+                "AuditOutlierEject",
+                "AuditDeregister",
+                "AuditExpire",
+                "AuditAdminOverride",
+                "AuditAdminEvict",
+                "AuditSnapshotImport",
+                "AuditCatalogCreate",
+                "AuditCatalogUpdate",
+                "AuditCatalogRetire"
+            ]
+        },
+        "redishelper.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/redishelper.AuditAction"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "newStatus": {
+                    "$ref": "#/definitions/redishelper.ServiceStatus"
+                },
+                "oldStatus": {
+                    "$ref": "#/definitions/redishelper.ServiceStatus"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "serviceType": {
+                    "type": "string"
+                },
+                "serviceUUID": {
+                    "type": "string"
+                },
+                "sourceIP": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "redishelper.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "consecutiveFailures": {
+                    "description": "(DISCO) Failed checks since the last passing one",
+                    "type": "integer"
+                },
+                "expectedStatus": {
+                    "description": "(Client) HTTP status that counts as passing",
+                    "type": "integer"
+                },
+                "failing": {
+                    "description": "(DISCO) Whether the failure threshold was reached",
+                    "type": "boolean"
+                },
+                "interval": {
+                    "description": "(Client) seconds between checks, 0 uses the configured default",
+                    "type": "integer"
+                },
+                "lastCheckedAt": {
+                    "description": "(DISCO) RFC3339 timestamp of the last check",
+                    "type": "string"
+                },
+                "lastOutput": {
+                    "description": "(DISCO) Error of the last failed check",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "(Client) HTTP path",
+                    "type": "string"
+                },
+                "service": {
+                    "description": "(Client) gRPC service name, empty for the whole server",
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "(Client) seconds before a check fails, 0 uses the configured default",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "(Client) http, tcp or grpc",
+                    "type": "string"
+                }
+            }
+        },
+        "redishelper.ServiceEntry": {
+            "type": "object",
+            "properties": {
+                "addr4": {
+                    "description": "IPv4 address",
+                    "type": "string"
+                },
+                "addr6": {
+                    "description": "IPv6 address",
+                    "type": "string"
+                },
+                "check": {
+                    "description": "(Client | DISCO) Active health check, if one was declared",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/redishelper.HealthCheck"
+                        }
+                    ]
+                },
+                "cluster": {
+                    "description": "cluster name, e.g., xyz-cluster",
+                    "type": "string"
+                },
+                "cpuload": {
+                    "description": "(Client) CPU load in percent, as of the last heartbeat",
+                    "type": "number",
+                    "format": "float64"
+                },
+                "createdAt": {
+                    "description": "(DISCO) RFC3339 Unix timestamp of creation",
+                    "type": "string"
+                },
+                "ejectedUntil": {
+                    "description": "(DISCO) RFC3339 end of the current outlier ejection",
+                    "type": "string"
+                },
+                "ejectionCount": {
+                    "description": "(DISCO) Ejection backoff, up on each ejection and down on each clean interval",
+                    "type": "integer"
+                },
+                "errorRate": {
+                    "description": "(DISCO) Client-reported error rate that caused the last ejection",
+                    "type": "number",
+                    "format": "float64"
+                },
+                "hasLoad": {
+                    "description": "(DISCO) Whether a heartbeat has reported load",
+                    "type": "boolean"
+                },
+                "heardCount": {
+                    "description": "(DISCO) Count of heartbeats received",
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "inFlight": {
+                    "description": "(Client) Requests in flight, as of the last heartbeat",
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "instanceID": {
+                    "description": "unique instance identifier",
+                    "type": "string"
+                },
+                "lastHeardAt": {
+                    "description": "(DISCO) RFC3339 Unix timestamp of last heartbeat",
+                    "type": "string"
+                },
+                "lastReportAt": {
+                    "description": "(DISCO | Client) RFC3339 Unix timestamp of last report",
+                    "type": "string"
+                },
+                "memoryLoad": {
+                    "description": "(Client) Memory load in percent, as of the last heartbeat",
+                    "type": "number",
+                    "format": "float64"
+                },
+                "metadata": {
+                    "description": "(DISCO | Client) Additional metadata",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "description": "xyz-service human-readable name",
+                    "type": "string"
+                },
+                "networkDomain": {
+                    "description": "e.g., internal, public, dmz",
+                    "type": "string"
+                },
+                "networkID": {
+                    "description": "network identifier vpc-12345, vnet-12345, etc.",
+                    "type": "string"
+                },
+                "port4": {
+                    "description": "IPv4 port",
+                    "type": "integer"
+                },
+                "port6": {
+                    "description": "IPv6 port",
+                    "type": "integer"
+                },
+                "provider": {
+                    "description": "aws, gcp, azure, etc.",
+                    "type": "string"
+                },
+                "recoveryHeartbeats": {
+                    "description": "(DISCO) Good heartbeats in a row while suspicious",
+                    "type": "integer"
+                },
+                "region": {
+                    "description": "region of the service, e.g., us-east-1",
+                    "type": "string"
+                },
+                "reportCount": {
+                    "description": "(DISCO | Client) Count of reports received",
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "reports": {
+                    "description": "(Client) Reports within the report window, one per reporter",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redishelper.ServiceReport"
+                    }
+                },
+                "selfStatus": {
+                    "description": "(Client) ok, degraded or failing, as of the last heartbeat",
+                    "type": "string"
+                },
+                "serviceUUID": {
+                    "description": "(DISCO) unique service identifier",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "(DISCO) e.g., healthy, degraded, offline",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/redishelper.ServiceStatus"
+                        }
+                    ]
+                },
+                "statusHistory": {
+                    "description": "(DISCO) Latest status transitions, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redishelper.StatusTransition"
+                    }
+                },
+                "subnetID": {
+                    "description": "subnet identifier, e.g., subnet-12345",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "key-value pairs for additional metadata",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ttl": {
+                    "description": "(DISCO) Time to live in seconds",
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "type": {
+                    "description": "type of service (shortname, örn: \"gw\")",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "version of the service",
+                    "type": "string"
+                },
+                "zone": {
+                    "description": "availability zone, e.g., us-east-1a",
+                    "type": "string"
+                }
+            }
+        },
+        "redishelper.ServiceReport": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "(DISCO) RFC3339 timestamp of the report",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "(Client) Free-form reason",
+                    "type": "string"
+                },
+                "reporterUUID": {
+                    "description": "(Client) UUID of the reporting instance",
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "(DISCO) Reporter reputation at report time, 0-1",
+                    "type": "number",
+                    "format": "float64"
+                }
+            }
+        },
+        "redishelper.ServiceStatus": {
+            "type": "string",
+            "enum": [
+                "*",
+                "unknown",
+                "registered",
+                "healthy",
+                "deregistered",
+                "suspicious",
+                "degraded"
+            ],
+            "x-enum-varnames": [
+                "StatusAny",
+                "StatusUnknown",
+                "StatusRegistered",
+                "StatusHealthy",
+                "StatusDeregistered",
+                "StatusSuspicious",
+                "StatusDegraded"
+            ]
+        },
+        "redishelper.Snapshot": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redishelper.SnapshotEntry"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "redishelper.SnapshotEntry": {
+            "type": "object",
+            "properties": {
+                "entry": {
+                    "$ref": "#/definitions/redishelper.ServiceEntry"
+                },
+                "ttl": {
+                    "description": "remaining time to live in seconds at export time",
+                    "type": "integer"
+                }
+            }
+        },
+        "redishelper.SnapshotImportResult": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "overwritten": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "redishelper.StatusTransition": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "(DISCO) RFC3339 timestamp of the transition",
+                    "type": "string"
+                },
+                "from": {
+                    "description": "(DISCO) Status before the transition, empty for the initial one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/redishelper.ServiceStatus"
+                        }
+                    ]
+                },
+                "reason": {
+                    "description": "(DISCO) Details, e.g. the failing check output",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "(DISCO) Status after the transition",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/redishelper.ServiceStatus"
+                        }
+                    ]
+                },
+                "trigger": {
+                    "description": "(DISCO) What caused the transition",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/redishelper.StatusTrigger"
+                        }
+                    ]
+                }
+            }
+        },
+        "redishelper.StatusTrigger": {
+            "type": "string",
+            "enum": [
+                "register",
+                "heartbeat",
+                "health-check",
+                "reports",
+                "recovery",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "TriggerRegister",
+                "TriggerHeartbeat",
+                "TriggerHealthCheck",
+                "TriggerReports",
+                "TriggerRecovery",
+                "TriggerAdmin"
+            ]
+        },
+        "requestdto.CheckDTO": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "expectedStatus": {
+                    "description": "http only, defaults to 200",
+                    "type": "integer",
+                    "maximum": 599,
+                    "minimum": 100
+                },
+                "interval": {
+                    "description": "seconds, defaults to PROBE_INTERVAL",
+                    "type": "integer",
+                    "maximum": 3600,
+                    "minimum": 1
+                },
+                "path": {
+                    "description": "http only, defaults to \"/\"",
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "service": {
+                    "description": "grpc only, empty checks the whole server",
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "timeout": {
+                    "description": "seconds, defaults to PROBE_TIMEOUT",
+                    "type": "integer",
+                    "maximum": 60,
+                    "minimum": 1
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "http",
+                        "tcp",
+                        "grpc"
+                    ]
+                }
+            }
+        },
+        "requestdto.HeartbeatRequestDTO": {
+            "type": "object",
+            "properties": {
+                "cpuload": {
+                    "description": "percent",
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "inFlight": {
+                    "description": "requests being served",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "memoryLoad": {
+                    "description": "percent",
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "metadata": {
+                    "description": "merged into the entry (at most 32 keys); an empty value removes the key",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "degraded",
+                        "failing"
+                    ]
+                }
+            }
+        },
+        "requestdto.OutcomeDTO": {
+            "type": "object",
+            "required": [
+                "serviceUUID"
+            ],
+            "properties": {
+                "failures": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "serviceUUID": {
+                    "type": "string"
+                },
+                "successes": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "requestdto.OutcomesRequestDTO": {
+            "type": "object",
+            "required": [
+                "reporterUUID"
+            ],
+            "properties": {
+                "outcomes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/requestdto.OutcomeDTO"
+                    }
+                },
+                "reporterUUID": {
+                    "type": "string"
+                }
+            }
+        },
+        "requestdto.RegisterRequestDTO": {
+            "type": "object",
+            "required": [
+                "cluster",
+                "instanceID",
+                "name",
+                "networkDomain",
+                "networkID",
+                "provider",
+                "region",
+                "subnetID",
+                "type",
+                "version",
+                "zone"
+            ],
+            "properties": {
+                "addr4": {
+                    "type": "string"
+                },
+                "addr6": {
+                    "type": "string"
+                },
+                "check": {
+                    "$ref": "#/definitions/requestdto.CheckDTO"
+                },
+                "cluster": {
+                    "type": "string"
+                },
+                "instanceID": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 3
+                },
+                "networkDomain": {
+                    "type": "string"
+                },
+                "networkID": {
+                    "type": "string"
+                },
+                "port4": {
+                    "type": "integer",
+                    "maximum": 65535,
+                    "minimum": 1
+                },
+                "port6": {
+                    "type": "integer",
+                    "maximum": 65535,
+                    "minimum": 1
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "subnetID": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "requestdto.ReportRequestDTO": {
+            "type": "object",
+            "required": [
+                "reporterUUID",
+                "serviceUUID"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "reporterUUID": {
+                    "type": "string"
+                },
+                "serviceUUID": {
+                    "description": "the reported instance",
+                    "type": "string"
+                }
+            }
+        },
+        "routes.AuditResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redishelper.AuditEvent"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.BulkHeartbeatItem": {
+            "type": "object",
+            "properties": {
+                "payload": {
+                    "$ref": "#/definitions/requestdto.HeartbeatRequestDTO"
+                },
+                "serviceUUID": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.BulkHeartbeatRequest": {
+            "type": "object",
+            "properties": {
+                "heartbeats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.BulkHeartbeatItem"
+                    }
+                },
+                "serviceUUIDs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "routes.BulkItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/utils.Problem"
+                },
+                "existing": {
+                    "type": "boolean"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "replacedUUID": {
+                    "type": "string"
+                },
+                "serviceUUID": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.BulkRegisterRequest": {
+            "type": "object",
+            "properties": {
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/requestdto.RegisterRequestDTO"
+                    }
+                }
+            }
+        },
+        "routes.BulkResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "healthCheckCycle": {
+                    "description": "HealthCheckCycle is set for registrations.",
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.BulkItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.BulkUUIDRequest": {
+            "type": "object",
+            "properties": {
+                "serviceUUIDs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "routes.CatalogInstances": {
+            "type": "object",
+            "properties": {
+                "byStatus": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.CatalogProvider": {
+            "type": "object",
+            "properties": {
+                "custom": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "retired": {
+                    "type": "boolean"
+                },
+                "short": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CatalogProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.CatalogProvider"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CatalogReloadResponse": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "$ref": "#/definitions/serviceconfigloader.CatalogDiff"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CatalogServiceType": {
+            "type": "object",
+            "properties": {
+                "custom": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "instances": {
+                    "$ref": "#/definitions/routes.CatalogInstances"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "retired": {
+                    "type": "boolean"
+                },
+                "short": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CatalogTypeGroup": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.CatalogServiceType"
+                    }
+                }
+            }
+        },
+        "routes.CatalogTypeResponse": {
+            "type": "object",
+            "properties": {
+                "serviceType": {
+                    "$ref": "#/definitions/routes.CatalogServiceType"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CatalogTypesResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.CatalogTypeGroup"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CreateCustomProviderRequestBody": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "short": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CreateCustomServiceTypeRequestBody": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "short": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CustomProviderResponse": {
+            "type": "object",
+            "properties": {
+                "provider": {
+                    "$ref": "#/definitions/serviceconfigloader.CustomProvider"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.CustomServiceTypeResponse": {
+            "type": "object",
+            "properties": {
+                "serviceType": {
+                    "$ref": "#/definitions/serviceconfigloader.CustomServiceType"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.DeregisterRequestBody": {
+            "type": "object",
+            "properties": {
+                "serviceUUID": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.DeregisterResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.DiscoverResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.ServiceInfo"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.HealthCheckResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "$ref": "#/definitions/routes.HealthStatus"
+                }
+            }
+        },
+        "routes.HealthStatus": {
+            "type": "string",
+            "enum": [
+                "healthy",
+                "unhealthy"
+            ],
+            "x-enum-varnames": [
+                "StatusHealthy",
+                "StatusUnhealthy"
+            ]
+        },
+        "routes.HeartbeatResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.InstanceListV2": {
+            "type": "object",
+            "properties": {
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.InstanceV2"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.InstanceRegistrationV2": {
+            "type": "object",
+            "properties": {
+                "address": {
                     "type": "string"
                 },
-                "addr6": {
-                    "type": "string"
+                "healthCheckCycle": {
+                    "description": "HealthCheckCycle is the expected heartbeat interval in seconds.",
+                    "type": "integer"
                 },
-                "cluster": {
+                "id": {
                     "type": "string"
                 },
-                "instanceID": {
+                "lastHeardAt": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string",
-                    "maxLength": 64,
-                    "minLength": 3
+                "load": {
+                    "$ref": "#/definitions/routes.LoadInfo"
                 },
-                "networkDomain": {
-                    "type": "string"
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "networkID": {
+                "name": {
                     "type": "string"
                 },
-                "port4": {
-                    "type": "integer",
-                    "maximum": 65535,
-                    "minimum": 1
-                },
-                "port6": {
-                    "type": "integer",
-                    "maximum": 65535,
-                    "minimum": 1
-                },
                 "provider": {
                     "type": "string"
                 },
                 "region": {
                     "type": "string"
                 },
-                "subnetID": {
+                "replacedId": {
+                    "description": "ReplacedID is the instance removed by a replacing registration.",
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 },
                 "tags": {
@@ -470,66 +3120,126 @@ const docTemplate = `{
                 }
             }
         },
-        "routes.DiscoverResponse": {
+        "routes.InstanceV2": {
             "type": "object",
             "properties": {
-                "message": {
+                "address": {
                     "type": "string"
                 },
-                "providerTypes": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "id": {
+                    "type": "string"
                 },
-                "serviceTypes": {
-                    "type": "array",
-                    "items": {
+                "lastHeardAt": {
+                    "type": "string"
+                },
+                "load": {
+                    "$ref": "#/definitions/routes.LoadInfo"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "string"
                     }
                 },
-                "services": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/routes.ServiceInfo"
-                    }
+                "name": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
                 },
                 "status": {
                     "type": "string"
                 },
-                "statusTypes": {
-                    "type": "array",
-                    "items": {
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "string"
                     }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "zone": {
+                    "type": "string"
                 }
             }
         },
-        "routes.HealthCheckResponse": {
+        "routes.LoadInfo": {
+            "type": "object",
+            "properties": {
+                "cpu": {
+                    "description": "percent",
+                    "type": "number"
+                },
+                "inFlight": {
+                    "description": "requests being served",
+                    "type": "integer"
+                },
+                "memory": {
+                    "description": "percent",
+                    "type": "number"
+                }
+            }
+        },
+        "routes.OutcomesResponse": {
             "type": "object",
             "properties": {
+                "accepted": {
+                    "description": "Accepted is the number of target instances counted.",
+                    "type": "integer"
+                },
+                "interval": {
+                    "description": "Interval is the aggregation interval in seconds; submit about once per interval.",
+                    "type": "integer"
+                },
                 "status": {
-                    "$ref": "#/definitions/routes.HealthStatus"
+                    "type": "string"
                 }
             }
         },
-        "routes.HealthStatus": {
-            "type": "string",
-            "enum": [
-                "healthy",
-                "unhealthy"
-            ],
-            "x-enum-varnames": [
-                "StatusHealthy",
-                "StatusUnhealthy"
-            ]
+        "routes.PatchCustomProviderRequestBody": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "retired": {
+                    "type": "boolean"
+                }
+            }
         },
-        "routes.HeartbeatResponse": {
+        "routes.PatchCustomServiceTypeRequestBody": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "retired": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "routes.PatchServiceRequestBody": {
             "type": "object",
             "properties": {
                 "reason": {
                     "type": "string"
                 },
+                "resetReportCount": {
+                    "type": "boolean"
+                },
                 "status": {
                     "type": "string"
                 }
@@ -538,23 +3248,81 @@ const docTemplate = `{
         "routes.RegisterResponse": {
             "type": "object",
             "properties": {
-                "health_check_cycle": {
-                    "description": "Only on success",
+                "existing": {
+                    "description": "Existing is set when the identity was already registered and its UUID is returned.",
+                    "type": "boolean"
+                },
+                "healthCheckCycle": {
                     "type": "integer"
                 },
-                "reason": {
-                    "description": "Only on error",
+                "replacedUUID": {
+                    "description": "ReplacedUUID is the entry removed by a replacing registration.",
+                    "type": "string"
+                },
+                "serviceUUID": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.ReportResponse": {
+            "type": "object",
+            "properties": {
+                "reporters": {
+                    "description": "Reporters is the number of distinct reporters within the report window.",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Score is the decayed, weighted report count compared to the threshold.",
+                    "type": "number"
+                },
+                "serviceStatus": {
+                    "$ref": "#/definitions/redishelper.ServiceStatus"
+                },
+                "serviceUUID": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.ServiceDetailResponse": {
+            "type": "object",
+            "properties": {
+                "remainingTTL": {
+                    "description": "seconds",
+                    "type": "integer"
+                },
+                "service": {
+                    "$ref": "#/definitions/redishelper.ServiceEntry"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.ServiceHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "history": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/redishelper.StatusTransition"
                     }
                 },
-                "service_uuid": {
-                    "description": "Only on success",
+                "serviceStatus": {
+                    "$ref": "#/definitions/redishelper.ServiceStatus"
+                },
+                "serviceUUID": {
                     "type": "string"
                 },
                 "status": {
-                    "description": "\"success\" or \"error\"",
                     "type": "string"
                 }
             }
@@ -562,11 +3330,42 @@ const docTemplate = `{
         "routes.ServiceInfo": {
             "type": "object",
             "properties": {
+                "load": {
+                    "$ref": "#/definitions/routes.LoadInfo"
+                },
                 "serviceAddr": {
                     "type": "string"
                 },
                 "serviceID": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.ServiceListResponse": {
+            "type": "object",
+            "properties": {
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redishelper.ServiceEntry"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.SnapshotImportResponse": {
+            "type": "object",
+            "properties": {
+                "result": {
+                    "$ref": "#/definitions/redishelper.SnapshotImportResult"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
@@ -579,20 +3378,150 @@ const docTemplate = `{
                 "version": {
                     "type": "string"
                 },
-                "version_name": {
+                "versionName": {
                     "type": "string"
                 }
             }
         },
-        "utils.JSONResponse": {
+        "serviceconfigloader.CatalogDiff": {
             "type": "object",
             "properties": {
-                "data": {},
-                "message": {
+                "addedProviders": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "addedTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "removedProviders": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "removedTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "serviceconfigloader.CustomProvider": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "retired": {
+                    "type": "boolean"
+                },
+                "short": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "serviceconfigloader.CustomServiceType": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "retired": {
+                    "type": "boolean"
+                },
+                "short": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.Problem": {
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "description": "Allowed lists the accepted values of Param.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "description": "Code is the stable error code clients branch on.",
+                    "type": "string",
+                    "enum": [
+                        "INVALID_BODY",
+                        "BODY_TOO_LARGE",
+                        "UNSUPPORTED_MEDIA_TYPE",
+                        "BATCH_TOO_LARGE",
+                        "VALIDATION_FAILED",
+                        "INVALID_PARAMETER",
+                        "INVALID_UUID",
+                        "SERVICE_NOT_FOUND",
+                        "SERVICE_SUSPICIOUS",
+                        "ILLEGAL_TRANSITION",
+                        "REPORTER_NOT_FOUND",
+                        "IDEMPOTENCY_KEY_REUSED",
+                        "REGISTRATION_IN_PROGRESS",
+                        "SERVICE_BUSY",
+                        "UNKNOWN_SERVICE_TYPE",
+                        "CATALOG_ENTRY_EXISTS",
+                        "CATALOG_ENTRY_NOT_FOUND",
+                        "CATALOG_INVALID",
+                        "UNAUTHORIZED",
+                        "ADMIN_DISABLED",
+                        "OUTLIERS_DISABLED",
+                        "NOT_ACCEPTABLE",
+                        "ROUTE_NOT_FOUND",
+                        "METHOD_NOT_ALLOWED",
+                        "RATE_LIMITED",
+                        "STORAGE_UNAVAILABLE",
+                        "INTERNAL_ERROR"
+                    ]
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors holds per-field validation errors."
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "param": {
+                    "description": "Param names the offending query or path parameter.",
                     "type": "string"
                 },
                 "status": {
                     "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         }
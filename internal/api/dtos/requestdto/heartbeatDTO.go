@@ -0,0 +1,11 @@
+package requestdto
+
+// HeartbeatRequestDTO is the optional body of a heartbeat. Omitted fields
+// keep their last reported value; Status defaults to "ok".
+type HeartbeatRequestDTO struct {
+	CPULoad    *float64          `validate:"omitempty,min=0,max=100"` // percent
+	MemoryLoad *float64          `validate:"omitempty,min=0,max=100"` // percent
+	InFlight   *int64            `validate:"omitempty,min=0"`         // requests being served
+	Status     string            `validate:"omitempty,oneof=ok degraded failing"`
+	Metadata   map[string]string `validate:"omitempty,max=32,dive,keys,min=1,max=63,tagkey,endkeys,max=256,tagvalue"` // merged into the entry (at most 32 keys); an empty value removes the key
+}
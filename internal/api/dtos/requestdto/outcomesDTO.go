@@ -0,0 +1,15 @@
+package requestdto
+
+// OutcomesRequestDTO carries the request outcomes a registered instance saw
+// against the instances it calls since its previous submission.
+type OutcomesRequestDTO struct {
+	ReporterUUID string       `validate:"required,uuid"`
+	Outcomes     []OutcomeDTO `validate:"dive"`
+}
+
+// OutcomeDTO counts the requests to one instance that succeeded and failed.
+type OutcomeDTO struct {
+	ServiceUUID string `validate:"required,uuid"`
+	Successes   int64  `validate:"min=0"`
+	Failures    int64  `validate:"min=0"`
+}
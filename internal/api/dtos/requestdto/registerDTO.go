@@ -1,7 +1,7 @@
 package requestdto
 
 type RegisterRequestDTO struct {
-	Name          string            `validate:"required,min=3,max=64"`
+	Name          string            `validate:"required,min=3,max=64,name"`
 	Type          string            `validate:"required,type"`
 	Version       string            `validate:"required,version"`
 	Provider      string            `validate:"required,provider"`
@@ -12,9 +12,21 @@ type RegisterRequestDTO struct {
 	NetworkID     string            `validate:"required,alphanumanddashandunderscore"`
 	SubnetID      string            `validate:"required,alphanumanddashandunderscore"`
 	NetworkDomain string            `validate:"required,alphanumanddashandunderscore"`
-	Tags          map[string]string `validate:"omitempty"`
+	Tags          map[string]string `validate:"omitempty,max=32,dive,keys,min=1,max=63,tagkey,endkeys,max=256,tagvalue"`
 	Addr4         string            `validate:"omitempty,ip4_addr"`
 	Port4         int               `validate:"omitempty,min=1,max=65535"`
 	Addr6         string            `validate:"omitempty,ip6_addr"`
 	Port6         int               `validate:"omitempty,min=1,max=65535"`
+	Check         *CheckDTO         `validate:"omitempty"`
+}
+
+// CheckDTO declares an active health check DiscoGo runs against Addr4:Port4
+// (or Addr6:Port6 when no IPv4 address is given).
+type CheckDTO struct {
+	Type           string `validate:"required,oneof=http tcp grpc"`
+	Path           string `validate:"omitempty,startswith=/,max=256"` // http only, defaults to "/"
+	ExpectedStatus int    `validate:"omitempty,min=100,max=599"`      // http only, defaults to 200
+	Service        string `validate:"omitempty,max=256"`              // grpc only, empty checks the whole server
+	Interval       int    `validate:"omitempty,min=1,max=3600"`       // seconds, defaults to PROBE_INTERVAL
+	Timeout        int    `validate:"omitempty,min=1,max=60"`         // seconds, defaults to PROBE_TIMEOUT
 }
@@ -0,0 +1,8 @@
+package requestdto
+
+// ReportRequestDTO is a complaint by one registered instance about another.
+type ReportRequestDTO struct {
+	ServiceUUID  string `validate:"required,uuid"` // the reported instance
+	ReporterUUID string `validate:"required,uuid,nefield=ServiceUUID"`
+	Reason       string `validate:"omitempty,max=256"`
+}
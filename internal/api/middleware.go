@@ -0,0 +1,64 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/tahakara/discogo/internal/api/routes"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// adminOnly guards a handler with the admin bearer token.
+func adminOnly(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			utils.WriteError(w, r, http.StatusForbidden, utils.CodeAdminDisabled, "Admin API is disabled")
+			return
+		}
+
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Invalid admin token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// deprecated serves a legacy unversioned route unchanged, pointing clients at
+// its /v1 successor.
+func deprecated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := "/v1" + strings.TrimPrefix(r.URL.Path, "/disco")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// negotiateV2 answers 406 when the client accepts no v2 media type.
+func negotiateV2(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := routes.NegotiateV2(r); !ok {
+			utils.WriteError(w, r, http.StatusNotAcceptable, utils.CodeNotAcceptable,
+				"Acceptable media types are "+routes.MediaTypeV2+" and application/json")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// resolveClientIP records the caller's address behind the trusted proxies for
+// utils.GetClientIP.
+func resolveClientIP(trusted []*net.IPNet) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, utils.WithClientIP(r, utils.ForwardedClientIP(r, trusted)))
+		})
+	}
+}
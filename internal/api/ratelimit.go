@@ -0,0 +1,147 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// rateLimiter hands out request tokens per bucket key.
+type rateLimiter interface {
+	// allow takes a token from the bucket of key and otherwise returns how
+	// long until one is available.
+	allow(key string, limit env.RouteRateLimit) (bool, time.Duration)
+}
+
+func newRateLimiter(cfg env.RateLimitConfig, rclient redisclient.Client) rateLimiter {
+	if cfg.Shared {
+		return sharedLimiter{rclient: rclient}
+	}
+	return &localLimiter{buckets: map[string]*tokenBucket{}}
+}
+
+// bucketSweepInterval is how often full buckets are dropped from memory.
+const bucketSweepInterval = time.Minute
+
+// localLimiter keeps token buckets in memory, so each replica enforces the
+// limits on its own.
+type localLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled; it can be dropped after
+	full time.Time
+}
+
+func (l *localLimiter) allow(key string, limit env.RouteRateLimit) (bool, time.Duration) {
+	now := time.Now()
+	rate, burst := float64(limit.Rate), float64(limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= bucketSweepInterval {
+		for k, b := range l.buckets {
+			if !now.Before(b.full) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+	if allowed {
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// sharedLimiter keeps the buckets in the storage backend, so all replicas
+// enforce one limit together.
+type sharedLimiter struct {
+	rclient redisclient.Client
+}
+
+func (l sharedLimiter) allow(key string, limit env.RouteRateLimit) (bool, time.Duration) {
+	startTime := time.Now()
+	allowed, wait, err := redishelper.TakeRateLimitToken(l.rclient, key, limit.Rate, limit.Burst)
+	if err != nil {
+		// An unavailable backend fails the request on its own; do not add to it
+		logger.Error(fmt.Sprintf("Failed to check rate limit of %s, allowing the request: %v", key, err), time.Since(startTime))
+		return true, 0
+	}
+	return allowed, wait
+}
+
+// rateLimitClient returns a func identifying the caller of a request: one of
+// apiKeys, hashed so keys never reach the storage backend, or else its source
+// IP. Unknown keys count as no key, so a client cannot get fresh buckets by
+// making up keys.
+func rateLimitClient(apiKeys []string) func(r *http.Request) string {
+	known := make(map[string]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		known[apiKeyID(key)] = true
+	}
+	return func(r *http.Request) string {
+		if apiKey := r.Header.Get(utils.APIKeyHeader); apiKey != "" {
+			if id := apiKeyID(apiKey); known[id] {
+				return "key:" + id
+			}
+		}
+		return "ip:" + utils.GetClientIP(r)
+	}
+}
+
+func apiKeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// rateLimited answers 429 with Retry-After once a client exhausts its bucket
+// for route. Routes without a limit are served unchanged.
+func rateLimited(limiter rateLimiter, client func(r *http.Request) string, limit env.RouteRateLimit, route string, next http.HandlerFunc) http.HandlerFunc {
+	if limit.Rate == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed, wait := limiter.allow(route+":"+client(r), limit)
+		if !allowed {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			utils.WriteError(w, r, http.StatusTooManyRequests, utils.CodeRateLimited,
+				fmt.Sprintf("Rate limit of %d requests per second exceeded, retry in %d s", limit.Rate, retryAfter))
+			return
+		}
+		next(w, r)
+	}
+}
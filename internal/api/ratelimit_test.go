@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	env "github.com/tahakara/discogo/internal/config"
+)
+
+// limitedHandler serves one request per client, like a route limited to a
+// rate and burst of 1, behind the proxies in trusted.
+func limitedHandler(t *testing.T, trusted []string, apiKeys []string) http.Handler {
+	t.Helper()
+	nets, err := env.HTTPConfig{TrustedProxies: trusted}.TrustedProxyNets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := &localLimiter{buckets: map[string]*tokenBucket{}}
+	limited := rateLimited(limiter, rateLimitClient(apiKeys), env.RouteRateLimit{Rate: 1, Burst: 1}, "/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return resolveClientIP(nets)(limited)
+}
+
+func call(h http.Handler, remoteAddr string, header http.Header) int {
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.RemoteAddr = remoteAddr
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRateLimitIgnoresForwardedForFromUntrustedPeers(t *testing.T) {
+	h := limitedHandler(t, []string{"10.0.0.1"}, nil)
+	for i, hop := range []string{"198.51.100.1", "198.51.100.2"} {
+		code := call(h, "203.0.113.7:4000", http.Header{"X-Forwarded-For": {hop}})
+		if want := []int{http.StatusNoContent, http.StatusTooManyRequests}[i]; code != want {
+			t.Fatalf("request %d with X-Forwarded-For %s returned %d, want %d", i, hop, code, want)
+		}
+	}
+}
+
+func TestRateLimitHonoursForwardedForFromTrustedProxies(t *testing.T) {
+	h := limitedHandler(t, []string{"10.0.0.0/8"}, nil)
+	// Each caller gets its own bucket; the hops left of the caller are
+	// ignored, as the caller could have sent them.
+	for _, xff := range []string{"1.1.1.1, 198.51.100.1, 10.0.0.2", "1.1.1.1, 198.51.100.2, 10.0.0.2"} {
+		if code := call(h, "10.0.0.1:4000", http.Header{"X-Forwarded-For": {xff}}); code != http.StatusNoContent {
+			t.Fatalf("X-Forwarded-For %q returned %d, want %d", xff, code, http.StatusNoContent)
+		}
+	}
+	if code := call(h, "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"2.2.2.2, 198.51.100.1"}}); code != http.StatusTooManyRequests {
+		t.Fatalf("second request of 198.51.100.1 returned %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitOnlyKeysByConfiguredAPIKeys(t *testing.T) {
+	h := limitedHandler(t, nil, []string{"key-a", "key-b"})
+	const peer = "203.0.113.7:4000"
+	for _, key := range []string{"key-a", "key-b"} {
+		if code := call(h, peer, http.Header{"X-Api-Key": {key}}); code != http.StatusNoContent {
+			t.Fatalf("configured key %s returned %d, want %d", key, code, http.StatusNoContent)
+		}
+	}
+	// Made-up keys share the bucket of the source IP
+	for i, key := range []string{"made-up-1", "made-up-2"} {
+		code := call(h, peer, http.Header{"X-Api-Key": {key}})
+		if want := []int{http.StatusNoContent, http.StatusTooManyRequests}[i]; code != want {
+			t.Fatalf("unknown key %s returned %d, want %d", key, code, want)
+		}
+	}
+}
+
+func TestTrustedProxyNets(t *testing.T) {
+	nets, err := env.HTTPConfig{TrustedProxies: []string{"10.0.0.1", "192.168.0.0/16", "::1"}}.TrustedProxyNets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range []string{"10.0.0.1", "192.168.4.5", "::1"} {
+		if !contains(nets, ip) {
+			t.Errorf("%s is not trusted", ip)
+		}
+	}
+	for _, ip := range []string{"10.0.0.2", "192.169.0.1", "::2"} {
+		if contains(nets, ip) {
+			t.Errorf("%s is trusted", ip)
+		}
+	}
+	if _, err := (env.HTTPConfig{TrustedProxies: []string{"proxy.local"}}).TrustedProxyNets(); err == nil {
+		t.Error("a host name was accepted as a trusted proxy")
+	}
+}
+
+func contains(nets []*net.IPNet, ip string) bool {
+	for _, n := range nets {
+		if n.Contains(net.ParseIP(ip)) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,44 +1,334 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/tahakara/discogo/internal/api/routes"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
 	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/utils"
 )
 
-func NewRouter(rclient redisclient.Client) *mux.Router {
+// route is a v1 endpoint. It is also served at its pre-versioning path
+// ("/disco" + path unless legacy is set) as a deprecated alias.
+type route struct {
+	path    string
+	methods []string
+	handler http.HandlerFunc
+	legacy  string
+	// legacyMethods overrides methods for the alias
+	legacyMethods []string
+	// versionedOnly routes were added after versioning and have no alias
+	versionedOnly bool
+}
+
+func NewRouter(rclient redisclient.Client, cfg env.Config) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
+	// admin guards a handler with the configured admin token
+	admin := func(next http.HandlerFunc) http.HandlerFunc {
+		return adminOnly(cfg.Admin.Token, next)
+	}
+
+	// Validate has already rejected malformed proxies
+	trustedProxies, _ := cfg.HTTP.TrustedProxyNets()
+	router.Use(resolveClientIP(trustedProxies))
+
+	limiter := newRateLimiter(cfg.RateLimit, rclient)
+	client := rateLimitClient(cfg.RateLimit.APIKeys)
+	limitedRoutes := map[string]bool{}
+	// limit applies the rate limit of route; aliases share their route's buckets
+	limit := func(route string, next http.HandlerFunc) http.HandlerFunc {
+		limitedRoutes[route] = true
+		return rateLimited(limiter, client, cfg.RateLimit.Limit(route), route, next)
+	}
 
 	router.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
-	router.HandleFunc("/disco/version", routes.VersionHandler).Methods("GET", "POST", "PUT")
-	router.HandleFunc("/disco/health", routes.HealthCheckHandler).Methods("GET")
 
-	router.HandleFunc("/disco/register", func(w http.ResponseWriter, r *http.Request) {
-		routes.RegisterHandler(w, r, rclient)
-	}).Methods("POST")
+	// Versions are plain path prefixes rather than subrouters, which would
+	// answer 404 instead of 405 on a method mismatch.
+	for _, rt := range v1Routes(rclient, cfg, admin) {
+		handler := limit(rt.path, rt.handler)
+		router.HandleFunc("/v1"+rt.path, handler).Methods(rt.methods...)
+		if rt.versionedOnly {
+			continue
+		}
+
+		legacy := rt.legacy
+		if legacy == "" {
+			legacy = "/disco" + rt.path
+		}
+		methods := rt.methods
+		if rt.legacyMethods != nil {
+			methods = rt.legacyMethods
+		}
+		router.HandleFunc(legacy, deprecated(handler)).Methods(methods...)
+	}
+
+	router.HandleFunc("/v2/services/{type}/instances", limit("/v2/services/{type}/instances", negotiateV2(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		routes.ListInstancesV2Handler(w, r, rclient, vars["type"])
+	}))).Methods("GET")
 
-	router.HandleFunc("/disco/heartbeat/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+	router.HandleFunc("/v2/services/{type}/instances", limit("/v2/services/{type}/instances", negotiateV2(func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
-		routes.HeartbeatHandler(w, r, rclient, vars["uuid"])
-	}).Methods("POST")
+		routes.RegisterInstanceV2Handler(w, r, rclient, cfg.Registry, vars["type"])
+	}))).Methods("POST")
 
-	router.HandleFunc("/disco/discover", func(w http.ResponseWriter, r *http.Request) {
-		routes.DiscoverHandler(w, r, rclient)
-	}).Methods("GET")
+	router.HandleFunc("/v2/services/{type}/instances/{uuid}", limit("/v2/services/{type}/instances/{uuid}", negotiateV2(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		routes.GetInstanceV2Handler(w, r, rclient, vars["type"], vars["uuid"])
+	}))).Methods("GET")
 
-	router.HandleFunc("/deregister", func(w http.ResponseWriter, r *http.Request) {
-		routes.DeregisterHandler(w, r, rclient)
-	}).Methods("POST")
+	router.HandleFunc("/v2/services/{type}/instances/{uuid}", limit("/v2/services/{type}/instances/{uuid}", negotiateV2(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		routes.DeregisterInstanceV2Handler(w, r, rclient, vars["type"], vars["uuid"])
+	}))).Methods("DELETE")
 
-	// TODO: will implement report handler
-	// It handles reporting of service status from service clients
-	// when a certain event occurs service health status changes etc.
-	// router.HandleFunc("/report", routes.ReportHandler(memcachedService)).Methods("POST")
+	router.HandleFunc("/v2/services/{type}/instances/{uuid}/heartbeat", limit("/v2/services/{type}/instances/{uuid}/heartbeat", negotiateV2(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		routes.HeartbeatInstanceV2Handler(w, r, rclient, cfg.Registry, vars["type"], vars["uuid"])
+	}))).Methods("POST")
 
 	// router.HandleFunc("/error", routes.ErrorHandler).Methods("GET")
 
+	for route := range cfg.RateLimit.Routes {
+		if !limitedRoutes[route] {
+			logger.Error(fmt.Sprintf("Rate limit set for unknown route %q", route), 0)
+		}
+	}
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeRouteNotFound, "No route matches "+r.URL.Path)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, r, http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+	})
+
 	return router
 }
+
+func v1Routes(rclient redisclient.Client, cfg env.Config, admin func(http.HandlerFunc) http.HandlerFunc) []route {
+	return []route{
+		{
+			path:    "/version",
+			methods: []string{"GET"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.VersionHandler(w, r, cfg.App)
+			},
+			legacyMethods: []string{"GET", "POST", "PUT"},
+		},
+		{
+			path:    "/health",
+			methods: []string{"GET"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.HealthCheckHandler(w, r, rclient)
+			},
+		},
+		{
+			path:    "/register",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.RegisterHandler(w, r, rclient, cfg.Registry)
+			},
+		},
+		{
+			path:    "/heartbeat/{uuid}",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.HeartbeatHandler(w, r, rclient, cfg.Registry, vars["uuid"])
+			},
+		},
+		{
+			path:    "/discover",
+			methods: []string{"GET"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.DiscoverHandler(w, r, rclient)
+			},
+		},
+		{
+			path:    "/deregister",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.DeregisterHandler(w, r, rclient)
+			},
+			legacy: "/deregister",
+		},
+		{
+			path:    "/report",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.ReportHandler(w, r, rclient, cfg.Registry)
+			},
+			versionedOnly: true,
+		},
+		{
+			path:    "/outcomes",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.OutcomesHandler(w, r, rclient, cfg.Registry, cfg.Outlier)
+			},
+			versionedOnly: true,
+		},
+		{
+			path:    "/bulk/register",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.BulkRegisterHandler(w, r, rclient, cfg.Registry)
+			},
+			versionedOnly: true,
+		},
+		{
+			path:    "/bulk/heartbeat",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.BulkHeartbeatHandler(w, r, rclient, cfg.Registry)
+			},
+			versionedOnly: true,
+		},
+		{
+			path:    "/bulk/deregister",
+			methods: []string{"POST"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.BulkDeregisterHandler(w, r, rclient, cfg.Registry)
+			},
+			versionedOnly: true,
+		},
+		{
+			path:    "/catalog/types",
+			methods: []string{"GET"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				routes.CatalogTypesHandler(w, r, rclient)
+			},
+		},
+		{
+			path:    "/catalog/types/{short}",
+			methods: []string{"GET"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.CatalogTypeHandler(w, r, rclient, vars["short"])
+			},
+		},
+		{
+			path:    "/catalog/providers",
+			methods: []string{"GET"},
+			handler: routes.CatalogProvidersHandler,
+		},
+		{
+			path:    "/admin/snapshot",
+			methods: []string{"GET"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				routes.SnapshotExportHandler(w, r, rclient)
+			}),
+		},
+		{
+			path:    "/admin/snapshot",
+			methods: []string{"POST"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				routes.SnapshotImportHandler(w, r, rclient, cfg.HTTP.MaxSnapshotBytes)
+			}),
+		},
+		{
+			path:    "/admin/reload",
+			methods: []string{"POST"},
+			handler: admin(routes.CatalogReloadHandler),
+		},
+		{
+			path:    "/admin/catalog/types",
+			methods: []string{"POST"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				routes.CreateCustomServiceTypeHandler(w, r, rclient)
+			}),
+		},
+		{
+			path:    "/admin/catalog/types/{short}",
+			methods: []string{"PATCH"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.PatchCustomServiceTypeHandler(w, r, rclient, vars["short"])
+			}),
+		},
+		{
+			path:    "/admin/catalog/types/{short}",
+			methods: []string{"DELETE"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.RetireCustomServiceTypeHandler(w, r, rclient, vars["short"])
+			}),
+		},
+		{
+			path:    "/admin/catalog/providers",
+			methods: []string{"POST"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				routes.CreateCustomProviderHandler(w, r, rclient)
+			}),
+		},
+		{
+			path:    "/admin/catalog/providers/{short}",
+			methods: []string{"PATCH"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.PatchCustomProviderHandler(w, r, rclient, vars["short"])
+			}),
+		},
+		{
+			path:    "/admin/catalog/providers/{short}",
+			methods: []string{"DELETE"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.RetireCustomProviderHandler(w, r, rclient, vars["short"])
+			}),
+		},
+		{
+			path:    "/services",
+			methods: []string{"GET"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				routes.ListServicesHandler(w, r, rclient)
+			}),
+		},
+		{
+			path:    "/services/{uuid}",
+			methods: []string{"GET"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.GetServiceHandler(w, r, rclient, vars["uuid"])
+			}),
+		},
+		{
+			path:    "/services/{uuid}",
+			methods: []string{"PATCH"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.PatchServiceHandler(w, r, rclient, vars["uuid"])
+			}),
+		},
+		{
+			path:    "/services/{uuid}",
+			methods: []string{"DELETE"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.EvictServiceHandler(w, r, rclient, vars["uuid"])
+			}),
+		},
+		{
+			path:    "/services/{uuid}/history",
+			methods: []string{"GET"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				vars := mux.Vars(r)
+				routes.ServiceHistoryHandler(w, r, rclient, vars["uuid"])
+			}),
+			versionedOnly: true,
+		},
+		{
+			path:    "/audit",
+			methods: []string{"GET"},
+			handler: admin(func(w http.ResponseWriter, r *http.Request) {
+				routes.AuditHandler(w, r, rclient)
+			}),
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tahakara/discogo/internal/api/routes"
+	boltclient "github.com/tahakara/discogo/internal/bolt"
+	env "github.com/tahakara/discogo/internal/config"
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+)
+
+func TestMain(m *testing.M) {
+	if err := serviceconfigloader.LoadAllConfigs(filepath.Join("..", "..", "conf.json")); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	rclient, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rclient.Close() })
+	return NewRouter(rclient, env.Defaults())
+}
+
+func serve(h http.Handler, method, path string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader("{}"))
+	r.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestLegacyAliasesAreDeprecated(t *testing.T) {
+	h := newTestRouter(t)
+	tests := []struct {
+		method    string
+		path      string
+		successor string
+	}{
+		{http.MethodGet, "/disco/version", "/v1/version"},
+		{http.MethodPost, "/disco/version", "/v1/version"},
+		{http.MethodGet, "/disco/health", "/v1/health"},
+		{http.MethodGet, "/disco/discover?type=gw", "/v1/discover"},
+		{http.MethodPost, "/deregister", "/v1/deregister"},
+	}
+	for _, tt := range tests {
+		w := serve(h, tt.method, tt.path, nil)
+		if w.Code == http.StatusNotFound || w.Code == http.StatusMethodNotAllowed {
+			t.Errorf("%s %s returned %d, want the v1 handler", tt.method, tt.path, w.Code)
+			continue
+		}
+		if got := w.Header().Get("Deprecation"); got != "true" {
+			t.Errorf("%s %s Deprecation = %q, want true", tt.method, tt.path, got)
+		}
+		if got, want := w.Header().Get("Link"), "<"+tt.successor+`>; rel="successor-version"`; got != want {
+			t.Errorf("%s %s Link = %q, want %q", tt.method, tt.path, got, want)
+		}
+	}
+
+	// The versioned routes are not deprecated
+	if w := serve(h, http.MethodGet, "/v1/version", nil); w.Header().Get("Deprecation") != "" || w.Header().Get("Link") != "" {
+		t.Errorf("/v1/version answered with Deprecation %q and Link %q", w.Header().Get("Deprecation"), w.Header().Get("Link"))
+	}
+	// Routes added after versioning have no alias
+	if w := serve(h, http.MethodPost, "/disco/report", nil); w.Code != http.StatusNotFound {
+		t.Errorf("/disco/report returned %d, want 404", w.Code)
+	}
+}
+
+func TestV2ContentNegotiation(t *testing.T) {
+	h := newTestRouter(t)
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"", http.StatusOK, routes.MediaTypeV2},
+		{routes.MediaTypeV2, http.StatusOK, routes.MediaTypeV2},
+		{"application/json", http.StatusOK, "application/json"},
+		{"*/*", http.StatusOK, "application/json"},
+		{"text/html", http.StatusNotAcceptable, "application/problem+json"},
+		{"text/html, application/json;q=0", http.StatusNotAcceptable, "application/problem+json"},
+		{routes.MediaTypeV2 + ";q=0, application/xml", http.StatusNotAcceptable, "application/problem+json"},
+	}
+	for _, tt := range tests {
+		w := serve(h, http.MethodGet, "/v2/services/gw/instances", http.Header{"Accept": {tt.accept}})
+		if w.Code != tt.status {
+			t.Errorf("Accept %q returned %d, want %d: %s", tt.accept, w.Code, tt.status, w.Body)
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+			t.Errorf("Accept %q answered with Content-Type %q, want %s", tt.accept, got, tt.contentType)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type AuditResponse struct {
+	Status string                   `json:"status"`
+	Events []redishelper.AuditEvent `json:"events"`
+}
+
+// AuditHandler godoc
+// @Summary      Query the audit log
+// @Description  Returns registry mutations (register, status changes, reports, deregister, expiry and admin overrides) in chronological order.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        from   query     string  false  "Start of the time range (RFC3339)"
+// @Param        to     query     string  false  "End of the time range (RFC3339)"
+// @Param        uuid   query     string  false  "Only events of this service UUID. At most 10000 events of the range are scanned, oldest first; narrow it with from and to on long logs"
+// @Param        limit  query     int     false  "Maximum number of events (1-1000)" minimum(1) maximum(1000)
+// @Success      200    {object}  AuditResponse
+// @Failure      400    {object}  utils.Problem
+// @Failure      500    {object}  utils.Problem
+// @Router       /v1/audit [get]
+func AuditHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	query := r.URL.Query()
+
+	const (
+		defaultLimit = 100
+		maxLimit     = 1000
+	)
+
+	var from, to time.Time
+	var err error
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidParameter, "Invalid 'from' query parameter (must be RFC3339)")
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidParameter, "Invalid 'to' query parameter (must be RFC3339)")
+			return
+		}
+	}
+
+	serviceUUID := query.Get("uuid")
+	if serviceUUID != "" {
+		if valid, err := utils.ValidateUUID(serviceUUID); err != nil || !valid {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidUUID, "Invalid 'uuid' query parameter")
+			return
+		}
+	}
+
+	limit := defaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidParameter, "Invalid 'limit' query parameter (must be 1-1000)")
+			return
+		}
+		limit = n
+	}
+
+	events, err := redishelper.QueryAuditEvents(rclient, from, to, serviceUUID, limit)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to query audit log")
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, AuditResponse{
+		Status: "ok",
+		Events: events,
+	})
+}
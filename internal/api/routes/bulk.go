@@ -0,0 +1,388 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	// Not aliased: swag cannot follow an alias into the request types below
+	"github.com/tahakara/discogo/internal/api/dtos/requestdto"
+	validators "github.com/tahakara/discogo/internal/api/validators"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type BulkRegisterRequest struct {
+	Services []requestdto.RegisterRequestDTO `json:"services"`
+}
+
+type BulkUUIDRequest struct {
+	ServiceUUIDs []string `json:"serviceUUIDs"`
+}
+
+// BulkHeartbeatRequest carries either plain heartbeats by UUID or heartbeats
+// with a payload each, like the body of POST /v1/heartbeat/{uuid}.
+type BulkHeartbeatRequest struct {
+	ServiceUUIDs []string            `json:"serviceUUIDs,omitempty"`
+	Heartbeats   []BulkHeartbeatItem `json:"heartbeats,omitempty"`
+}
+
+// BulkHeartbeatItem is one heartbeat of a bulk request. Without a payload it
+// behaves like an entry of serviceUUIDs.
+type BulkHeartbeatItem struct {
+	ServiceUUID string                          `json:"serviceUUID"`
+	Payload     *requestdto.HeartbeatRequestDTO `json:"payload,omitempty"`
+}
+
+// BulkItemResult is the outcome of one item, in request order. Status is the
+// HTTP status the item would have received from the single-item endpoint.
+type BulkItemResult struct {
+	Index        int            `json:"index"`
+	ServiceUUID  string         `json:"serviceUUID,omitempty"`
+	Status       int            `json:"status"`
+	Existing     bool           `json:"existing,omitempty"`
+	ReplacedUUID string         `json:"replacedUUID,omitempty"`
+	Error        *utils.Problem `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// HealthCheckCycle is set for registrations.
+	HealthCheckCycle int              `json:"healthCheckCycle,omitempty"`
+	Results          []BulkItemResult `json:"results"`
+}
+
+func (resp *BulkResponse) add(result BulkItemResult) {
+	if result.Error != nil {
+		resp.Failed++
+	} else {
+		resp.Succeeded++
+	}
+	resp.Results = append(resp.Results, result)
+}
+
+func bulkItemError(index int, serviceUUID string, status int, code, detail string) BulkItemResult {
+	problem := utils.NewProblem(status, code, detail)
+	return BulkItemResult{Index: index, ServiceUUID: serviceUUID, Status: status, Error: &problem}
+}
+
+// checkBatchSize answers 400 for an empty batch and 413 for one larger than
+// registry.BulkMaxItems.
+func checkBatchSize(w http.ResponseWriter, r *http.Request, registry env.RegistryConfig, n int) bool {
+	if n == 0 {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidBody, "The batch is empty")
+		return false
+	}
+	if n > registry.BulkMaxItems {
+		utils.WriteError(w, r, http.StatusRequestEntityTooLarge, utils.CodeBatchTooLarge,
+			fmt.Sprintf("The batch has %d items, at most %d are accepted", n, registry.BulkMaxItems))
+		return false
+	}
+	return true
+}
+
+// decodeBulkUUIDs reads a BulkUUIDRequest and checks its size.
+func decodeBulkUUIDs(w http.ResponseWriter, r *http.Request, registry env.RegistryConfig) ([]string, bool) {
+	var body BulkUUIDRequest
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return nil, false
+	}
+	if !checkBatchSize(w, r, registry, len(body.ServiceUUIDs)) {
+		return nil, false
+	}
+	return body.ServiceUUIDs, true
+}
+
+// splitValidUUIDs returns the well-formed UUIDs with their request indexes
+// and records an INVALID_UUID result for the others.
+func splitValidUUIDs(uuids []string, resp *BulkResponse) ([]string, []int) {
+	var valid []string
+	var indexes []int
+	for i, serviceUUID := range uuids {
+		if ok, err := utils.ValidateUUID(serviceUUID); err != nil || !ok {
+			resp.add(bulkItemError(i, serviceUUID, http.StatusBadRequest, utils.CodeInvalidUUID, "Invalid uuid format"))
+			continue
+		}
+		valid = append(valid, serviceUUID)
+		indexes = append(indexes, i)
+	}
+	return valid, indexes
+}
+
+// BulkRegisterHandler godoc
+// @Summary      Register several services
+// @Description  Registers up to BULK_MAX_ITEMS instances in one request. Each item behaves like POST /v1/register and gets its own result; the request itself only fails when the batch is malformed.
+// @Tags         DiscoGo
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Param        request  body   BulkRegisterRequest  true   "Registration payloads"
+// @Param        replace  query  bool                 false  "Replace existing registrations of the same identity"
+// @Success      200      {object}  BulkResponse
+// @Failure      400      {object}  utils.Problem  "INVALID_BODY or INVALID_PARAMETER"
+// @Failure      409      {object}  utils.Problem  "SERVICE_BUSY"
+// @Failure      413      {object}  utils.Problem  "BATCH_TOO_LARGE"
+// @Failure      503      {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v1/bulk/register [post]
+func BulkRegisterHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig) {
+	startTime := time.Now()
+	var body BulkRegisterRequest
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if !checkBatchSize(w, r, registry, len(body.Services)) {
+		return
+	}
+	replace, ok := replaceParam(w, r)
+	if !ok {
+		return
+	}
+
+	resp := BulkResponse{HealthCheckCycle: registry.HealthCheckInterval}
+	lang := validators.NegotiateLanguage(r.Header.Get("Accept-Language"))
+	var entries []redishelper.ServiceEntry
+	var indexes []int
+	for i := range body.Services {
+		if errs := validators.ValidateRegisterRequest(&body.Services[i], lang); errs != nil {
+			w.Header().Set("Content-Language", lang)
+			result := bulkItemError(i, "", http.StatusBadRequest, utils.CodeValidationFailed, validators.Summary(lang, errs))
+			result.Error.Errors = errs
+			resp.add(result)
+			continue
+		}
+		entries = append(entries, newServiceEntry(body.Services[i]))
+		indexes = append(indexes, i)
+	}
+
+	results, err := redishelper.BulkRegister(rclient, entries, replace, registrationLockWait)
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to reach the storage backend")
+		return
+	}
+
+	actor := utils.GetActor(r, "service")
+	sourceIP := utils.GetClientIP(r)
+	for n, result := range results {
+		i := indexes[n]
+		switch {
+		case errors.Is(result.Err, redishelper.ErrRegistrationLocked):
+			resp.add(bulkItemError(i, "", http.StatusConflict, utils.CodeRegistrationInProgress, "Another registration of this instance is in progress"))
+			continue
+		case result.Err != nil:
+			resp.add(bulkItemError(i, "", http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to store the service entry"))
+			continue
+		}
+
+		item := BulkItemResult{Index: i, ServiceUUID: result.Entry.ServiceUUID, Status: http.StatusOK, Existing: result.Existing}
+		if result.Replaced != nil {
+			item.ReplacedUUID = result.Replaced.ServiceUUID
+			redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+				Action:      redishelper.AuditDeregister,
+				ServiceUUID: result.Replaced.ServiceUUID,
+				ServiceType: result.Replaced.Type,
+				Actor:       actor,
+				SourceIP:    sourceIP,
+				OldStatus:   result.Replaced.Status,
+				NewStatus:   redishelper.StatusDeregistered,
+				Reason:      "replaced by " + result.Entry.ServiceUUID,
+			})
+		}
+		if !result.Existing {
+			redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+				Action:      redishelper.AuditRegister,
+				ServiceUUID: result.Entry.ServiceUUID,
+				ServiceType: result.Entry.Type,
+				Actor:       actor,
+				SourceIP:    sourceIP,
+				NewStatus:   redishelper.StatusRegistered,
+			})
+		}
+		resp.add(item)
+	}
+
+	sortBulkResults(resp.Results)
+	logger.Register(fmt.Sprintf("bulk: %d registered, %d failed", resp.Succeeded, resp.Failed), time.Since(startTime))
+	utils.WriteJSONResponse(w, http.StatusOK, resp)
+}
+
+// BulkHeartbeatHandler godoc
+// @Summary      Send heartbeats for several services
+// @Description  Refreshes up to BULK_MAX_ITEMS instances in one request, given as serviceUUIDs or as heartbeats with a payload each. Each item behaves like POST /v1/heartbeat/{uuid} and gets its own result.
+// @Tags         DiscoGo
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Param        request  body      BulkHeartbeatRequest  true  "Service UUIDs or heartbeats"
+// @Success      200      {object}  BulkResponse
+// @Failure      400      {object}  utils.Problem  "INVALID_BODY"
+// @Failure      409      {object}  utils.Problem  "SERVICE_BUSY"
+// @Failure      413      {object}  utils.Problem  "BATCH_TOO_LARGE"
+// @Failure      503      {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v1/bulk/heartbeat [post]
+func BulkHeartbeatHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig) {
+	startTime := time.Now()
+	var body BulkHeartbeatRequest
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if len(body.ServiceUUIDs) > 0 && len(body.Heartbeats) > 0 {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidBody, "Send either serviceUUIDs or heartbeats, not both")
+		return
+	}
+	uuids := body.ServiceUUIDs
+	requests := make([]*requestdto.HeartbeatRequestDTO, len(uuids))
+	for _, item := range body.Heartbeats {
+		uuids = append(uuids, item.ServiceUUID)
+		requests = append(requests, item.Payload)
+	}
+	if !checkBatchSize(w, r, registry, len(uuids)) {
+		return
+	}
+
+	var resp BulkResponse
+	lang := validators.NegotiateLanguage(r.Header.Get("Accept-Language"))
+	var valid []string
+	var indexes []int
+	var payloads []redishelper.HeartbeatPayload
+	validUUIDs, validIndexes := splitValidUUIDs(uuids, &resp)
+	for n, serviceUUID := range validUUIDs {
+		i := validIndexes[n]
+		var payload redishelper.HeartbeatPayload
+		if req := requests[i]; req != nil {
+			if errs := validators.ValidateHeartbeatRequest(req, lang); errs != nil {
+				w.Header().Set("Content-Language", lang)
+				result := bulkItemError(i, serviceUUID, http.StatusBadRequest, utils.CodeValidationFailed, validators.Summary(lang, errs))
+				result.Error.Errors = errs
+				resp.add(result)
+				continue
+			}
+			payload = heartbeatPayload(*req)
+		}
+		valid = append(valid, serviceUUID)
+		indexes = append(indexes, i)
+		payloads = append(payloads, payload)
+	}
+
+	results, err := redishelper.BulkHeartbeat(rclient, valid, payloads, statusPolicy(registry))
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to reach the storage backend")
+		return
+	}
+
+	actor := utils.GetActor(r, "service")
+	sourceIP := utils.GetClientIP(r)
+	for n, result := range results {
+		i := indexes[n]
+		if result.Change.Changed() {
+			redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+				Action:      redishelper.AuditStatusChange,
+				ServiceUUID: result.ServiceUUID,
+				ServiceType: result.Entry.Type,
+				Actor:       actor,
+				SourceIP:    sourceIP,
+				OldStatus:   result.Change.From,
+				NewStatus:   result.Change.To,
+				Reason:      result.Change.AuditReason(),
+			})
+		}
+
+		switch {
+		case result.Err == nil:
+			resp.add(BulkItemResult{Index: i, ServiceUUID: result.ServiceUUID, Status: http.StatusOK})
+		case errors.Is(result.Err, redishelper.ErrServiceNotFound):
+			resp.add(bulkItemError(i, result.ServiceUUID, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found"))
+		case errors.Is(result.Err, redishelper.ErrServiceSuspicious):
+			resp.add(bulkItemError(i, result.ServiceUUID, http.StatusConflict, utils.CodeServiceSuspicious, suspiciousDetail))
+		case errors.Is(result.Err, redishelper.ErrIllegalTransition):
+			resp.add(bulkItemError(i, result.ServiceUUID, http.StatusConflict, utils.CodeIllegalTransition, illegalHeartbeatDetail))
+		default:
+			resp.add(bulkItemError(i, result.ServiceUUID, http.StatusInternalServerError, utils.CodeInternal, result.Err.Error()))
+		}
+	}
+
+	sortBulkResults(resp.Results)
+	logger.HeartBeat(fmt.Sprintf("bulk: %d healthy, %d failed", resp.Succeeded, resp.Failed), time.Since(startTime))
+	utils.WriteJSONResponse(w, http.StatusOK, resp)
+}
+
+// BulkDeregisterHandler godoc
+// @Summary      Deregister several services
+// @Description  Removes up to BULK_MAX_ITEMS instances in one request. Each item gets its own result; unknown UUIDs answer 404.
+// @Tags         DiscoGo
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Param        request  body      BulkUUIDRequest  true  "Service UUIDs"
+// @Success      200      {object}  BulkResponse
+// @Failure      400      {object}  utils.Problem  "INVALID_BODY"
+// @Failure      409      {object}  utils.Problem  "SERVICE_BUSY"
+// @Failure      413      {object}  utils.Problem  "BATCH_TOO_LARGE"
+// @Failure      503      {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v1/bulk/deregister [post]
+func BulkDeregisterHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig) {
+	startTime := time.Now()
+	uuids, ok := decodeBulkUUIDs(w, r, registry)
+	if !ok {
+		return
+	}
+
+	var resp BulkResponse
+	valid, indexes := splitValidUUIDs(uuids, &resp)
+	results, err := redishelper.BulkDeregister(rclient, valid)
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to reach the storage backend")
+		return
+	}
+
+	actor := utils.GetActor(r, "service")
+	sourceIP := utils.GetClientIP(r)
+	for n, result := range results {
+		i := indexes[n]
+		switch {
+		case errors.Is(result.Err, redishelper.ErrServiceNotFound):
+			resp.add(bulkItemError(i, result.ServiceUUID, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found"))
+			continue
+		case result.Err != nil:
+			resp.add(bulkItemError(i, result.ServiceUUID, http.StatusInternalServerError, utils.CodeInternal, "Failed to deregister service"))
+			continue
+		}
+
+		redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+			Action:      redishelper.AuditDeregister,
+			ServiceUUID: result.ServiceUUID,
+			ServiceType: result.Entry.Type,
+			Actor:       actor,
+			SourceIP:    sourceIP,
+			OldStatus:   result.Entry.Status,
+			NewStatus:   redishelper.StatusDeregistered,
+		})
+		resp.add(BulkItemResult{Index: i, ServiceUUID: result.ServiceUUID, Status: http.StatusOK})
+	}
+
+	sortBulkResults(resp.Results)
+	logger.DeRegister(fmt.Sprintf("bulk: %d deregistered, %d failed", resp.Succeeded, resp.Failed), time.Since(startTime))
+	utils.WriteJSONResponse(w, http.StatusOK, resp)
+}
+
+// sortBulkResults restores request order; rejected items are added first.
+func sortBulkResults(results []BulkItemResult) {
+	sort.Slice(results, func(a, b int) bool { return results[a].Index < results[b].Index })
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	env "github.com/tahakara/discogo/internal/config"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+func bulkHeartbeat(t *testing.T, rclient redisclient.Client, body string) (int, BulkResponse) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/v1/bulk/heartbeat", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	BulkHeartbeatHandler(w, r, rclient, env.Defaults().Registry)
+	if w.Code != http.StatusOK {
+		return w.Code, BulkResponse{}
+	}
+	var resp struct {
+		Data BulkResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return w.Code, resp.Data
+}
+
+func TestBulkHeartbeatAppliesPayloads(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		first := registeredAs(t, rclient, "i-1")
+		second := registeredAs(t, rclient, "i-2")
+		third := registeredAs(t, rclient, "i-3")
+
+		code, resp := bulkHeartbeat(t, rclient, fmt.Sprintf(`{"heartbeats": [
+			{"serviceUUID": %q, "payload": {"status": "degraded", "cpuLoad": 42, "metadata": {"zone": "b"}}},
+			{"serviceUUID": %q},
+			{"serviceUUID": %q, "payload": {"status": "sleeping"}}
+		]}`, first.ServiceUUID, second.ServiceUUID, third.ServiceUUID))
+		if code != http.StatusOK {
+			t.Fatalf("bulk heartbeat returned %d", code)
+		}
+		if resp.Succeeded != 2 || resp.Failed != 1 {
+			t.Fatalf("succeeded/failed = %d/%d, want 2/1: %+v", resp.Succeeded, resp.Failed, resp.Results)
+		}
+		if got := resp.Results[2]; got.Status != http.StatusBadRequest || got.Error == nil || got.Error.Code != utils.CodeValidationFailed {
+			t.Fatalf("invalid payload result = %+v, want %s", got, utils.CodeValidationFailed)
+		}
+
+		_, updated := redishelper.IsServiceExistsByUUID(rclient, first.ServiceUUID)
+		if updated.SelfStatus != redishelper.SelfStatusDegraded || updated.CPULoad != 42 || updated.Metadata["zone"] != "b" {
+			t.Fatalf("payload not applied: selfStatus=%s cpuLoad=%v metadata=%v", updated.SelfStatus, updated.CPULoad, updated.Metadata)
+		}
+		_, plain := redishelper.IsServiceExistsByUUID(rclient, second.ServiceUUID)
+		if plain.SelfStatus != redishelper.SelfStatusOK || plain.HeardCount != second.HeardCount+1 {
+			t.Fatalf("plain heartbeat: selfStatus=%s heardCount=%d", plain.SelfStatus, plain.HeardCount)
+		}
+		_, untouched := redishelper.IsServiceExistsByUUID(rclient, third.ServiceUUID)
+		if untouched.HeardCount != third.HeardCount {
+			t.Fatalf("invalid payload still counted a heartbeat")
+		}
+	})
+}
+
+func TestBulkHeartbeatAppliesDuplicatesInOrder(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+
+		code, resp := bulkHeartbeat(t, rclient, fmt.Sprintf(`{"heartbeats": [
+			{"serviceUUID": %[1]q, "payload": {"status": "degraded", "cpuLoad": 10}},
+			{"serviceUUID": %[1]q, "payload": {"cpuLoad": 20}}
+		]}`, entry.ServiceUUID))
+		if code != http.StatusOK {
+			t.Fatalf("bulk heartbeat returned %d", code)
+		}
+		if resp.Succeeded != 2 {
+			t.Fatalf("succeeded = %d, want 2: %+v", resp.Succeeded, resp.Results)
+		}
+
+		_, updated := redishelper.IsServiceExistsByUUID(rclient, entry.ServiceUUID)
+		if updated.HeardCount != entry.HeardCount+2 {
+			t.Fatalf("heardCount = %d, want %d", updated.HeardCount, entry.HeardCount+2)
+		}
+		if updated.CPULoad != 20 {
+			t.Fatalf("cpuLoad = %v, want the later heartbeat's 20", updated.CPULoad)
+		}
+	})
+}
+
+func TestBulkHeartbeatRejectsMixedForms(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+		code, _ := bulkHeartbeat(t, rclient, fmt.Sprintf(`{"serviceUUIDs": [%[1]q], "heartbeats": [{"serviceUUID": %[1]q}]}`, entry.ServiceUUID))
+		if code != http.StatusBadRequest {
+			t.Fatalf("mixed bulk heartbeat returned %d, want 400", code)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package routes
+
+import (
+	"net/http"
+	"sort"
+
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type CatalogInstances struct {
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+type CatalogServiceType struct {
+	serviceconfigloader.ServiceDef
+	Group     string           `json:"group"`
+	Custom    bool             `json:"custom,omitempty"`
+	Retired   bool             `json:"retired,omitempty"`
+	Instances CatalogInstances `json:"instances"`
+}
+
+type CatalogProvider struct {
+	serviceconfigloader.ProviderDef
+	Custom  bool `json:"custom,omitempty"`
+	Retired bool `json:"retired,omitempty"`
+}
+
+type CatalogTypeGroup struct {
+	Key      string               `json:"key"`
+	Name     string               `json:"name"`
+	Services []CatalogServiceType `json:"services"`
+}
+
+type CatalogTypesResponse struct {
+	Status string             `json:"status"`
+	Groups []CatalogTypeGroup `json:"groups"`
+}
+
+type CatalogTypeResponse struct {
+	Status      string              `json:"status"`
+	ServiceType *CatalogServiceType `json:"serviceType,omitempty"`
+}
+
+type CatalogProvidersResponse struct {
+	Status    string            `json:"status"`
+	Providers []CatalogProvider `json:"providers"`
+}
+
+// catalogInstances converts the counts of one type to the response shape,
+// listing every concrete status even when no instance has it.
+func catalogInstances(counts redishelper.InstanceCounts) CatalogInstances {
+	instances := CatalogInstances{
+		Total:    counts.Total(),
+		ByStatus: map[string]int{},
+	}
+	for _, status := range redishelper.GetAllServiceStatuses() {
+		if status == string(redishelper.StatusAny) {
+			continue
+		}
+		instances.ByStatus[status] = counts[redishelper.ServiceStatus(status)]
+	}
+	return instances
+}
+
+// CatalogTypesHandler godoc
+// @Summary      List service types
+// @Description  Returns every service type group from the catalog, including custom types, with names, descriptions and live instance counts per status.
+// @Tags         Catalog
+// @Produce      json,application/problem+json
+// @Success      200  {object}  CatalogTypesResponse
+// @Failure      500  {object}  utils.Problem
+// @Router       /v1/catalog/types [get]
+func CatalogTypesHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	counts, err := redishelper.CountServicesByType(rclient)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to count service instances")
+		return
+	}
+
+	groups := []CatalogTypeGroup{}
+	for key, group := range serviceconfigloader.GetServiceTypeGroups() {
+		services := []CatalogServiceType{}
+		for _, svc := range group.Services {
+			services = append(services, CatalogServiceType{
+				ServiceDef: svc,
+				Group:      key,
+				Custom:     serviceconfigloader.IsCustomServiceType(svc.Short),
+				Retired:    serviceconfigloader.IsRetiredServiceType(svc.Short),
+				Instances:  catalogInstances(counts[svc.Short]),
+			})
+		}
+		groups = append(groups, CatalogTypeGroup{
+			Key:      key,
+			Name:     group.Name,
+			Services: services,
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+
+	utils.WriteJSONResponse(w, http.StatusOK, CatalogTypesResponse{
+		Status: "ok",
+		Groups: groups,
+	})
+}
+
+// CatalogTypeHandler godoc
+// @Summary      Inspect a service type
+// @Description  Returns the name, description, group and live instance counts per status of one service type.
+// @Tags         Catalog
+// @Produce      json,application/problem+json
+// @Param        short  path      string  true  "Service type short name"
+// @Success      200    {object}  CatalogTypeResponse
+// @Failure      404    {object}  utils.Problem
+// @Failure      500    {object}  utils.Problem
+// @Router       /v1/catalog/types/{short} [get]
+func CatalogTypeHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, short string) {
+	name, description, ok := serviceconfigloader.GetServiceTypeInfo(short)
+	if !ok {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeUnknownServiceType, "Unknown service type")
+		return
+	}
+	group, _ := serviceconfigloader.GetServiceTypeGroupKey(short)
+
+	counts, err := redishelper.CountServicesByType(rclient)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to count service instances")
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, CatalogTypeResponse{
+		Status: "ok",
+		ServiceType: &CatalogServiceType{
+			ServiceDef: serviceconfigloader.ServiceDef{
+				Name:        name,
+				Short:       short,
+				Description: description,
+			},
+			Group:     group,
+			Custom:    serviceconfigloader.IsCustomServiceType(short),
+			Retired:   serviceconfigloader.IsRetiredServiceType(short),
+			Instances: catalogInstances(counts[short]),
+		},
+	})
+}
+
+// CatalogProvidersHandler godoc
+// @Summary      List providers
+// @Description  Returns every provider from conf.json and the custom providers managed through the admin API.
+// @Tags         Catalog
+// @Produce      json
+// @Success      200  {object}  CatalogProvidersResponse
+// @Router       /v1/catalog/providers [get]
+func CatalogProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	providers := []CatalogProvider{}
+	for _, provider := range serviceconfigloader.GetProviderDefs() {
+		providers = append(providers, CatalogProvider{
+			ProviderDef: provider,
+			Custom:      !serviceconfigloader.IsStaticProvider(provider.Short),
+			Retired:     serviceconfigloader.IsRetiredProvider(provider.Short),
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Short < providers[j].Short })
+
+	utils.WriteJSONResponse(w, http.StatusOK, CatalogProvidersResponse{
+		Status:    "ok",
+		Providers: providers,
+	})
+}
@@ -0,0 +1,333 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type CreateCustomServiceTypeRequestBody struct {
+	Short       string `json:"short"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Group       string `json:"group,omitempty"`
+}
+
+type PatchCustomServiceTypeRequestBody struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Group       *string `json:"group,omitempty"`
+	Retired     *bool   `json:"retired,omitempty"`
+}
+
+type CreateCustomProviderRequestBody struct {
+	Short string `json:"short"`
+	Name  string `json:"name"`
+}
+
+type PatchCustomProviderRequestBody struct {
+	Name    *string `json:"name,omitempty"`
+	Retired *bool   `json:"retired,omitempty"`
+}
+
+type CustomServiceTypeResponse struct {
+	Status      string                                 `json:"status"`
+	ServiceType *serviceconfigloader.CustomServiceType `json:"serviceType,omitempty"`
+}
+
+type CustomProviderResponse struct {
+	Status   string                              `json:"status"`
+	Provider *serviceconfigloader.CustomProvider `json:"provider,omitempty"`
+}
+
+// writeCatalogError maps custom catalog errors to a problem response.
+func writeCatalogError(w http.ResponseWriter, r *http.Request, err error, kind string) {
+	switch {
+	case errors.Is(err, redishelper.ErrCatalogEntryExists):
+		utils.WriteError(w, r, http.StatusConflict, utils.CodeCatalogEntryExists, fmt.Sprintf("A %s with this short name already exists", kind))
+	case errors.Is(err, redishelper.ErrCatalogEntryNotFound):
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeCatalogEntryNotFound, fmt.Sprintf("Custom %s not found", kind))
+	default:
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, fmt.Sprintf("Failed to store custom %s", kind))
+	}
+}
+
+func recordCatalogAuditEvent(rclient redisclient.Client, r *http.Request, action redishelper.AuditAction, serviceType string, reason string) {
+	redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+		Action:      action,
+		ServiceType: serviceType,
+		Actor:       adminActor(r),
+		SourceIP:    utils.GetClientIP(r),
+		Reason:      reason,
+	})
+}
+
+// CreateCustomServiceTypeHandler godoc
+// @Summary      Create a custom service type
+// @Description  Adds a service type that is not defined in conf.json. It is stored in the registry backend and accepted for registration immediately.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        request  body      CreateCustomServiceTypeRequestBody  true  "Service type"
+// @Success      201      {object}  CustomServiceTypeResponse
+// @Failure      400      {object}  utils.Problem
+// @Failure      409      {object}  utils.Problem
+// @Failure      500      {object}  utils.Problem
+// @Router       /v1/admin/catalog/types [post]
+func CreateCustomServiceTypeHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	startTime := time.Now()
+
+	var body CreateCustomServiceTypeRequestBody
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if !serviceconfigloader.IsValidShortName(body.Short) {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Invalid short name (1-32 letters, digits, '-' or '_')")
+		return
+	}
+	if strings.TrimSpace(body.Name) == "" {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Name is required")
+		return
+	}
+
+	created, err := redishelper.CreateCustomServiceType(rclient, serviceconfigloader.CustomServiceType{
+		ServiceDef: serviceconfigloader.ServiceDef{
+			Name:        body.Name,
+			Short:       body.Short,
+			Description: body.Description,
+		},
+		Group: body.Group,
+	})
+	if err != nil {
+		writeCatalogError(w, r, err, "service type")
+		return
+	}
+
+	recordCatalogAuditEvent(rclient, r, redishelper.AuditCatalogCreate, created.Short, "service type created")
+	logger.Info(fmt.Sprintf("Custom service type %s created", created.Short), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusCreated, CustomServiceTypeResponse{
+		Status:      "ok",
+		ServiceType: &created,
+	})
+}
+
+// PatchCustomServiceTypeHandler godoc
+// @Summary      Update a custom service type
+// @Description  Changes the name, description or group of a custom service type, or retires / restores it.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        short    path      string                             true  "Service type short name"
+// @Param        request  body      PatchCustomServiceTypeRequestBody  true  "Changes"
+// @Success      200      {object}  CustomServiceTypeResponse
+// @Failure      400      {object}  utils.Problem
+// @Failure      404      {object}  utils.Problem
+// @Failure      500      {object}  utils.Problem
+// @Router       /v1/admin/catalog/types/{short} [patch]
+func PatchCustomServiceTypeHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, short string) {
+	startTime := time.Now()
+
+	var body PatchCustomServiceTypeRequestBody
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if body.Name != nil && strings.TrimSpace(*body.Name) == "" {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Name cannot be empty")
+		return
+	}
+	if body.Name == nil && body.Description == nil && body.Group == nil && body.Retired == nil {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Nothing to update")
+		return
+	}
+
+	_, updated, err := redishelper.UpdateCustomServiceType(rclient, short, redishelper.CustomServiceTypeUpdate{
+		Name:        body.Name,
+		Description: body.Description,
+		Group:       body.Group,
+		Retired:     body.Retired,
+	})
+	if err != nil {
+		writeCatalogError(w, r, err, "service type")
+		return
+	}
+
+	recordCatalogAuditEvent(rclient, r, redishelper.AuditCatalogUpdate, short, "service type updated")
+	logger.Info(fmt.Sprintf("Custom service type %s updated", short), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, CustomServiceTypeResponse{
+		Status:      "ok",
+		ServiceType: &updated,
+	})
+}
+
+// RetireCustomServiceTypeHandler godoc
+// @Summary      Retire a custom service type
+// @Description  Rejects new registrations of the type. Existing instances stay discoverable until they expire.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        short  path      string  true  "Service type short name"
+// @Success      200    {object}  CustomServiceTypeResponse
+// @Failure      404    {object}  utils.Problem
+// @Failure      500    {object}  utils.Problem
+// @Router       /v1/admin/catalog/types/{short} [delete]
+func RetireCustomServiceTypeHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, short string) {
+	startTime := time.Now()
+
+	retired := true
+	_, updated, err := redishelper.UpdateCustomServiceType(rclient, short, redishelper.CustomServiceTypeUpdate{Retired: &retired})
+	if err != nil {
+		writeCatalogError(w, r, err, "service type")
+		return
+	}
+
+	recordCatalogAuditEvent(rclient, r, redishelper.AuditCatalogRetire, short, "service type retired")
+	logger.Info(fmt.Sprintf("Custom service type %s retired", short), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, CustomServiceTypeResponse{
+		Status:      "ok",
+		ServiceType: &updated,
+	})
+}
+
+// CreateCustomProviderHandler godoc
+// @Summary      Create a custom provider
+// @Description  Adds a provider that is not defined in conf.json. It is stored in the registry backend and accepted for registration immediately.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        request  body      CreateCustomProviderRequestBody  true  "Provider"
+// @Success      201      {object}  CustomProviderResponse
+// @Failure      400      {object}  utils.Problem
+// @Failure      409      {object}  utils.Problem
+// @Failure      500      {object}  utils.Problem
+// @Router       /v1/admin/catalog/providers [post]
+func CreateCustomProviderHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	startTime := time.Now()
+
+	var body CreateCustomProviderRequestBody
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if !serviceconfigloader.IsValidShortName(body.Short) {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Invalid short name (1-32 letters, digits, '-' or '_')")
+		return
+	}
+	if strings.TrimSpace(body.Name) == "" {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Name is required")
+		return
+	}
+
+	created, err := redishelper.CreateCustomProvider(rclient, serviceconfigloader.CustomProvider{
+		ProviderDef: serviceconfigloader.ProviderDef{
+			Name:  body.Name,
+			Short: body.Short,
+		},
+	})
+	if err != nil {
+		writeCatalogError(w, r, err, "provider")
+		return
+	}
+
+	recordCatalogAuditEvent(rclient, r, redishelper.AuditCatalogCreate, "", "provider "+created.Short+" created")
+	logger.Info(fmt.Sprintf("Custom provider %s created", created.Short), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusCreated, CustomProviderResponse{
+		Status:   "ok",
+		Provider: &created,
+	})
+}
+
+// PatchCustomProviderHandler godoc
+// @Summary      Update a custom provider
+// @Description  Changes the name of a custom provider, or retires / restores it.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        short    path      string                          true  "Provider short name"
+// @Param        request  body      PatchCustomProviderRequestBody  true  "Changes"
+// @Success      200      {object}  CustomProviderResponse
+// @Failure      400      {object}  utils.Problem
+// @Failure      404      {object}  utils.Problem
+// @Failure      500      {object}  utils.Problem
+// @Router       /v1/admin/catalog/providers/{short} [patch]
+func PatchCustomProviderHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, short string) {
+	startTime := time.Now()
+
+	var body PatchCustomProviderRequestBody
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if body.Name != nil && strings.TrimSpace(*body.Name) == "" {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Name cannot be empty")
+		return
+	}
+	if body.Name == nil && body.Retired == nil {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Nothing to update")
+		return
+	}
+
+	_, updated, err := redishelper.UpdateCustomProvider(rclient, short, redishelper.CustomProviderUpdate{
+		Name:    body.Name,
+		Retired: body.Retired,
+	})
+	if err != nil {
+		writeCatalogError(w, r, err, "provider")
+		return
+	}
+
+	recordCatalogAuditEvent(rclient, r, redishelper.AuditCatalogUpdate, "", "provider "+short+" updated")
+	logger.Info(fmt.Sprintf("Custom provider %s updated", short), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, CustomProviderResponse{
+		Status:   "ok",
+		Provider: &updated,
+	})
+}
+
+// RetireCustomProviderHandler godoc
+// @Summary      Retire a custom provider
+// @Description  Rejects new registrations with the provider. Existing instances stay discoverable until they expire.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        short  path      string  true  "Provider short name"
+// @Success      200    {object}  CustomProviderResponse
+// @Failure      404    {object}  utils.Problem
+// @Failure      500    {object}  utils.Problem
+// @Router       /v1/admin/catalog/providers/{short} [delete]
+func RetireCustomProviderHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, short string) {
+	startTime := time.Now()
+
+	retired := true
+	_, updated, err := redishelper.UpdateCustomProvider(rclient, short, redishelper.CustomProviderUpdate{Retired: &retired})
+	if err != nil {
+		writeCatalogError(w, r, err, "provider")
+		return
+	}
+
+	recordCatalogAuditEvent(rclient, r, redishelper.AuditCatalogRetire, "", "provider "+short+" retired")
+	logger.Info(fmt.Sprintf("Custom provider %s retired", short), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, CustomProviderResponse{
+		Status:   "ok",
+		Provider: &updated,
+	})
+}
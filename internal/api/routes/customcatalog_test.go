@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+func TestRetiredServiceTypeKeepsInstancesDiscoverable(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		t.Cleanup(func() { serviceconfigloader.SetCustomCatalog(nil, nil) })
+		legacy := serviceconfigloader.CustomServiceType{ServiceDef: serviceconfigloader.ServiceDef{Name: "Legacy", Short: "legacy"}}
+		if _, err := redishelper.CreateCustomServiceType(rclient, legacy); err != nil {
+			t.Fatal(err)
+		}
+		legacyBody := func(instanceID string) string {
+			body := strings.Replace(registerBody, `"Type":"gw"`, `"Type":"legacy"`, 1)
+			return strings.Replace(body, `"InstanceID":"i-1"`, `"InstanceID":"`+instanceID+`"`, 1)
+		}
+		existing := registeredBody(t, rclient, legacyBody("i-1"))
+
+		retired := true
+		if _, _, err := redishelper.UpdateCustomServiceType(rclient, "legacy", redishelper.CustomServiceTypeUpdate{Retired: &retired}); err != nil {
+			t.Fatal(err)
+		}
+
+		w := register(rclient, legacyBody("i-2"))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("registration of a retired type returned %d, want 400: %s", w.Code, w.Body)
+		}
+		var problem struct {
+			Code   string `json:"code"`
+			Errors []struct {
+				Field string `json:"field"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Code != utils.CodeValidationFailed || len(problem.Errors) != 1 || problem.Errors[0].Field != "Type" {
+			t.Fatalf("problem = %+v, want VALIDATION_FAILED on Type", problem)
+		}
+
+		services := discovered(t, discover(rclient, "servicetype=legacy&status=registered"))
+		if len(services) != 1 || services[0].ServiceID != existing.ServiceUUID {
+			t.Fatalf("discover of a retired type = %+v, want the existing instance %s", services, existing.ServiceUUID)
+		}
+		if w := heartbeat(rclient, existing.ServiceUUID, "{}"); w.Code != http.StatusOK {
+			t.Fatalf("heartbeat of a retired type's instance returned %d: %s", w.Code, w.Body)
+		}
+	})
+}
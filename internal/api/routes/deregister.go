@@ -1,7 +1,7 @@
 package routes
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -24,61 +24,73 @@ type DeregisterResponse struct {
 // @Description Deregisters a service from the registry using its UUID.
 // @Tags DiscoGo
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param DeregisterRequestBody body DeregisterRequestBody true "Service UUID to deregister"
 // @Success 200 {object} DeregisterResponse "Service deregistered successfully"
-// @Failure 400 {object} DeregisterResponse "Invalid request body or serviceUUID"
-// @Failure 404 {object} DeregisterResponse "Service not found"
-// @Failure 500 {object} DeregisterResponse "Failed to deregister service"
-// @Router /deregister [post]
+// @Failure 400 {object} utils.Problem "INVALID_BODY or INVALID_UUID"
+// @Failure 404 {object} utils.Problem "SERVICE_NOT_FOUND"
+// @Failure 409 {object} utils.Problem "SERVICE_BUSY"
+// @Failure 500 {object} utils.Problem "INTERNAL_ERROR"
+// @Router /v1/deregister [post]
 func DeregisterHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
-	startTime := time.Now()
 	var body DeregisterRequestBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, DeregisterResponse{
-			Message: "Invalid request body",
-			Status:  "error",
-		})
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
 	if body.ServiceUUID == "" {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, DeregisterResponse{
-			Message: "serviceUUID is required",
-			Status:  "error",
-		})
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidUUID, "serviceUUID is required")
 		return
 	}
 
-	isValid, err := utils.ValidateUUID(body.ServiceUUID)
-	if err != nil || !isValid {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, DeregisterResponse{
-			Message: "Invalid serviceUUID format",
-			Status:  "error",
-		})
+	if !validateServiceUUIDParam(w, r, body.ServiceUUID) {
+		return
+	}
+	if !deregisterService(w, r, rclient, body.ServiceUUID) {
 		return
 	}
 
-	result, err := redishelper.DeregisterServiceEntry(rclient, body.ServiceUUID)
+	utils.WriteJSONResponse(w, http.StatusOK, DeregisterResponse{
+		Message: "Service deregistered successfully",
+		Status:  "success",
+	})
+}
+
+// deregisterService removes the entry of serviceUUID. On failure it writes
+// the problem response and returns false.
+func deregisterService(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceUUID string) bool {
+	startTime := time.Now()
+	// Looked up only for the audit trail
+	found, entry := redishelper.IsServiceExistsByUUID(rclient, serviceUUID)
+
+	result, err := redishelper.DeregisterServiceEntry(rclient, serviceUUID)
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return false
+	}
 	if err != nil {
-		utils.WriteJSONResponse(w, http.StatusInternalServerError, DeregisterResponse{
-			Message: "Failed to deregister service",
-			Status:  "error",
-		})
-		return
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to deregister service")
+		return false
 	}
 
 	if !result {
-		utils.WriteJSONResponse(w, http.StatusNotFound, DeregisterResponse{
-			Message: "Service not found",
-			Status:  "error",
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return false
+	}
+
+	if found {
+		redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+			Action:      redishelper.AuditDeregister,
+			ServiceUUID: serviceUUID,
+			ServiceType: entry.Type,
+			Actor:       utils.GetActor(r, "service"),
+			SourceIP:    utils.GetClientIP(r),
+			OldStatus:   entry.Status,
+			NewStatus:   redishelper.StatusDeregistered,
 		})
-		return
 	}
 
-	logger.DeRegister(body.ServiceUUID, time.Since(startTime))
-	utils.WriteJSONResponse(w, http.StatusOK, DeregisterResponse{
-		Message: "Service deregistered successfully",
-		Status:  "success",
-	})
+	logger.DeRegister(serviceUUID, time.Since(startTime))
+	return true
 }
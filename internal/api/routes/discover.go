@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	validators "github.com/tahakara/discogo/internal/api/validators"
 	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
 	"github.com/tahakara/discogo/internal/logger"
 	redisclient "github.com/tahakara/discogo/internal/redis"
@@ -14,17 +15,68 @@ import (
 )
 
 type ServiceInfo struct {
-	ServiceID   string `json:"serviceID"`
-	ServiceAddr string `json:"serviceAddr"`
+	ServiceID   string    `json:"serviceID"`
+	ServiceAddr string    `json:"serviceAddr"`
+	Status      string    `json:"status,omitempty"`
+	Load        *LoadInfo `json:"load,omitempty"`
+}
+
+// LoadInfo is the load an instance reported with its last heartbeat.
+type LoadInfo struct {
+	CPU      float64 `json:"cpu"`      // percent
+	Memory   float64 `json:"memory"`   // percent
+	InFlight int64   `json:"inFlight"` // requests being served
+}
+
+// loadInfo returns the reported load of service, or nil if it never sent any.
+func loadInfo(service redishelper.ServiceEntry) *LoadInfo {
+	if !service.HasLoad {
+		return nil
+	}
+	return &LoadInfo{CPU: service.CPULoad, Memory: service.MemoryLoad, InFlight: service.InFlight}
 }
 
 type DiscoverResponse struct {
-	Status        string        `json:"status"`
-	Message       string        `json:"message,omitempty"`
-	ServiceTypes  []string      `json:"serviceTypes,omitempty"`
-	ProviderTypes []string      `json:"providerTypes,omitempty"`
-	StatusTypes   []string      `json:"statusTypes,omitempty"`
-	Services      []ServiceInfo `json:"services"`
+	Status   string        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Services []ServiceInfo `json:"services"`
+}
+
+// writeInvalidParameter reports a bad query parameter together with the
+// values it accepts.
+func writeInvalidParameter(w http.ResponseWriter, r *http.Request, param, detail string, allowed []string) {
+	problem := utils.NewProblem(http.StatusBadRequest, utils.CodeInvalidParameter, detail)
+	problem.Param = param
+	problem.Allowed = allowed
+	utils.WriteProblem(w, r, problem)
+}
+
+// filterParams lists the free-form filter query parameters of discovery and
+// listing, which follow the same rules as the registered values they match.
+var filterParams = []struct {
+	name  string
+	rule  string
+	valid func(string) bool
+}{
+	{"region", "letters, digits, '-' and '_'", validators.IsIdentifier},
+	{"zone", "letters, digits, '-' and '_'", validators.IsIdentifier},
+	{"networkid", "letters, digits, '-' and '_'", validators.IsIdentifier},
+	{"subnetid", "letters, digits, '-' and '_'", validators.IsIdentifier},
+	{"instanceid", "letters, digits, '-' and '_'", validators.IsIdentifier},
+	{"version", "dot-separated numbers", validators.IsVersion},
+}
+
+// validFilterParams checks the filter query parameters of r. On failure it
+// writes the problem response and returns false.
+func validFilterParams(w http.ResponseWriter, r *http.Request) bool {
+	query := r.URL.Query()
+	for _, param := range filterParams {
+		if value := query.Get(param.name); value != "" && !param.valid(value) {
+			writeInvalidParameter(w, r, param.name, fmt.Sprintf("Invalid '%s' query parameter (must be %s)", param.name, param.rule), nil)
+			return false
+		}
+	}
+	return true
 }
 
 // DiscoverHandler handles service discovery requests.
@@ -33,9 +85,9 @@ type DiscoverResponse struct {
 // @Description  Retrieves a list of services filtered by query parameters such as service type, provider, region, zone, network ID, subnet ID, instance ID, and version. Supports pagination.
 // @Tags         DiscoGo
 // @Accept       json
-// @Produce      json
-// @Param        servicetype   query     string  true   "Service type to discover"  Enums(mock,test,perftest,loadgen,staging,dev,debug,mq,eventbus,notify,email,sms,push,inmsg,chat,monitor,log,alert,health,cb,lb,discovery,config,util,helper,migrate,cleanup,archive,maint,other,stream,audio,live,transcode,abr,drm,quality,user,auth,authz,profile,prefs,social,watchlist,history,web,mobile,admin,cdn,assets,img,video,catalog,recommend,search,personal,ingest,metadata,subtitle,thumb,sub,billing,payment,pricing,trial,entitle,revenue,secgw,waf,fraud,audit,encrypt,kms,comply,threat,workflow,scheduler,pipeline,etl,batch,eventproc,orchestrate,3rdapi,partner,social,paygate,cdnint,cloudstor,tracker,gw,rest,graphql,grpc,ws,webhook,ratelimit,db,analyticsdb,cache,file,object,datalake,backup,sync,analytics,rtanalytics,abtest,flags,ml,ds,report,metrics)  // Replace with actual service types
-// @Param		 status query     string  false  "Service status"            Enums(healthy,unknown,suspicious,registered,deregistered) // Replace with actual statuses
+// @Produce      json,application/problem+json
+// @Param        servicetype   query     string  true   "Service type to discover"  Enums(mock,test,perftest,loadgen,staging,dev,debug,mq,eventbus,notify,email,sms,push,inmsg,chat,monitor,log,alert,health,cb,lb,discovery,config,util,helper,migrate,cleanup,archive,maint,other,stream,audio,live,transcode,abr,drm,quality,user,auth,authz,profile,prefs,social,watchlist,history,web,mobile,admin,cdn,assets,img,video,catalog,recommend,search,personal,ingest,metadata,subtitle,thumb,sub,billing,payment,pricing,trial,entitle,revenue,secgw,waf,fraud,audit,encrypt,kms,comply,threat,workflow,scheduler,pipeline,etl,batch,eventproc,orchestrate,3rdapi,partner,sociallogin,paygate,cdnint,cloudstor,tracker,gw,rest,graphql,grpc,ws,webhook,ratelimit,db,analyticsdb,cache,file,object,datalake,backup,sync,analytics,rtanalytics,abtest,flags,ml,ds,report,metrics)  // Replace with actual service types
+// @Param		 status query     string  false  "Service status"            Enums(healthy,degraded,unknown,suspicious,registered,deregistered) // Replace with actual statuses
 // @Param        provider      query     string  false  "Service provider"          Enums(provider1,provider2,...) // Replace with actual providers
 // @Param        region        query     string  false  "Region"
 // @Param        zone          query     string  false  "Zone"
@@ -46,12 +98,47 @@ type DiscoverResponse struct {
 // @Param        pagesize      query     int     false  "Number of results per page (1-10)" minimum(1) maximum(10)
 // @Param        pageoffset    query     int     false  "Page offset (>= 0)" minimum(0)
 // @Success      200  {object}  DiscoverResponse  "List of discovered services"
-// @Failure      400  {object}  DiscoverResponse  "Invalid request parameters"
-// @Failure      500  {object}  DiscoverResponse  "Internal server error"
-// @Router       /disco/discover [get]
+// @Failure      400  {object}  utils.Problem     "INVALID_PARAMETER, with the accepted values in allowed"
+// @Failure      500  {object}  utils.Problem     "INTERNAL_ERROR"
+// @Router       /v1/discover [get]
 func DiscoverHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	services, ok := discoverServices(w, r, rclient, r.URL.Query().Get("servicetype"))
+	if !ok {
+		return
+	}
+
+	var serviceInfos []ServiceInfo
+	for _, service := range services {
+		serviceInfos = append(serviceInfos, ServiceInfo{
+			ServiceID:   service.ServiceUUID,
+			ServiceAddr: serviceAddr(service),
+			Status:      string(service.Status),
+			Load:        loadInfo(service),
+		})
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, DiscoverResponse{
+		Status:   "success",
+		Message:  "Services discovered successfully",
+		Services: serviceInfos,
+	})
+}
+
+// serviceAddr returns the dialable address of service, preferring IPv4.
+func serviceAddr(service redishelper.ServiceEntry) string {
+	if service.Addr4 != "" {
+		return service.Addr4 + ":" + strconv.Itoa(service.Port4)
+	} else if service.Addr6 != "" {
+		return "[" + service.Addr6 + "]:" + strconv.Itoa(service.Port6)
+	}
+	return ""
+}
+
+// discoverServices looks up the services of serviceType matching the filter
+// and paging query parameters of r. On failure it writes the problem
+// response and returns false.
+func discoverServices(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceType string) ([]redishelper.ServiceEntry, bool) {
 	startTime := time.Now()
-	serviceType := r.URL.Query().Get("servicetype")
 	selectedServiceStatus := r.URL.Query().Get("status") // Optional, default to any status
 	provider := r.URL.Query().Get("provider")
 	region := r.URL.Query().Get("region")
@@ -76,11 +163,8 @@ func DiscoverHandler(w http.ResponseWriter, r *http.Request, rclient redisclient
 		if n, err := strconv.Atoi(pageSizeStr); err == nil && n > 0 && n <= maxPageSize {
 			pageSize = n
 		} else {
-			utils.WriteJSONResponse(w, http.StatusBadRequest, DiscoverResponse{
-				Status:  "error",
-				Message: "Invalid 'pagesize' query parameter (must be 1-10)",
-			})
-			return
+			writeInvalidParameter(w, r, "pagesize", "Invalid 'pagesize' query parameter (must be 1-10)", nil)
+			return nil, false
 		}
 	}
 
@@ -88,51 +172,37 @@ func DiscoverHandler(w http.ResponseWriter, r *http.Request, rclient redisclient
 		if n, err := strconv.Atoi(pageOffsetStr); err == nil && n >= minPageOffset {
 			pageOffset = n
 		} else {
-			utils.WriteJSONResponse(w, http.StatusBadRequest, DiscoverResponse{
-				Status:  "error",
-				Message: "Invalid 'pageoffset' query parameter (must be >= 0)",
-			})
-			return
+			writeInvalidParameter(w, r, "pageoffset", "Invalid 'pageoffset' query parameter (must be >= 0)", nil)
+			return nil, false
 		}
 	}
 
 	if serviceType == "" {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, DiscoverResponse{
-			Status:  "error",
-			Message: "Missing 'servicetype' query parameter",
-		})
-		return
+		writeInvalidParameter(w, r, "servicetype", "Missing 'servicetype' query parameter", serviceconfigloader.GetAllServiceTypes())
+		return nil, false
 	}
 
-	if !serviceconfigloader.IsValidServiceType(serviceType) {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, DiscoverResponse{
-			Status:       "error",
-			Message:      "Invalid 'servicetype' query parameter",
-			ServiceTypes: serviceconfigloader.GetAllServiceTypes(),
-		})
-		return
+	if !serviceconfigloader.IsKnownServiceType(serviceType) {
+		writeInvalidParameter(w, r, "servicetype", "Invalid 'servicetype' query parameter", serviceconfigloader.GetAllServiceTypes())
+		return nil, false
 	}
 
-	if provider != "" && !serviceconfigloader.IsValidProvider(provider) {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, DiscoverResponse{
-			Status:        "error",
-			Message:       "Invalid 'provider' query parameter",
-			ProviderTypes: serviceconfigloader.GetAllProviders(),
-		})
-		return
+	if provider != "" && !serviceconfigloader.IsKnownProvider(provider) {
+		writeInvalidParameter(w, r, "provider", "Invalid 'provider' query parameter", serviceconfigloader.GetAllProviders())
+		return nil, false
 	}
 
 	if selectedServiceStatus != "" {
 		if !redishelper.IsValidServiceStatus(selectedServiceStatus) {
-			utils.WriteJSONResponse(w, http.StatusBadRequest, DiscoverResponse{
-				Status:      "error",
-				Message:     "Invalid 'status' query parameter",
-				StatusTypes: redishelper.GetAllServiceStatuses(),
-			})
-			return
+			writeInvalidParameter(w, r, "status", "Invalid 'status' query parameter", redishelper.GetAllServiceStatuses())
+			return nil, false
 		}
 	}
 
+	if !validFilterParams(w, r) {
+		return nil, false
+	}
+
 	services, err := redishelper.GetServicesFiltered(
 		rclient,
 		serviceType,
@@ -148,31 +218,10 @@ func DiscoverHandler(w http.ResponseWriter, r *http.Request, rclient redisclient
 		pageOffset,
 	)
 	if err != nil {
-		utils.WriteJSONResponse(w, http.StatusInternalServerError, DiscoverResponse{
-			Status:  "error",
-			Message: "Failed to retrieve services",
-		})
-		return
-	}
-
-	var serviceInfos []ServiceInfo
-	for _, service := range services {
-		addr := ""
-		if service.Addr4 != "" {
-			addr = service.Addr4 + ":" + strconv.Itoa(service.Port4)
-		} else if service.Addr6 != "" {
-			addr = "[" + service.Addr6 + "]:" + strconv.Itoa(service.Port6)
-		}
-		serviceInfos = append(serviceInfos, ServiceInfo{
-			ServiceID:   service.ServiceUUID,
-			ServiceAddr: addr,
-		})
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to retrieve services")
+		return nil, false
 	}
 
-	logger.Discovery(fmt.Sprintf("Discovered '%s':(%v)", serviceType, len(serviceInfos)), time.Since(startTime))
-	utils.WriteJSONResponse(w, http.StatusOK, DiscoverResponse{
-		Status:   "success",
-		Message:  "Services discovered successfully",
-		Services: serviceInfos,
-	})
+	logger.Discovery(fmt.Sprintf("Discovered '%s':(%v)", serviceType, len(services)), time.Since(startTime))
+	return services, true
 }
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+func discover(rclient redisclient.Client, query string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/v1/discover?"+query, nil)
+	w := httptest.NewRecorder()
+	DiscoverHandler(w, r, rclient)
+	return w
+}
+
+func discovered(t *testing.T, w *httptest.ResponseRecorder) []ServiceInfo {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("discover returned %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data DiscoverResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.Data.Services
+}
+
+// eject marks the stored entry of serviceUUID as ejected for an hour.
+func eject(t *testing.T, rclient redisclient.Client, serviceUUID string) {
+	t.Helper()
+	keys, err := rclient.FindKeys(serviceUUID + ":*")
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("keys of %s: %v %v", serviceUUID, keys, err)
+	}
+	data, err := rclient.Get(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry redishelper.ServiceEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.EjectedUntil = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := rclient.Set(keys[0], data, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoverPaginatesAfterEjection(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		var available string
+		for i, instanceID := range []string{"i-1", "i-2", "i-3"} {
+			entry := registeredAs(t, rclient, instanceID)
+			if i == 1 {
+				available = entry.ServiceUUID
+			} else {
+				eject(t, rclient, entry.ServiceUUID)
+			}
+		}
+
+		first := discovered(t, discover(rclient, "servicetype=gw&status=registered&pagesize=1"))
+		if len(first) != 1 || first[0].ServiceID != available {
+			t.Fatalf("first page = %+v, want only %s", first, available)
+		}
+		if second := discovered(t, discover(rclient, "servicetype=gw&status=registered&pagesize=1&pageoffset=1")); len(second) != 0 {
+			t.Fatalf("second page = %+v, want none", second)
+		}
+	})
+}
+
+func TestDiscoverRejectsGlobFilters(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		registered(t, rclient)
+
+		params := []string{"servicetype", "provider", "status", "region", "zone", "networkid", "subnetid", "instanceid", "version"}
+		for _, param := range params {
+			for _, value := range []string{"*", "g?", "[a-z]", "gw:x", "r1:*"} {
+				if param == "status" && value == "*" {
+					// The documented "any status" value, not a pattern
+					continue
+				}
+				query := url.Values{"servicetype": {"gw"}}
+				query.Set(param, value)
+				w := discover(rclient, query.Encode())
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("%s=%q returned %d, want 400: %s", param, value, w.Code, w.Body)
+					continue
+				}
+				var problem struct {
+					Code  string `json:"code"`
+					Param string `json:"param"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+					t.Fatal(err)
+				}
+				if problem.Code != "INVALID_PARAMETER" || problem.Param != param {
+					t.Errorf("%s=%q returned %s for %q, want INVALID_PARAMETER for %q", param, value, problem.Code, problem.Param, param)
+				}
+			}
+		}
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"time"
 
-	env "github.com/tahakara/discogo/internal/config"
 	"github.com/tahakara/discogo/internal/logger"
 	redisclient "github.com/tahakara/discogo/internal/redis"
 	"github.com/tahakara/discogo/internal/utils"
@@ -22,39 +21,25 @@ type HealthCheckResponse struct {
 	Status HealthStatus `json:"status"`
 }
 
-func _startRedisService() redisclient.Client {
-	startTime := time.Now()
-	addr := env.GetRedisServerAddr()
-	password := env.GetRedisPassword() // Şifre yoksa "" döndürsün
-	db := env.GetRedisDB()             // Örn: 0
-
-	client := redisclient.New(addr, password, db)
-	err := client.Ping()
-
-	if err != nil {
-		logger.Error(fmt.Sprintf("Redis connection failed: %v", err), time.Since(startTime))
-		return nil
-	}
-	logger.Info(fmt.Sprintf("Redis connected to %s", addr), time.Since(startTime))
-	return client
-}
-
 // HealthCheck godoc
 // @Summary      Health check endpoint
-// @Description  Returns the health status of the API and Redis connection
+// @Description  Returns the health status of the API and its storage backend
 // @Tags         DiscoGo
-// @Produce      json
+// @Produce      json,application/problem+json
 // @Success      200  {object}  HealthCheckResponse
-// @Failure      500  {object}  HealthCheckResponse
-// @Router       /disco/health [get]
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+// @Failure      503  {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v1/health [get]
+func HealthCheckHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
 	startTime := time.Now()
-	client := _startRedisService()
-	if client == nil {
-		utils.WriteJSONResponse(w, http.StatusInternalServerError, HealthCheckResponse{Status: StatusUnhealthy})
+	if rclient == nil {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Storage backend is not available")
+		return
+	}
+	if err := rclient.Ping(); err != nil {
+		logger.Error(fmt.Sprintf("Storage backend ping failed: %v", err), time.Since(startTime))
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Storage backend is not available")
 		return
 	}
-	client.Close()
 	logger.HealthCheck("ok", time.Since(startTime))
 
 	utils.WriteJSONResponse(w, http.StatusOK, HealthCheckResponse{Status: StatusHealthy})
@@ -1,10 +1,15 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
+	requestDTOs "github.com/tahakara/discogo/internal/api/dtos/requestdto"
+	validators "github.com/tahakara/discogo/internal/api/validators"
+	env "github.com/tahakara/discogo/internal/config"
 	"github.com/tahakara/discogo/internal/logger"
 	redisclient "github.com/tahakara/discogo/internal/redis"
 	redisHelper "github.com/tahakara/discogo/internal/redis/helper"
@@ -13,51 +18,124 @@ import (
 
 type HeartbeatResponse struct {
 	Status string `json:"status"`
-	Reason string `json:"reason,omitempty"`
 }
 
 // HeartbeatHandler godoc
 // @Summary      Heartbeat endpoint
-// @Description  Checks the health of a service by UUID and updates its status in Redis.
+// @Description  Checks the health of a service by UUID and updates its status in Redis. The optional body reports load, a self-status (ok, degraded, failing) and metadata; degraded instances are discoverable with status=degraded.
 // @Tags         DiscoGo
 // @Accept       json
-// @Produce      json
-// @Param        uuid  query     string  true  "Service UUID"
+// @Produce      json,application/problem+json
+// @Param        uuid     path      string                          true   "Service UUID"
+// @Param        request  body      requestdto.HeartbeatRequestDTO  false  "Load and health report"
 // @Success      200   {object}  HeartbeatResponse
-// @Failure      400   {object}  HeartbeatResponse
-// @Failure      500   {object}  HeartbeatResponse
-// @Router       /disco/heartbeat [post]
-func HeartbeatHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, uuid string) {
-	startTime := time.Now()
-	if uuid == "" {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, HeartbeatResponse{
-			Status: "error",
-			Reason: "Missing uuid",
-		})
+// @Failure      400   {object}  utils.Problem  "INVALID_UUID, INVALID_BODY or VALIDATION_FAILED"
+// @Failure      404   {object}  utils.Problem  "SERVICE_NOT_FOUND"
+// @Failure      409   {object}  utils.Problem  "SERVICE_SUSPICIOUS, ILLEGAL_TRANSITION or SERVICE_BUSY"
+// @Failure      500   {object}  utils.Problem  "INTERNAL_ERROR"
+// @Router       /v1/heartbeat/{uuid} [post]
+func HeartbeatHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig, uuid string) {
+	if !validateServiceUUIDParam(w, r, uuid) {
 		return
 	}
-
-	matched, err := utils.ValidateUUID(uuid)
-	if err != nil || !matched {
-		utils.WriteJSONResponse(w, http.StatusBadRequest, HeartbeatResponse{
-			Status: "error",
-			Reason: "Invalid uuid format",
-		})
+	payload, ok := decodeHeartbeatPayload(w, r)
+	if !ok {
 		return
 	}
-
-	updated, err := redisHelper.UpdateServiceEntry(rclient, uuid)
-	if !updated {
-		utils.WriteJSONResponse(w, http.StatusInternalServerError, HeartbeatResponse{
-			Status: "error",
-			Reason: err.Error(),
-		})
+	if !heartbeatService(w, r, rclient, registry, uuid, payload) {
 		return
 	}
 
-	logger.HeartBeat(fmt.Sprintf("%s healthy", uuid), time.Since(startTime))
 	utils.WriteJSONResponse(w, http.StatusOK, HeartbeatResponse{
 		Status: "ok",
 	})
+}
+
+// decodeHeartbeatPayload reads the optional heartbeat body. An empty body is
+// a plain heartbeat. On failure it writes the problem response and returns
+// false.
+func decodeHeartbeatPayload(w http.ResponseWriter, r *http.Request) (redisHelper.HeartbeatPayload, bool) {
+	var req requestDTOs.HeartbeatRequestDTO
+	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
+		if errors.Is(err, io.EOF) {
+			return redisHelper.HeartbeatPayload{}, true
+		}
+		utils.WriteDecodeError(w, r, err)
+		return redisHelper.HeartbeatPayload{}, false
+	}
+
+	lang := validators.NegotiateLanguage(r.Header.Get("Accept-Language"))
+	if errs := validators.ValidateHeartbeatRequest(&req, lang); errs != nil {
+		w.Header().Set("Content-Language", lang)
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidationFailed, validators.Summary(lang, errs))
+		problem.Errors = errs
+		utils.WriteProblem(w, r, problem)
+		return redisHelper.HeartbeatPayload{}, false
+	}
+
+	return heartbeatPayload(req), true
+}
+
+// heartbeatPayload maps a validated heartbeat body onto the stored report.
+func heartbeatPayload(req requestDTOs.HeartbeatRequestDTO) redisHelper.HeartbeatPayload {
+	return redisHelper.HeartbeatPayload{
+		CPULoad:    req.CPULoad,
+		MemoryLoad: req.MemoryLoad,
+		InFlight:   req.InFlight,
+		SelfStatus: req.Status,
+		Metadata:   req.Metadata,
+	}
+}
+
+// Problem details shared by the heartbeat endpoints.
+const (
+	suspiciousDetail       = "Service has been reported too often and stays suspicious until it recovers"
+	illegalHeartbeatDetail = "The current status of the service does not accept heartbeats"
+)
+
+// statusPolicy returns the state machine thresholds configured in registry.
+func statusPolicy(registry env.RegistryConfig) redisHelper.StatusPolicy {
+	return redisHelper.StatusPolicy{
+		ReportThreshold:    registry.ReportToleranceCount,
+		ReportWindow:       time.Duration(registry.ReportWindow) * time.Second,
+		ReportHalfLife:     time.Duration(registry.ReportHalfLife) * time.Second,
+		RecoveryHeartbeats: registry.RecoveryHeartbeats,
+	}
+}
+
+// heartbeatService refreshes the entry of uuid with payload. On failure it
+// writes the problem response and returns false.
+func heartbeatService(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig, uuid string, payload redisHelper.HeartbeatPayload) bool {
+	startTime := time.Now()
+	entry, change, err := redisHelper.UpdateServiceEntry(rclient, uuid, statusPolicy(registry), payload)
+	if change.Changed() {
+		redisHelper.RecordAuditEvent(rclient, redisHelper.AuditEvent{
+			Action:      redisHelper.AuditStatusChange,
+			ServiceUUID: uuid,
+			ServiceType: entry.Type,
+			Actor:       utils.GetActor(r, "service"),
+			SourceIP:    utils.GetClientIP(r),
+			OldStatus:   change.From,
+			NewStatus:   change.To,
+			Reason:      change.AuditReason(),
+		})
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, redisHelper.ErrServiceNotFound):
+			utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		case errors.Is(err, redisHelper.ErrServiceSuspicious):
+			utils.WriteError(w, r, http.StatusConflict, utils.CodeServiceSuspicious, suspiciousDetail)
+		case errors.Is(err, redisHelper.ErrIllegalTransition):
+			utils.WriteError(w, r, http.StatusConflict, utils.CodeIllegalTransition, illegalHeartbeatDetail)
+		case errors.Is(err, redisHelper.ErrServiceLocked):
+			writeServiceBusy(w, r)
+		default:
+			utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, err.Error())
+		}
+		return false
+	}
 
+	logger.HeartBeat(fmt.Sprintf("%s %s", uuid, change.To), time.Since(startTime))
+	return true
 }
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	env "github.com/tahakara/discogo/internal/config"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+// registered registers registerBody and returns its entry.
+func registered(t *testing.T, rclient redisclient.Client) redishelper.ServiceEntry {
+	t.Helper()
+	return registeredBody(t, rclient, registerBody)
+}
+
+// registeredAs registers registerBody under instanceID and returns its entry.
+func registeredAs(t *testing.T, rclient redisclient.Client, instanceID string) redishelper.ServiceEntry {
+	t.Helper()
+	return registeredBody(t, rclient, strings.Replace(registerBody, `"i-1"`, `"`+instanceID+`"`, 1))
+}
+
+// registeredBody registers body and returns the stored entry.
+func registeredBody(t *testing.T, rclient redisclient.Client, body string) redishelper.ServiceEntry {
+	t.Helper()
+	w := register(rclient, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("register returned %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data RegisterResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	exists, entry := redishelper.IsServiceExistsByUUID(rclient, resp.Data.ServiceUUID)
+	if !exists {
+		t.Fatalf("registered service %s not found", resp.Data.ServiceUUID)
+	}
+	return entry
+}
+
+func heartbeat(rclient redisclient.Client, uuid, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPut, "/v1/heartbeat/"+uuid, bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	HeartbeatHandler(w, r, rclient, env.Defaults().Registry, uuid)
+	return w
+}
+
+func TestHeartbeatConcurrentStatusChanges(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+
+		// Alternating self-reported statuses move the entry between keys on
+		// almost every heartbeat.
+		const heartbeats = 20
+		var wg sync.WaitGroup
+		for i := 0; i < heartbeats; i++ {
+			status := []string{"ok", "degraded"}[i%2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := heartbeat(rclient, entry.ServiceUUID, fmt.Sprintf(`{"status":%q}`, status))
+				if w.Code != http.StatusOK {
+					t.Errorf("heartbeat returned %d: %s", w.Code, w.Body)
+				}
+			}()
+		}
+		wg.Wait()
+
+		keys, err := rclient.FindKeys(entry.ServiceUUID + ":*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("concurrent heartbeats left %d keys, want 1: %v", len(keys), keys)
+		}
+		_, updated := redishelper.IsServiceExistsByUUID(rclient, entry.ServiceUUID)
+		if want := entry.HeardCount + heartbeats; updated.HeardCount != want {
+			t.Fatalf("HeardCount = %d after %d concurrent heartbeats, want %d", updated.HeardCount, heartbeats, want)
+		}
+	})
+}
+
+func TestHeartbeatStatusChangeAuditsServiceType(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+		if w := heartbeat(rclient, entry.ServiceUUID, `{}`); w.Code != http.StatusOK {
+			t.Fatalf("heartbeat returned %d: %s", w.Code, w.Body)
+		}
+
+		events, err := redishelper.QueryAuditEvents(rclient, time.Time{}, time.Time{}, entry.ServiceUUID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, event := range events {
+			if event.Action != redishelper.AuditStatusChange {
+				continue
+			}
+			found = true
+			if event.ServiceType != entry.Type {
+				t.Fatalf("status change audited with type %q, want %q", event.ServiceType, entry.Type)
+			}
+		}
+		if !found {
+			t.Fatalf("no status change audited: %+v", events)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	validators "github.com/tahakara/discogo/internal/api/validators"
+	env "github.com/tahakara/discogo/internal/config"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// registerAs posts body to the register handler with contentType.
+func registerAs(rclient redisclient.Client, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/register", bytes.NewBufferString(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	RegisterHandler(w, r, rclient, env.Defaults().Registry)
+	return w
+}
+
+func problemOf(t *testing.T, w *httptest.ResponseRecorder) (string, []validators.ValidationError) {
+	t.Helper()
+	var problem struct {
+		Code   string                       `json:"code"`
+		Errors []validators.ValidationError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v: %s", err, w.Body)
+	}
+	return problem.Code, problem.Errors
+}
+
+func TestRegisterBodyTooLarge(t *testing.T) {
+	utils.SetMaxBodyBytes(64)
+	t.Cleanup(func() { utils.SetMaxBodyBytes(env.Defaults().HTTP.MaxBodyBytes) })
+
+	// The body is rejected before the storage backend is used
+	w := registerAs(nil, "application/json", registerBody)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("register of %d bytes returned %d, want 413", len(registerBody), w.Code)
+	}
+	if code, _ := problemOf(t, w); code != utils.CodeBodyTooLarge {
+		t.Fatalf("code = %s, want %s", code, utils.CodeBodyTooLarge)
+	}
+}
+
+func TestRegisterContentType(t *testing.T) {
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded", "application/jsonx"} {
+		w := registerAs(nil, contentType, registerBody)
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Content-Type %q returned %d, want 415", contentType, w.Code)
+			continue
+		}
+		if code, _ := problemOf(t, w); code != utils.CodeUnsupportedMediaType {
+			t.Errorf("Content-Type %q answered %s, want %s", contentType, code, utils.CodeUnsupportedMediaType)
+		}
+	}
+
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		for _, contentType := range []string{"application/json; charset=utf-8", "application/vnd.discogo.v2+json"} {
+			if w := registerAs(rclient, contentType, registerBody); w.Code != http.StatusOK {
+				t.Errorf("Content-Type %q returned %d, want 200: %s", contentType, w.Code, w.Body)
+			}
+		}
+	})
+}
+
+func TestRegisterTagLimits(t *testing.T) {
+	// withTags returns registerBody with tags as its Tags
+	withTags := func(tags map[string]string) string {
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(registerBody), &body); err != nil {
+			t.Fatal(err)
+		}
+		body["Tags"] = tags
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	tooMany := map[string]string{}
+	for i := 0; i < 33; i++ {
+		tooMany[fmt.Sprintf("k%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name string
+		tags map[string]string
+	}{
+		{"more than 32 tags", tooMany},
+		{"key over 63 characters", map[string]string{strings.Repeat("k", 64): "v"}},
+		{"value over 256 characters", map[string]string{"k": strings.Repeat("v", 257)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := registerAs(nil, "application/json", withTags(tt.tags))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("register returned %d, want 400: %s", w.Code, w.Body)
+			}
+			code, errs := problemOf(t, w)
+			if code != utils.CodeValidationFailed || len(errs) != 1 || errs[0].Rule != "max" || !strings.HasPrefix(errs[0].Field, "Tags") {
+				t.Fatalf("problem = %s %+v, want one max error on Tags", code, errs)
+			}
+		})
+	}
+
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		atLimit := map[string]string{}
+		for i := 0; i < 31; i++ {
+			atLimit[fmt.Sprintf("k%d", i)] = "v"
+		}
+		atLimit[strings.Repeat("k", 63)] = strings.Repeat("v", 256)
+		if w := registerAs(rclient, "application/json", withTags(atLimit)); w.Code != http.StatusOK {
+			t.Fatalf("tags at the limits returned %d, want 200: %s", w.Code, w.Body)
+		}
+	})
+}
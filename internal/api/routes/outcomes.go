@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	requestDTOs "github.com/tahakara/discogo/internal/api/dtos/requestdto"
+	validators "github.com/tahakara/discogo/internal/api/validators"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type OutcomesResponse struct {
+	Status string `json:"status"`
+	// Accepted is the number of target instances counted.
+	Accepted int `json:"accepted"`
+	// Interval is the aggregation interval in seconds; submit about once per interval.
+	Interval int `json:"interval"`
+}
+
+// OutcomesHandler godoc
+// @Summary      Report request outcomes
+// @Description  Adds the successful and failed requests a registered instance saw per target instance to the current aggregation interval. At the end of each interval the error rate of every instance is compared with the other instances of its type, and outliers are ejected from discovery for a growing time.
+// @Tags         DiscoGo
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Param        request  body      requestdto.OutcomesRequestDTO  true  "Request outcomes"
+// @Success      200      {object}  OutcomesResponse
+// @Failure      400      {object}  utils.Problem  "INVALID_BODY or VALIDATION_FAILED"
+// @Failure      403      {object}  utils.Problem  "OUTLIERS_DISABLED"
+// @Failure      413      {object}  utils.Problem  "BATCH_TOO_LARGE"
+// @Failure      422      {object}  utils.Problem  "REPORTER_NOT_FOUND"
+// @Failure      503      {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v1/outcomes [post]
+func OutcomesHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig, outlier env.OutlierConfig) {
+	startTime := time.Now()
+	if outlier.Interval == 0 {
+		utils.WriteError(w, r, http.StatusForbidden, utils.CodeOutliersDisabled, "Outlier ejection is disabled")
+		return
+	}
+
+	var req requestDTOs.OutcomesRequestDTO
+	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if !checkBatchSize(w, r, registry, len(req.Outcomes)) {
+		return
+	}
+	lang := validators.NegotiateLanguage(r.Header.Get("Accept-Language"))
+	if errs := validators.ValidateOutcomesRequest(&req, lang); errs != nil {
+		w.Header().Set("Content-Language", lang)
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidationFailed, validators.Summary(lang, errs))
+		problem.Errors = errs
+		utils.WriteProblem(w, r, problem)
+		return
+	}
+
+	// Only registered instances may submit outcomes, like reports
+	if exists, _ := redishelper.IsServiceExistsByUUID(rclient, req.ReporterUUID); !exists {
+		utils.WriteError(w, r, http.StatusUnprocessableEntity, utils.CodeReporterNotFound, "The reporter is not a registered service")
+		return
+	}
+
+	outcomes := make([]redishelper.RequestOutcome, 0, len(req.Outcomes))
+	for _, outcome := range req.Outcomes {
+		// An instance's view of itself says nothing about its callers
+		if outcome.ServiceUUID == req.ReporterUUID {
+			continue
+		}
+		outcomes = append(outcomes, redishelper.RequestOutcome{
+			ServiceUUID: outcome.ServiceUUID,
+			Successes:   outcome.Successes,
+			Failures:    outcome.Failures,
+		})
+	}
+	if err := redishelper.RecordRequestOutcomes(rclient, outcomes, outlier.AggregationInterval()); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store request outcomes: %v", err), time.Since(startTime))
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to store the request outcomes")
+		return
+	}
+	logger.Info(fmt.Sprintf("Request outcomes for %d instances from %s", len(outcomes), req.ReporterUUID), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, OutcomesResponse{
+		Status:   "ok",
+		Accepted: len(outcomes),
+		Interval: outlier.Interval,
+	})
+}
@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,47 +18,86 @@ import (
 )
 
 type RegisterResponse struct {
-	Status           string   `json:"status"`                     // "success" or "error"
-	ServiceUUID      string   `json:"serviceUUID,omitempty"`      // Only on success
-	HealthCheckCycle int      `json:"healthCheckCycle,omitempty"` // Only on success
-	Reason           []string `json:"reason,omitempty"`           // Only on error
+	Status           string `json:"status"`
+	ServiceUUID      string `json:"serviceUUID"`
+	HealthCheckCycle int    `json:"healthCheckCycle"`
+	// Existing is set when the identity was already registered and its UUID is returned.
+	Existing bool `json:"existing,omitempty"`
+	// ReplacedUUID is the entry removed by a replacing registration.
+	ReplacedUUID string `json:"replacedUUID,omitempty"`
+}
+
+// registrationLockWait is how long a registration waits for a concurrent
+// registration of the same instance to finish.
+const registrationLockWait = 3 * time.Second
+
+// registration is the outcome of registerService.
+type registration struct {
+	entry redisHelper.ServiceEntry
+	// existing is set when the identity was already registered and the
+	// stored entry was returned instead of a new one.
+	existing bool
+	// replaced is the UUID of the entry a replacing registration removed.
+	replaced string
 }
 
 // RegisterHandler handles service registration requests.
 //
 // @Summary      Register a new service
-// @Description  Registers a new service instance with the discovery system.
+// @Description  Registers a service instance with the discovery system. Registering an identity (type, provider, region, zone, network, subnet, instance, version) that is already registered returns the existing UUID unless replace=true. The optional Check declares an active HTTP, TCP or gRPC health check run by DiscoGo. Validation messages follow the Accept-Language header (en, tr).
 // @Tags         DiscoGo
 // @Accept       json
-// @Produce      json
+// @Produce      json,application/problem+json
 // @Param        request body requestdto.RegisterRequestDTO true "Service registration payload"
+// @Param        replace query bool false "Replace an existing registration of the same identity"
+// @Param        Idempotency-Key header string false "Returns the original registration when a request is retried"
+// @Param        Accept-Language header string false "Language of validation messages (en, tr)"
 // @Success      200 {object} RegisterResponse
-// @Failure      400 {object} RegisterResponse
-// @Failure      409 {object} RegisterResponse
-// @Router       /disco/register [post]
-func RegisterHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
-	startTime := time.Now()
+// @Failure      400 {object} utils.Problem "INVALID_BODY, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure      409 {object} utils.Problem "REGISTRATION_IN_PROGRESS"
+// @Failure      422 {object} utils.Problem "IDEMPOTENCY_KEY_REUSED"
+// @Failure      500 {object} utils.Problem "INTERNAL_ERROR"
+// @Failure      503 {object} utils.Problem "STORAGE_UNAVAILABLE"
+// @Router       /v1/register [post]
+func RegisterHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig) {
 	var req requestDTOs.RegisterRequestDTO
 	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
-		// utils.DecodeJSONBody already writes error response
-		utils.WriteJSONResponse(w, http.StatusBadRequest,
-			RegisterResponse{
-				Status: "error",
-				Reason: []string{"Invalid request payload"},
-			})
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if err := validators.ValidateRegisterRequest(&req); err != nil {
-		utils.WriteJSONResponse(w, http.StatusBadRequest,
-			RegisterResponse{
-				Status: "error",
-				Reason: err,
-			})
+	result, ok := registerService(w, r, rclient, registry, req)
+	if !ok {
 		return
 	}
 
-	mappedEntry := redisHelper.ServiceEntry{
+	utils.WriteJSONResponse(w, http.StatusOK, RegisterResponse{
+		Status:           "ok",
+		ServiceUUID:      result.entry.ServiceUUID,
+		HealthCheckCycle: registry.HealthCheckInterval, // Health check cycle in seconds
+		Existing:         result.existing,
+		ReplacedUUID:     result.replaced,
+	})
+}
+
+// replaceParam parses the optional replace query parameter. On failure it
+// writes the problem response and returns false.
+func replaceParam(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	raw := r.URL.Query().Get("replace")
+	if raw == "" {
+		return false, true
+	}
+	replace, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeInvalidParameter(w, r, "replace", "Invalid 'replace' query parameter (must be true or false)", nil)
+		return false, false
+	}
+	return replace, true
+}
+
+// newServiceEntry maps a registration request to a new entry with a fresh UUID.
+func newServiceEntry(req requestDTOs.RegisterRequestDTO) redisHelper.ServiceEntry {
+	return redisHelper.ServiceEntry{
 		ServiceUUID:   uuid.New().String(),
 		Name:          req.Name,
 		Type:          req.Type,
@@ -75,24 +116,176 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request, rclient redisclient
 		Addr6:         req.Addr6,
 		Port4:         req.Port4,
 		Port6:         req.Port6,
+		Check:         newHealthCheck(req.Check),
 	}
+}
 
-	if exists, _ := redisHelper.IsServiceExists(rclient, mappedEntry); exists {
-		utils.WriteJSONResponse(w, http.StatusConflict,
-			RegisterResponse{
-				Status: "error",
-				Reason: []string{"Service already exists"},
-			})
-		return
+// newHealthCheck maps a declared check, filling in its defaults. The probe
+// interval and timeout defaults are applied by the prober.
+func newHealthCheck(req *requestDTOs.CheckDTO) *redisHelper.HealthCheck {
+	if req == nil {
+		return nil
 	}
+	check := &redisHelper.HealthCheck{
+		Type:           req.Type,
+		Path:           req.Path,
+		ExpectedStatus: req.ExpectedStatus,
+		Service:        req.Service,
+		Interval:       req.Interval,
+		Timeout:        req.Timeout,
+	}
+	if check.Type == redisHelper.CheckHTTP {
+		if check.Path == "" {
+			check.Path = "/"
+		}
+		if check.ExpectedStatus == 0 {
+			check.ExpectedStatus = http.StatusOK
+		}
+	}
+	return check
+}
 
-	redisHelper.RegisterNewService(rclient, mappedEntry)
+// validIdempotencyKey reports whether key is 1-255 printable ASCII characters.
+func validIdempotencyKey(key string) bool {
+	if len(key) == 0 || len(key) > 255 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
 
-	logger.Register(fmt.Sprintf("%s:%s", mappedEntry.Type, mappedEntry.ServiceUUID), time.Since(startTime))
+// registerService validates req and stores it as a service entry. An entry
+// with the same identity is returned as is, or replaced when the replace
+// query parameter is true. Requests carrying an Idempotency-Key that was
+// already used return the registration of the first request. On failure it
+// writes the problem response and returns false.
+func registerService(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig, req requestDTOs.RegisterRequestDTO) (registration, bool) {
+	startTime := time.Now()
+	lang := validators.NegotiateLanguage(r.Header.Get("Accept-Language"))
+	if errs := validators.ValidateRegisterRequest(&req, lang); errs != nil {
+		w.Header().Set("Content-Language", lang)
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidationFailed, validators.Summary(lang, errs))
+		problem.Errors = errs
+		utils.WriteProblem(w, r, problem)
+		return registration{}, false
+	}
 
-	utils.WriteJSONResponse(w, http.StatusOK, RegisterResponse{
-		Status:           "ok",
-		ServiceUUID:      mappedEntry.ServiceUUID,
-		HealthCheckCycle: env.GetHealthCheckInterval(), // Default health check cycle in seconds
+	replace, ok := replaceParam(w, r)
+	if !ok {
+		return registration{}, false
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	var fingerprint string
+	if idempotencyKey != "" {
+		if !validIdempotencyKey(idempotencyKey) {
+			writeInvalidParameter(w, r, "Idempotency-Key", "Idempotency-Key must be 1-255 printable ASCII characters", nil)
+			return registration{}, false
+		}
+		// replace changes the outcome, so it is part of the fingerprint
+		fingerprint, _ = redisHelper.RequestFingerprint(struct {
+			Request requestDTOs.RegisterRequestDTO
+			Replace bool
+		}{req, replace})
+	}
+
+	mappedEntry := newServiceEntry(req)
+
+	// The identity lock makes the existence check and the write below atomic
+	// with respect to concurrent registrations of the same instance.
+	release, err := redisHelper.AcquireRegistrationLock(rclient, mappedEntry, registrationLockWait)
+	if errors.Is(err, redisHelper.ErrRegistrationLocked) {
+		w.Header().Set("Retry-After", "1")
+		utils.WriteError(w, r, http.StatusConflict, utils.CodeRegistrationInProgress, "Another registration of this instance is in progress")
+		return registration{}, false
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to reach the storage backend")
+		return registration{}, false
+	}
+	defer release()
+
+	if idempotencyKey != "" {
+		record, found, err := redisHelper.LookupIdempotencyKey(rclient, idempotencyKey, fingerprint)
+		switch {
+		case errors.Is(err, redisHelper.ErrIdempotencyKeyReused):
+			utils.WriteError(w, r, http.StatusUnprocessableEntity, utils.CodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request")
+			return registration{}, false
+		case errors.Is(err, redisHelper.ErrIdempotencyRecordInvalid):
+			utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to read the stored Idempotency-Key record")
+			return registration{}, false
+		case err != nil:
+			utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to reach the storage backend")
+			return registration{}, false
+		}
+		if found {
+			// A registration that has since expired is registered again
+			if exists, entry := redisHelper.IsServiceExistsByUUID(rclient, record.ServiceUUID); exists {
+				w.Header().Set("Idempotent-Replayed", "true")
+				return registration{entry: entry}, true
+			}
+		}
+	}
+
+	exists, existingEntry := redisHelper.IsServiceExists(rclient, mappedEntry)
+	if exists && !replace {
+		saveIdempotencyKey(rclient, registry, idempotencyKey, fingerprint, existingEntry.ServiceUUID)
+		return registration{entry: existingEntry, existing: true}, true
+	}
+
+	if !redisHelper.RegisterNewService(rclient, mappedEntry) {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to store the service entry")
+		return registration{}, false
+	}
+
+	result := registration{entry: mappedEntry}
+	if exists {
+		// The old entry goes only once the new one is stored, so a failed
+		// write never leaves the instance unregistered
+		if _, err := redisHelper.DeregisterServiceEntry(rclient, existingEntry.ServiceUUID); err != nil {
+			if _, err := redisHelper.DeregisterServiceEntry(rclient, mappedEntry.ServiceUUID); err != nil {
+				logger.Error(fmt.Sprintf("Failed to roll back replacing service entry %s: %v", mappedEntry.ServiceUUID, err), time.Since(startTime))
+			}
+			utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to replace the existing service")
+			return registration{}, false
+		}
+		redisHelper.RecordAuditEvent(rclient, redisHelper.AuditEvent{
+			Action:      redisHelper.AuditDeregister,
+			ServiceUUID: existingEntry.ServiceUUID,
+			ServiceType: existingEntry.Type,
+			Actor:       utils.GetActor(r, "service"),
+			SourceIP:    utils.GetClientIP(r),
+			OldStatus:   existingEntry.Status,
+			NewStatus:   redisHelper.StatusDeregistered,
+			Reason:      "replaced by " + mappedEntry.ServiceUUID,
+		})
+		result.replaced = existingEntry.ServiceUUID
+	}
+	redisHelper.RecordAuditEvent(rclient, redisHelper.AuditEvent{
+		Action:      redisHelper.AuditRegister,
+		ServiceUUID: mappedEntry.ServiceUUID,
+		ServiceType: mappedEntry.Type,
+		Actor:       utils.GetActor(r, "service"),
+		SourceIP:    utils.GetClientIP(r),
+		NewStatus:   redisHelper.StatusRegistered,
 	})
+	saveIdempotencyKey(rclient, registry, idempotencyKey, fingerprint, mappedEntry.ServiceUUID)
+
+	logger.Register(fmt.Sprintf("%s:%s", mappedEntry.Type, mappedEntry.ServiceUUID), time.Since(startTime))
+	return result, true
+}
+
+// saveIdempotencyKey remembers the registration of key. Failures are only
+// logged; the registration itself has succeeded.
+func saveIdempotencyKey(rclient redisclient.Client, registry env.RegistryConfig, key, fingerprint, serviceUUID string) {
+	if key == "" {
+		return
+	}
+	if err := redisHelper.SaveIdempotencyKey(rclient, key, fingerprint, serviceUUID, registry.IdempotencyKeyExpiration()); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store idempotency key: %v", err), 0)
+	}
 }
@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	env "github.com/tahakara/discogo/internal/config"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+const registerBody = `{"Name":"api-1","Type":"gw","Version":"1.0.0","Provider":"aws","Region":"r1","Zone":"z1","Cluster":"c","InstanceID":"i-1","NetworkID":"n","SubnetID":"s","NetworkDomain":"d","Addr4":"10.0.0.1","Port4":80}`
+
+func register(rclient redisclient.Client, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/register", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	RegisterHandler(w, r, rclient, env.Defaults().Registry)
+	return w
+}
+
+func TestRegisterConcurrentSameIdentity(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		const requests = 20
+		var (
+			wg    sync.WaitGroup
+			mu    sync.Mutex
+			uuids = map[string]int{}
+		)
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := register(rclient, registerBody)
+				switch w.Code {
+				case http.StatusOK:
+					var resp struct {
+						Data RegisterResponse `json:"data"`
+					}
+					if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+						t.Errorf("decode response: %v", err)
+						return
+					}
+					mu.Lock()
+					uuids[resp.Data.ServiceUUID]++
+					mu.Unlock()
+				case http.StatusConflict:
+					// Waited longer than registrationLockWait; the client retries
+				default:
+					t.Errorf("register returned %d: %s", w.Code, w.Body)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(uuids) != 1 {
+			t.Fatalf("concurrent registrations of one identity returned %d UUIDs, want 1: %v", len(uuids), uuids)
+		}
+		keys, err := rclient.FindKeys("*:*:gw:*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("concurrent registrations of one identity stored %d keys, want 1: %v", len(keys), keys)
+		}
+		for uuid := range uuids {
+			if exists, _ := redishelper.IsServiceExistsByUUID(rclient, uuid); !exists {
+				t.Fatalf("the returned UUID %s is not registered", uuid)
+			}
+		}
+	})
+}
+
+// failingSetClient fails every Set, like a backend that stops accepting
+// writes.
+type failingSetClient struct {
+	redisclient.Client
+}
+
+func (failingSetClient) Set(string, []byte, time.Duration) error {
+	return errors.New("write refused")
+}
+
+func TestRegisterReplaceKeepsEntryWhenWriteFails(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		existing := registered(t, rclient)
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/register?replace=true", bytes.NewBufferString(registerBody))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		RegisterHandler(w, r, failingSetClient{rclient}, env.Defaults().Registry)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("replace with a failing write returned %d, want 503: %s", w.Code, w.Body)
+		}
+		if exists, _ := redishelper.IsServiceExistsByUUID(rclient, existing.ServiceUUID); !exists {
+			t.Fatal("the replaced entry was deleted although its replacement was never stored")
+		}
+	})
+}
+
+// failingGetClient fails every Get, like a backend that stops answering
+// reads.
+type failingGetClient struct {
+	redisclient.Client
+}
+
+func (failingGetClient) Get(string) ([]byte, error) {
+	return nil, errors.New("read refused")
+}
+
+func TestRegisterIdempotencyLookupErrors(t *testing.T) {
+	registerWithKey := func(rclient redisclient.Client, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v1/register", bytes.NewBufferString(registerBody))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		RegisterHandler(w, r, rclient, env.Defaults().Registry)
+		return w
+	}
+
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		if w := registerWithKey(failingGetClient{rclient}, "k-1"); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("lookup with a failing read returned %d, want 503: %s", w.Code, w.Body)
+		}
+
+		if err := rclient.Set("discogo:idempotency:k-2", []byte("not json"), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if w := registerWithKey(rclient, "k-2"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("lookup of a corrupt record returned %d, want 500: %s", w.Code, w.Body)
+		}
+
+		keys, err := rclient.FindKeys("*:*:gw:*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 0 {
+			t.Fatalf("a failed lookup still registered the service: %v", keys)
+		}
+	})
+}
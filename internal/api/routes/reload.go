@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"net/http"
+
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type CatalogReloadResponse struct {
+	Status string                           `json:"status"`
+	Diff   *serviceconfigloader.CatalogDiff `json:"diff,omitempty"`
+}
+
+// CatalogReloadHandler godoc
+// @Summary      Reload the service catalog
+// @Description  Re-reads the catalog file (DISCOGO_CONFIG_PATH) and swaps in the new service types and providers. On a parse or validation error the current catalog stays active.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Success      200  {object}  CatalogReloadResponse
+// @Failure      422  {object}  utils.Problem
+// @Router       /v1/admin/reload [post]
+func CatalogReloadHandler(w http.ResponseWriter, r *http.Request) {
+	diff, err := serviceconfigloader.ReloadConfigs()
+	if err != nil {
+		utils.WriteError(w, r, http.StatusUnprocessableEntity, utils.CodeCatalogInvalid, "Catalog reload failed, keeping current catalog: "+err.Error())
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, CatalogReloadResponse{
+		Status: "ok",
+		Diff:   &diff,
+	})
+}
@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	requestDTOs "github.com/tahakara/discogo/internal/api/dtos/requestdto"
+	validators "github.com/tahakara/discogo/internal/api/validators"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type ReportResponse struct {
+	Status        string                    `json:"status"`
+	ServiceUUID   string                    `json:"serviceUUID"`
+	ServiceStatus redishelper.ServiceStatus `json:"serviceStatus"`
+	// Reporters is the number of distinct reporters within the report window.
+	Reporters int `json:"reporters"`
+	// Score is the decayed, weighted report count compared to the threshold.
+	Score     float64 `json:"score"`
+	Threshold int64   `json:"threshold"`
+}
+
+// ReportHandler godoc
+// @Summary      Report a misbehaving service
+// @Description  Records a report by one registered instance about another. Each reporter counts once within the report window and reports lose weight as they age; the instance becomes suspicious once the weighted number of reporters reaches the threshold.
+// @Tags         DiscoGo
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Param        request  body      requestdto.ReportRequestDTO  true  "Report"
+// @Success      200      {object}  ReportResponse
+// @Failure      400      {object}  utils.Problem  "INVALID_BODY or VALIDATION_FAILED"
+// @Failure      404      {object}  utils.Problem  "SERVICE_NOT_FOUND"
+// @Failure      409      {object}  utils.Problem  "SERVICE_BUSY"
+// @Failure      422      {object}  utils.Problem  "REPORTER_NOT_FOUND"
+// @Failure      503      {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v1/report [post]
+func ReportHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig) {
+	startTime := time.Now()
+
+	var req requestDTOs.ReportRequestDTO
+	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	lang := validators.NegotiateLanguage(r.Header.Get("Accept-Language"))
+	if errs := validators.ValidateReportRequest(&req, lang); errs != nil {
+		w.Header().Set("Content-Language", lang)
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidationFailed, validators.Summary(lang, errs))
+		problem.Errors = errs
+		utils.WriteProblem(w, r, problem)
+		return
+	}
+
+	// Only registered instances may report, so each reporter is accountable
+	exists, reporter := redishelper.IsServiceExistsByUUID(rclient, req.ReporterUUID)
+	if !exists {
+		utils.WriteError(w, r, http.StatusUnprocessableEntity, utils.CodeReporterNotFound, "The reporter is not a registered service")
+		return
+	}
+
+	policy := statusPolicy(registry)
+	entry, summary, change, err := redishelper.RecordReport(rclient, req.ServiceUUID, redishelper.ServiceReport{
+		ReporterUUID: req.ReporterUUID,
+		Weight:       redishelper.ReporterWeight(reporter, registry.ReportReputation),
+		Reason:       req.Reason,
+	}, policy)
+	if errors.Is(err, redishelper.ErrServiceNotFound) {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return
+	}
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeStorageUnavailable, "Failed to store the report")
+		return
+	}
+
+	sourceIP := utils.GetClientIP(r)
+	redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+		Action:      redishelper.AuditReport,
+		ServiceUUID: entry.ServiceUUID,
+		ServiceType: entry.Type,
+		Actor:       req.ReporterUUID,
+		SourceIP:    sourceIP,
+		Reason:      req.Reason,
+	})
+	if change.Changed() {
+		redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+			Action:      redishelper.AuditStatusChange,
+			ServiceUUID: entry.ServiceUUID,
+			ServiceType: entry.Type,
+			Actor:       req.ReporterUUID,
+			SourceIP:    sourceIP,
+			OldStatus:   change.From,
+			NewStatus:   change.To,
+			Reason:      change.AuditReason(),
+		})
+	}
+	logger.Info(fmt.Sprintf("Service %s reported by %s (%d reporters, score %.2f)", entry.ServiceUUID, req.ReporterUUID, summary.Reporters, summary.Score), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, ReportResponse{
+		Status:        "ok",
+		ServiceUUID:   entry.ServiceUUID,
+		ServiceStatus: entry.Status,
+		Reporters:     summary.Reporters,
+		Score:         summary.Score,
+		Threshold:     policy.ReportThreshold,
+	})
+}
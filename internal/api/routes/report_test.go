@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	env "github.com/tahakara/discogo/internal/config"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+func report(rclient redisclient.Client, serviceUUID, reporterUUID string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"ServiceUUID":%q,"ReporterUUID":%q}`, serviceUUID, reporterUUID)
+	r := httptest.NewRequest(http.MethodPost, "/v1/report", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ReportHandler(w, r, rclient, env.Defaults().Registry)
+	return w
+}
+
+func TestReportConcurrentReporters(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		target := registered(t, rclient)
+
+		// More reporters than tolerated, so one of the reports also moves
+		// the target to suspicious.
+		const reporters = 8
+		var wg sync.WaitGroup
+		for i := 0; i < reporters; i++ {
+			reporter := registeredAs(t, rclient, fmt.Sprintf("i-r%d", i))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if w := report(rclient, target.ServiceUUID, reporter.ServiceUUID); w.Code != http.StatusOK {
+					t.Errorf("report returned %d: %s", w.Code, w.Body)
+				}
+			}()
+		}
+		wg.Wait()
+
+		keys, err := rclient.FindKeys(target.ServiceUUID + ":*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("concurrent reports left %d keys, want 1: %v", len(keys), keys)
+		}
+		_, updated := redishelper.IsServiceExistsByUUID(rclient, target.ServiceUUID)
+		if len(updated.Reports) != reporters {
+			t.Fatalf("target holds %d reports after %d concurrent reporters, want %d", len(updated.Reports), reporters, reporters)
+		}
+		if updated.Status != redishelper.StatusSuspicious {
+			t.Fatalf("target status = %s, want %s", updated.Status, redishelper.StatusSuspicious)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	boltclient "github.com/tahakara/discogo/internal/bolt"
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+func TestMain(m *testing.M) {
+	if err := serviceconfigloader.LoadAllConfigs(filepath.Join("..", "..", "..", "conf.json")); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// forEachBackend runs test against an empty bolt store and an empty Redis.
+func forEachBackend(t *testing.T, test func(t *testing.T, rclient redisclient.Client)) {
+	t.Run("bolt", func(t *testing.T) {
+		rclient, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { rclient.Close() })
+		test(t, rclient)
+	})
+	t.Run("redis", func(t *testing.T) {
+		rclient := redisclient.New(miniredis.RunT(t).Addr(), "", 0)
+		t.Cleanup(func() { rclient.Close() })
+		test(t, rclient)
+	})
+}
@@ -0,0 +1,354 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type ServiceDetailResponse struct {
+	Status       string                    `json:"status"`
+	Service      *redishelper.ServiceEntry `json:"service,omitempty"`
+	RemainingTTL int64                     `json:"remainingTTL,omitempty"` // seconds
+}
+
+type ServiceHistoryResponse struct {
+	Status        string                         `json:"status"`
+	ServiceUUID   string                         `json:"serviceUUID"`
+	ServiceStatus redishelper.ServiceStatus      `json:"serviceStatus"`
+	History       []redishelper.StatusTransition `json:"history"`
+}
+
+type ServiceListResponse struct {
+	Status   string                     `json:"status"`
+	Services []redishelper.ServiceEntry `json:"services"`
+}
+
+type PatchServiceRequestBody struct {
+	Status           *string `json:"status,omitempty"`
+	ResetReportCount bool    `json:"resetReportCount,omitempty"`
+	Reason           string  `json:"reason,omitempty"`
+}
+
+// adminActor identifies the operator behind an admin call for audit logging.
+func adminActor(r *http.Request) string {
+	return utils.GetActor(r, "admin")
+}
+
+func validateServiceUUIDParam(w http.ResponseWriter, r *http.Request, serviceUUID string) bool {
+	if valid, err := utils.ValidateUUID(serviceUUID); err != nil || !valid {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidUUID, "Invalid uuid format")
+		return false
+	}
+	return true
+}
+
+// writeServiceBusy answers an update that timed out waiting for another
+// update of the same service entry.
+func writeServiceBusy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	utils.WriteError(w, r, http.StatusConflict, utils.CodeServiceBusy, "Another update of this service is in progress")
+}
+
+// GetServiceHandler godoc
+// @Summary      Inspect a service instance
+// @Description  Returns the complete service entry with its remaining TTL in seconds.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        uuid  path      string  true  "Service UUID"
+// @Success      200   {object}  ServiceDetailResponse
+// @Failure      400   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Router       /v1/services/{uuid} [get]
+func GetServiceHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceUUID string) {
+	if !validateServiceUUIDParam(w, r, serviceUUID) {
+		return
+	}
+
+	exists, entry, ttl := redishelper.GetServiceEntryWithTTL(rclient, serviceUUID)
+	if !exists {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, ServiceDetailResponse{
+		Status:       "ok",
+		Service:      &entry,
+		RemainingTTL: int64(ttl.Round(time.Second) / time.Second),
+	})
+}
+
+// ServiceHistoryHandler godoc
+// @Summary      Status history of a service instance
+// @Description  Returns the latest status transitions of a service, oldest first, with their trigger, reason and time.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        uuid  path      string  true  "Service UUID"
+// @Success      200   {object}  ServiceHistoryResponse
+// @Failure      400   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Router       /v1/services/{uuid}/history [get]
+func ServiceHistoryHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceUUID string) {
+	if !validateServiceUUIDParam(w, r, serviceUUID) {
+		return
+	}
+
+	exists, entry := redishelper.IsServiceExistsByUUID(rclient, serviceUUID)
+	if !exists {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return
+	}
+	history := entry.StatusHistory
+	if history == nil {
+		history = []redishelper.StatusTransition{}
+	}
+
+	utils.WriteJSONResponse(w, http.StatusOK, ServiceHistoryResponse{
+		Status:        "ok",
+		ServiceUUID:   entry.ServiceUUID,
+		ServiceStatus: entry.Status,
+		History:       history,
+	})
+}
+
+// ListServicesHandler godoc
+// @Summary      List service instances
+// @Description  Lists full service entries across all service types. Every filter is optional.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        servicetype   query     string  false  "Service type"
+// @Param        status        query     string  false  "Service status"
+// @Param        provider      query     string  false  "Service provider"
+// @Param        region        query     string  false  "Region"
+// @Param        zone          query     string  false  "Zone"
+// @Param        networkid     query     string  false  "Network ID"
+// @Param        subnetid      query     string  false  "Subnet ID"
+// @Param        instanceid    query     string  false  "Instance ID"
+// @Param        version       query     string  false  "Service version"
+// @Param        pagesize      query     int     false  "Number of results per page (1-100)" minimum(1) maximum(100)
+// @Param        pageoffset    query     int     false  "Page offset (>= 0)" minimum(0)
+// @Success      200  {object}  ServiceListResponse
+// @Failure      400  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /v1/services [get]
+func ListServicesHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	startTime := time.Now()
+	query := r.URL.Query()
+	serviceType := query.Get("servicetype")
+	selectedServiceStatus := query.Get("status")
+
+	const (
+		defaultPageSize = 50
+		maxPageSize     = 100
+	)
+
+	pageSize := defaultPageSize
+	pageOffset := 0
+	if v := query.Get("pagesize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidParameter, "Invalid 'pagesize' query parameter (must be 1-100)")
+			return
+		}
+		pageSize = n
+	}
+	if v := query.Get("pageoffset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidParameter, "Invalid 'pageoffset' query parameter (must be >= 0)")
+			return
+		}
+		pageOffset = n
+	}
+
+	if serviceType != "" && !serviceconfigloader.IsKnownServiceType(serviceType) {
+		writeInvalidParameter(w, r, "servicetype", "Invalid 'servicetype' query parameter", serviceconfigloader.GetAllServiceTypes())
+		return
+	}
+
+	status := redishelper.StatusAny
+	if selectedServiceStatus != "" {
+		if !redishelper.IsValidServiceStatus(selectedServiceStatus) {
+			writeInvalidParameter(w, r, "status", "Invalid 'status' query parameter", redishelper.GetAllServiceStatuses())
+			return
+		}
+		status = redishelper.DecideStatus(selectedServiceStatus)
+	}
+
+	if provider := query.Get("provider"); provider != "" && !serviceconfigloader.IsKnownProvider(provider) {
+		writeInvalidParameter(w, r, "provider", "Invalid 'provider' query parameter", serviceconfigloader.GetAllProviders())
+		return
+	}
+	if !validFilterParams(w, r) {
+		return
+	}
+
+	services, err := redishelper.ListServicesFiltered(
+		rclient,
+		serviceType,
+		status,
+		query.Get("provider"),
+		query.Get("region"),
+		query.Get("zone"),
+		query.Get("networkid"),
+		query.Get("subnetid"),
+		query.Get("instanceid"),
+		query.Get("version"),
+		pageSize,
+		pageOffset,
+	)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to retrieve services")
+		return
+	}
+	if services == nil {
+		services = []redishelper.ServiceEntry{}
+	}
+
+	logger.Info(fmt.Sprintf("Admin listed %d services", len(services)), time.Since(startTime))
+	utils.WriteJSONResponse(w, http.StatusOK, ServiceListResponse{
+		Status:   "ok",
+		Services: services,
+	})
+}
+
+// PatchServiceHandler godoc
+// @Summary      Override a service instance
+// @Description  Overrides the status of a service and/or resets its report count. The remaining TTL is preserved.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        uuid     path      string                   true  "Service UUID"
+// @Param        request  body      PatchServiceRequestBody  true  "Override"
+// @Success      200      {object}  ServiceDetailResponse
+// @Failure      400      {object}  utils.Problem
+// @Failure      404      {object}  utils.Problem
+// @Failure      409      {object}  utils.Problem
+// @Failure      500      {object}  utils.Problem
+// @Router       /v1/services/{uuid} [patch]
+func PatchServiceHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceUUID string) {
+	startTime := time.Now()
+	if !validateServiceUUIDParam(w, r, serviceUUID) {
+		return
+	}
+
+	var body PatchServiceRequestBody
+	if err := utils.DecodeJSONBody(w, r, &body); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	override := redishelper.ServiceEntryOverride{Reason: body.Reason}
+	if body.Status != nil {
+		if *body.Status == string(redishelper.StatusAny) || !redishelper.IsValidServiceStatus(*body.Status) {
+			utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Invalid status")
+			return
+		}
+		status := redishelper.DecideStatus(*body.Status)
+		override.Status = &status
+	}
+	override.ResetReportCount = body.ResetReportCount
+
+	if override.Status == nil && !override.ResetReportCount {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Nothing to update")
+		return
+	}
+
+	oldEntry, newEntry, err := redishelper.OverrideServiceEntry(rclient, serviceUUID, override)
+	if errors.Is(err, redishelper.ErrServiceNotFound) {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return
+	}
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to update service")
+		return
+	}
+
+	reason := body.Reason
+	if override.ResetReportCount {
+		reason = strings.TrimSpace(fmt.Sprintf("%s (report count reset from %d)", reason, oldEntry.ReportCount))
+	}
+	redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+		Action:      redishelper.AuditAdminOverride,
+		ServiceUUID: serviceUUID,
+		ServiceType: newEntry.Type,
+		Actor:       adminActor(r),
+		SourceIP:    utils.GetClientIP(r),
+		OldStatus:   oldEntry.Status,
+		NewStatus:   newEntry.Status,
+		Reason:      reason,
+	})
+	logger.Info(fmt.Sprintf("Service %s overridden", serviceUUID), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, ServiceDetailResponse{
+		Status:  "ok",
+		Service: &newEntry,
+	})
+}
+
+// EvictServiceHandler godoc
+// @Summary      Force-evict a service instance
+// @Description  Removes a service entry immediately, regardless of its status.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        uuid  path      string  true  "Service UUID"
+// @Success      200   {object}  ServiceDetailResponse
+// @Failure      400   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /v1/services/{uuid} [delete]
+func EvictServiceHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceUUID string) {
+	startTime := time.Now()
+	if !validateServiceUUIDParam(w, r, serviceUUID) {
+		return
+	}
+
+	entry, err := redishelper.EvictServiceEntry(rclient, serviceUUID)
+	if errors.Is(err, redishelper.ErrServiceNotFound) {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return
+	}
+	if errors.Is(err, redishelper.ErrServiceLocked) {
+		writeServiceBusy(w, r)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to evict service")
+		return
+	}
+
+	redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+		Action:      redishelper.AuditAdminEvict,
+		ServiceUUID: serviceUUID,
+		ServiceType: entry.Type,
+		Actor:       adminActor(r),
+		SourceIP:    utils.GetClientIP(r),
+		OldStatus:   entry.Status,
+		Reason:      r.URL.Query().Get("reason"),
+	})
+	logger.Info(fmt.Sprintf("Service %s evicted", serviceUUID), time.Since(startTime))
+
+	utils.WriteJSONResponse(w, http.StatusOK, ServiceDetailResponse{
+		Status:  "ok",
+		Service: &entry,
+	})
+}
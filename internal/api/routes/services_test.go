@@ -0,0 +1,233 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+func serviceHistory(rclient redisclient.Client, uuid string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/v1/services/"+uuid+"/history", nil)
+	w := httptest.NewRecorder()
+	ServiceHistoryHandler(w, r, rclient, uuid)
+	return w
+}
+
+func TestServiceHistory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+		for _, body := range []string{`{}`, `{"status":"degraded"}`, `{"status":"degraded"}`} {
+			if w := heartbeat(rclient, entry.ServiceUUID, body); w.Code != http.StatusOK {
+				t.Fatalf("heartbeat returned %d: %s", w.Code, w.Body)
+			}
+		}
+
+		w := serviceHistory(rclient, entry.ServiceUUID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("history returned %d: %s", w.Code, w.Body)
+		}
+		var resp struct {
+			Data ServiceHistoryResponse `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		want := []struct {
+			from, to redishelper.ServiceStatus
+			trigger  redishelper.StatusTrigger
+		}{
+			{"", redishelper.StatusRegistered, redishelper.TriggerRegister},
+			{redishelper.StatusRegistered, redishelper.StatusHealthy, redishelper.TriggerHeartbeat},
+			{redishelper.StatusHealthy, redishelper.StatusDegraded, redishelper.TriggerHeartbeat},
+		}
+		history := resp.Data.History
+		if resp.Data.ServiceStatus != redishelper.StatusDegraded || len(history) != len(want) {
+			t.Fatalf("history of a %s service = %+v, want %d transitions", resp.Data.ServiceStatus, history, len(want))
+		}
+		for i, transition := range history {
+			if transition.From != want[i].from || transition.To != want[i].to || transition.Trigger != want[i].trigger {
+				t.Errorf("transition %d = %+v, want %q -> %q on %s", i, transition, want[i].from, want[i].to, want[i].trigger)
+			}
+		}
+	})
+}
+
+func TestServiceHistoryErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		if w := serviceHistory(rclient, "not-a-uuid"); w.Code != http.StatusBadRequest {
+			t.Errorf("history of an invalid UUID returned %d, want 400", w.Code)
+		}
+		if w := serviceHistory(rclient, "6f1c2d3e-0000-4000-8000-000000000000"); w.Code != http.StatusNotFound {
+			t.Errorf("history of an unknown service returned %d, want 404", w.Code)
+		}
+	})
+}
+
+func patchService(rclient redisclient.Client, uuid, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPatch, "/v1/services/"+uuid, bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	PatchServiceHandler(w, r, rclient, uuid)
+	return w
+}
+
+func evictService(rclient redisclient.Client, uuid, reason string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodDelete, "/v1/services/"+uuid+"?reason="+reason, nil)
+	w := httptest.NewRecorder()
+	EvictServiceHandler(w, r, rclient, uuid)
+	return w
+}
+
+// auditEvents returns the audit events recorded for serviceUUID.
+func auditEvents(t *testing.T, rclient redisclient.Client, serviceUUID string) []redishelper.AuditEvent {
+	t.Helper()
+	events, err := redishelper.QueryAuditEvents(rclient, time.Time{}, time.Time{}, serviceUUID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+// setEntryTTL stores the entry of serviceUUID again with ttl.
+func setEntryTTL(t *testing.T, rclient redisclient.Client, serviceUUID string, ttl time.Duration) {
+	t.Helper()
+	keys, err := rclient.FindKeys(serviceUUID + ":*")
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("keys of %s: %v %v", serviceUUID, keys, err)
+	}
+	data, err := rclient.Get(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rclient.Set(keys[0], data, ttl); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatchServiceKeepsTTLAndAudits(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+		setEntryTTL(t, rclient, entry.ServiceUUID, 10*time.Second)
+
+		w := patchService(rclient, entry.ServiceUUID, `{"status": "degraded", "resetReportCount": true, "reason": "maintenance"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("patch returned %d: %s", w.Code, w.Body)
+		}
+
+		keys, err := rclient.FindKeys(entry.ServiceUUID + ":*")
+		if err != nil || len(keys) != 1 {
+			t.Fatalf("keys after patch: %v %v", keys, err)
+		}
+		ttl, err := rclient.TTL(keys[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ttl <= 0 || ttl > 10*time.Second {
+			t.Fatalf("TTL after patch = %v, want the remaining 10s kept", ttl)
+		}
+		if _, patched := redishelper.IsServiceExistsByUUID(rclient, entry.ServiceUUID); patched.Status != redishelper.StatusDegraded {
+			t.Fatalf("status after patch = %s, want %s", patched.Status, redishelper.StatusDegraded)
+		}
+
+		events := auditEvents(t, rclient, entry.ServiceUUID)
+		last := events[len(events)-1]
+		if last.Action != redishelper.AuditAdminOverride || last.OldStatus != entry.Status || last.NewStatus != redishelper.StatusDegraded || last.Reason != "maintenance (report count reset from 0)" {
+			t.Fatalf("audit event = %+v, want an admin override from %s to %s", last, entry.Status, redishelper.StatusDegraded)
+		}
+	})
+}
+
+func TestEvictServiceAudits(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		entry := registered(t, rclient)
+
+		if w := evictService(rclient, entry.ServiceUUID, "decommissioned"); w.Code != http.StatusOK {
+			t.Fatalf("evict returned %d: %s", w.Code, w.Body)
+		}
+		if exists, _ := redishelper.IsServiceExistsByUUID(rclient, entry.ServiceUUID); exists {
+			t.Fatal("the evicted service is still registered")
+		}
+
+		events := auditEvents(t, rclient, entry.ServiceUUID)
+		last := events[len(events)-1]
+		if last.Action != redishelper.AuditAdminEvict || last.OldStatus != entry.Status || last.Reason != "decommissioned" {
+			t.Fatalf("audit event = %+v, want an admin eviction", last)
+		}
+	})
+}
+
+func TestAdminServiceMutationErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		const unknown = "00000000-0000-4000-8000-000000000000"
+		if w := patchService(rclient, unknown, `{"status": "degraded"}`); w.Code != http.StatusNotFound {
+			t.Errorf("patch of an unknown service returned %d, want 404", w.Code)
+		}
+		if w := evictService(rclient, unknown, ""); w.Code != http.StatusNotFound {
+			t.Errorf("evict of an unknown service returned %d, want 404", w.Code)
+		}
+
+		// Another update holds the entry lock for longer than a mutation waits,
+		// so both requests below take the full lock wait
+		entry := registered(t, rclient)
+		if _, err := rclient.Add("discogo:lock:service:"+entry.ServiceUUID, []byte("other"), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		var patched, evicted *httptest.ResponseRecorder
+		wg.Add(2)
+		go func() { defer wg.Done(); patched = patchService(rclient, entry.ServiceUUID, `{"status": "degraded"}`) }()
+		go func() { defer wg.Done(); evicted = evictService(rclient, entry.ServiceUUID, "") }()
+		wg.Wait()
+		for name, w := range map[string]*httptest.ResponseRecorder{"patch": patched, "evict": evicted} {
+			if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+				t.Errorf("%s of a busy service returned %d, want 409 with Retry-After", name, w.Code)
+			}
+		}
+		if exists, stored := redishelper.IsServiceExistsByUUID(rclient, entry.ServiceUUID); !exists || stored.Status != entry.Status {
+			t.Fatal("a busy service was changed")
+		}
+		for _, event := range auditEvents(t, rclient, entry.ServiceUUID) {
+			if event.Action == redishelper.AuditAdminOverride || event.Action == redishelper.AuditAdminEvict {
+				t.Fatalf("a rejected mutation was audited: %+v", event)
+			}
+		}
+	})
+}
+
+func TestListServicesIncludesEjected(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		first := registeredAs(t, rclient, "i-1")
+		second := registeredAs(t, rclient, "i-2")
+		eject(t, rclient, second.ServiceUUID)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/services?servicetype=gw", nil)
+		w := httptest.NewRecorder()
+		ListServicesHandler(w, r, rclient)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list returned %d: %s", w.Code, w.Body)
+		}
+		var resp struct {
+			Data ServiceListResponse `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		listed := map[string]redishelper.ServiceEntry{}
+		for _, entry := range resp.Data.Services {
+			listed[entry.ServiceUUID] = entry
+		}
+		if _, ok := listed[first.ServiceUUID]; !ok || len(listed) != 2 {
+			t.Fatalf("listed %v, want both instances", listed)
+		}
+		if listed[second.ServiceUUID].EjectedUntil == "" {
+			t.Fatalf("the ejected instance is listed without EjectedUntil")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+type SnapshotImportResponse struct {
+	Status string                            `json:"status"`
+	Result *redishelper.SnapshotImportResult `json:"result,omitempty"`
+}
+
+// SnapshotExportHandler godoc
+// @Summary      Export registry snapshot
+// @Description  Dumps every registered service entry with its remaining TTL as a versioned JSON snapshot.
+// @Tags         Admin
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Success      200  {object}  redishelper.Snapshot
+// @Failure      500  {object}  utils.Problem
+// @Router       /v1/admin/snapshot [get]
+func SnapshotExportHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client) {
+	snapshot, err := redishelper.ExportSnapshot(rclient)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to export snapshot")
+		return
+	}
+
+	// The snapshot is written as-is (not wrapped) so it can be fed back to the import endpoint
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"discogo-snapshot-%d.json\"", time.Now().Unix()))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// SnapshotImportHandler godoc
+// @Summary      Import registry snapshot
+// @Description  Restores a snapshot produced by the export endpoint. Entries that share a UUID or an instance identity with a stored entry are skipped or overwrite it according to mode.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json,application/problem+json
+// @Security     AdminToken
+// @Param        mode     query  string                true  "Conflict mode"  Enums(skip,overwrite)
+// @Param        snapshot body   redishelper.Snapshot  true  "Snapshot"
+// @Success      200  {object}  SnapshotImportResponse
+// @Failure      400  {object}  utils.Problem
+// @Failure      413  {object}  utils.Problem  "BODY_TOO_LARGE"
+// @Failure      500  {object}  utils.Problem
+// @Router       /v1/admin/snapshot [post]
+func SnapshotImportHandler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, maxBytes int64) {
+	startTime := time.Now()
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = string(redishelper.SnapshotImportSkip)
+	}
+	if !redishelper.IsValidSnapshotImportMode(mode) {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidParameter, "Invalid 'mode' query parameter (must be skip or overwrite)")
+		return
+	}
+
+	var snapshot redishelper.Snapshot
+	if err := utils.DecodeJSONBodyLimit(w, r, &snapshot, maxBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	result, err := redishelper.ImportSnapshot(rclient, snapshot, redishelper.SnapshotImportMode(mode), registrationLockWait)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, err.Error())
+		return
+	}
+	if result.Failed > 0 {
+		utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal,
+			fmt.Sprintf("%d entries could not be imported (imported=%d overwritten=%d skipped=%d)", result.Failed, result.Imported, result.Overwritten, result.Skipped))
+		return
+	}
+
+	redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+		Action:   redishelper.AuditSnapshotImport,
+		Actor:    adminActor(r),
+		SourceIP: utils.GetClientIP(r),
+		Reason:   fmt.Sprintf("mode=%s imported=%d overwritten=%d skipped=%d", mode, result.Imported, result.Overwritten, result.Skipped),
+	})
+	logger.Info(fmt.Sprintf("Snapshot imported in %s mode", mode), time.Since(startTime))
+	utils.WriteJSONResponse(w, http.StatusOK, SnapshotImportResponse{
+		Status: "ok",
+		Result: &result,
+	})
+}
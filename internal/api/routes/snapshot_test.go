@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+func importSnapshot(t *testing.T, rclient redisclient.Client, mode string, entries ...redishelper.ServiceEntry) redishelper.SnapshotImportResult {
+	t.Helper()
+	snapshot := redishelper.Snapshot{Version: redishelper.SnapshotVersion}
+	for _, entry := range entries {
+		snapshot.Entries = append(snapshot.Entries, redishelper.SnapshotEntry{Entry: entry, TTL: 60})
+	}
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/v1/admin/snapshot?mode="+mode, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	SnapshotImportHandler(w, r, rclient, 1<<20)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import returned %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data SnapshotImportResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return *resp.Data.Result
+}
+
+// storedGateways returns the stored gw entries by UUID.
+func storedGateways(t *testing.T, rclient redisclient.Client) map[string]redishelper.ServiceEntry {
+	t.Helper()
+	keys, err := rclient.FindKeys("*:*:gw:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := map[string]redishelper.ServiceEntry{}
+	for _, key := range keys {
+		data, err := rclient.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entry redishelper.ServiceEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatal(err)
+		}
+		if _, dup := stored[entry.ServiceUUID]; dup {
+			t.Fatalf("%s is stored under more than one key: %v", entry.ServiceUUID, keys)
+		}
+		stored[entry.ServiceUUID] = entry
+	}
+	return stored
+}
+
+func TestSnapshotImportConflicts(t *testing.T) {
+	const otherUUID = "3f2b8c1e-5a4d-4e6f-9b7a-1c2d3e4f5a6b"
+	tests := []struct {
+		name string
+		// imported derives the snapshot entry from the registered one
+		imported func(registered redishelper.ServiceEntry) redishelper.ServiceEntry
+		mode     string
+		want     redishelper.SnapshotImportResult
+		// keep is set when the registered entry must survive the import
+		keep bool
+	}{
+		{
+			name: "same identity, skip",
+			imported: func(e redishelper.ServiceEntry) redishelper.ServiceEntry {
+				e.ServiceUUID, e.Name = otherUUID, "restored"
+				return e
+			},
+			mode: "skip",
+			want: redishelper.SnapshotImportResult{Skipped: 1},
+			keep: true,
+		},
+		{
+			name: "same identity, overwrite",
+			imported: func(e redishelper.ServiceEntry) redishelper.ServiceEntry {
+				e.ServiceUUID, e.Name = otherUUID, "restored"
+				return e
+			},
+			mode: "overwrite",
+			want: redishelper.SnapshotImportResult{Overwritten: 1},
+		},
+		{
+			name: "same UUID, skip",
+			imported: func(e redishelper.ServiceEntry) redishelper.ServiceEntry {
+				e.InstanceID, e.Status = "i-restored", redishelper.StatusHealthy
+				return e
+			},
+			mode: "skip",
+			want: redishelper.SnapshotImportResult{Skipped: 1},
+			keep: true,
+		},
+		{
+			name: "same UUID, overwrite",
+			imported: func(e redishelper.ServiceEntry) redishelper.ServiceEntry {
+				e.InstanceID, e.Status = "i-restored", redishelper.StatusHealthy
+				return e
+			},
+			mode: "overwrite",
+			want: redishelper.SnapshotImportResult{Overwritten: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+				registered := registered(t, rclient)
+				imported := tt.imported(registered)
+
+				if got := importSnapshot(t, rclient, tt.mode, imported); got != tt.want {
+					t.Fatalf("import result = %+v, want %+v", got, tt.want)
+				}
+
+				stored := storedGateways(t, rclient)
+				if len(stored) != 1 {
+					t.Fatalf("%d entries stored after the import, want 1: %+v", len(stored), stored)
+				}
+				want := imported
+				if tt.keep {
+					want = registered
+				}
+				for _, entry := range stored {
+					if entry.ServiceUUID != want.ServiceUUID || entry.InstanceID != want.InstanceID || entry.Name != want.Name {
+						t.Fatalf("stored entry %s (%s, %s), want %s (%s, %s)", entry.ServiceUUID, entry.Name, entry.InstanceID, want.ServiceUUID, want.Name, want.InstanceID)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestSnapshotImportNewEntry(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, rclient redisclient.Client) {
+		registered := registered(t, rclient)
+		other := registered
+		other.ServiceUUID, other.InstanceID = "3f2b8c1e-5a4d-4e6f-9b7a-1c2d3e4f5a6b", "i-2"
+
+		if got, want := importSnapshot(t, rclient, "skip", other), (redishelper.SnapshotImportResult{Imported: 1}); got != want {
+			t.Fatalf("import result = %+v, want %+v", got, want)
+		}
+		if stored := storedGateways(t, rclient); len(stored) != 2 {
+			t.Fatalf("%d entries stored after the import, want 2", len(stored))
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	requestDTOs "github.com/tahakara/discogo/internal/api/dtos/requestdto"
+	env "github.com/tahakara/discogo/internal/config"
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// MediaTypeV2 is the vendor media type of v2 responses. Clients that only
+// accept application/json get the same body under that type.
+const MediaTypeV2 = "application/vnd.discogo.v2+json"
+
+type InstanceV2 struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Status      string            `json:"status"`
+	Version     string            `json:"version"`
+	Provider    string            `json:"provider"`
+	Region      string            `json:"region"`
+	Zone        string            `json:"zone"`
+	Address     string            `json:"address"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Load        *LoadInfo         `json:"load,omitempty"`
+	LastHeardAt string            `json:"lastHeardAt,omitempty"`
+}
+
+type InstanceListV2 struct {
+	Type      string       `json:"type"`
+	Instances []InstanceV2 `json:"instances"`
+}
+
+type InstanceRegistrationV2 struct {
+	InstanceV2
+	// HealthCheckCycle is the expected heartbeat interval in seconds.
+	HealthCheckCycle int `json:"healthCheckCycle"`
+	// ReplacedID is the instance removed by a replacing registration.
+	ReplacedID string `json:"replacedId,omitempty"`
+}
+
+// NegotiateV2 returns the media type a v2 response to r is written in, or
+// false when the Accept header allows neither MediaTypeV2 nor JSON.
+func NegotiateV2(r *http.Request) (string, bool) {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypeV2, true
+	}
+
+	jsonOK := false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(strings.TrimSpace(params), " ", "") == "q=0" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case MediaTypeV2:
+			return MediaTypeV2, true
+		case "application/json", "application/*", "*/*":
+			jsonOK = true
+		}
+	}
+	if jsonOK {
+		return "application/json", true
+	}
+	return "", false
+}
+
+// writeV2 writes v unwrapped in the negotiated v2 media type.
+func writeV2(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	mediaType, _ := NegotiateV2(r)
+	w.Header().Add("Vary", "Accept")
+	utils.WriteJSONBody(w, mediaType, status, v)
+}
+
+func toInstanceV2(entry redishelper.ServiceEntry) InstanceV2 {
+	return InstanceV2{
+		ID:          entry.ServiceUUID,
+		Name:        entry.Name,
+		Type:        entry.Type,
+		Status:      string(entry.Status),
+		Version:     entry.Version,
+		Provider:    entry.Provider,
+		Region:      entry.Region,
+		Zone:        entry.Zone,
+		Address:     serviceAddr(entry),
+		Tags:        entry.Tags,
+		Metadata:    entry.Metadata,
+		Load:        loadInfo(entry),
+		LastHeardAt: entry.LastHeardAt,
+	}
+}
+
+// requireServiceType answers 404 when serviceType is not in the catalog.
+func requireServiceType(w http.ResponseWriter, r *http.Request, serviceType string) bool {
+	if !serviceconfigloader.IsKnownServiceType(serviceType) {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeUnknownServiceType, "Unknown service type")
+		return false
+	}
+	return true
+}
+
+// findInstance looks up serviceUUID and answers 404 unless it is an instance
+// of serviceType.
+func findInstance(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceType, serviceUUID string) (redishelper.ServiceEntry, bool) {
+	if !requireServiceType(w, r, serviceType) || !validateServiceUUIDParam(w, r, serviceUUID) {
+		return redishelper.ServiceEntry{}, false
+	}
+	found, entry := redishelper.IsServiceExistsByUUID(rclient, serviceUUID)
+	if !found || entry.Type != serviceType {
+		utils.WriteError(w, r, http.StatusNotFound, utils.CodeServiceNotFound, "Service not found")
+		return redishelper.ServiceEntry{}, false
+	}
+	return entry, true
+}
+
+// ListInstancesV2Handler godoc
+// @Summary      List instances of a service type
+// @Description  Lists the instances of a service type. Accepts the discover filters (status, provider, region, zone, networkid, subnetid, instanceid, version, pagesize, pageoffset) as query parameters.
+// @Tags         v2
+// @Produce      application/vnd.discogo.v2+json,json,application/problem+json
+// @Param        type  path      string  true  "Service type"
+// @Success      200   {object}  InstanceListV2
+// @Failure      400   {object}  utils.Problem  "INVALID_PARAMETER"
+// @Failure      404   {object}  utils.Problem  "UNKNOWN_SERVICE_TYPE"
+// @Router       /v2/services/{type}/instances [get]
+func ListInstancesV2Handler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceType string) {
+	if !requireServiceType(w, r, serviceType) {
+		return
+	}
+	services, ok := discoverServices(w, r, rclient, serviceType)
+	if !ok {
+		return
+	}
+
+	instances := []InstanceV2{}
+	for _, service := range services {
+		instances = append(instances, toInstanceV2(service))
+	}
+	writeV2(w, r, http.StatusOK, InstanceListV2{
+		Type:      serviceType,
+		Instances: instances,
+	})
+}
+
+// RegisterInstanceV2Handler godoc
+// @Summary      Register an instance of a service type
+// @Description  Registers an instance. The body is the v1 registration payload; Type may be omitted and defaults to the path. An instance with the same identity is returned with 200 unless replace=true.
+// @Tags         v2
+// @Accept       json
+// @Produce      application/vnd.discogo.v2+json,json,application/problem+json
+// @Param        type             path      string                          true   "Service type"
+// @Param        request          body      requestdto.RegisterRequestDTO  true   "Service registration payload"
+// @Param        replace          query     bool                            false  "Replace an existing instance of the same identity"
+// @Param        Idempotency-Key  header    string                          false  "Returns the original registration when a request is retried"
+// @Success      200              {object}  InstanceRegistrationV2  "Existing instance"
+// @Success      201              {object}  InstanceRegistrationV2
+// @Failure      400              {object}  utils.Problem  "INVALID_BODY, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure      404              {object}  utils.Problem  "UNKNOWN_SERVICE_TYPE"
+// @Failure      409              {object}  utils.Problem  "REGISTRATION_IN_PROGRESS"
+// @Failure      422              {object}  utils.Problem  "IDEMPOTENCY_KEY_REUSED"
+// @Failure      500              {object}  utils.Problem  "INTERNAL_ERROR"
+// @Failure      503              {object}  utils.Problem  "STORAGE_UNAVAILABLE"
+// @Router       /v2/services/{type}/instances [post]
+func RegisterInstanceV2Handler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig, serviceType string) {
+	if !requireServiceType(w, r, serviceType) {
+		return
+	}
+
+	var req requestDTOs.RegisterRequestDTO
+	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if req.Type == "" {
+		req.Type = serviceType
+	}
+	if req.Type != serviceType {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "Type in the body does not match the path")
+		return
+	}
+
+	result, ok := registerService(w, r, rclient, registry, req)
+	if !ok {
+		return
+	}
+
+	status := http.StatusCreated
+	if result.existing {
+		status = http.StatusOK
+	}
+	w.Header().Set("Location", "/v2/services/"+serviceType+"/instances/"+result.entry.ServiceUUID)
+	writeV2(w, r, status, InstanceRegistrationV2{
+		InstanceV2:       toInstanceV2(result.entry),
+		HealthCheckCycle: registry.HealthCheckInterval,
+		ReplacedID:       result.replaced,
+	})
+}
+
+// GetInstanceV2Handler godoc
+// @Summary      Get an instance
+// @Tags         v2
+// @Produce      application/vnd.discogo.v2+json,json,application/problem+json
+// @Param        type  path      string  true  "Service type"
+// @Param        uuid  path      string  true  "Service UUID"
+// @Success      200   {object}  InstanceV2
+// @Failure      404   {object}  utils.Problem  "UNKNOWN_SERVICE_TYPE or SERVICE_NOT_FOUND"
+// @Router       /v2/services/{type}/instances/{uuid} [get]
+func GetInstanceV2Handler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceType, serviceUUID string) {
+	entry, ok := findInstance(w, r, rclient, serviceType, serviceUUID)
+	if !ok {
+		return
+	}
+	writeV2(w, r, http.StatusOK, toInstanceV2(entry))
+}
+
+// HeartbeatInstanceV2Handler godoc
+// @Summary      Send a heartbeat for an instance
+// @Tags         v2
+// @Accept       json
+// @Param        type     path  string                          true   "Service type"
+// @Param        uuid     path  string                          true   "Service UUID"
+// @Param        request  body  requestdto.HeartbeatRequestDTO  false  "Load and health report"
+// @Success      204
+// @Failure      400   {object}  utils.Problem  "INVALID_BODY or VALIDATION_FAILED"
+// @Failure      404   {object}  utils.Problem  "UNKNOWN_SERVICE_TYPE or SERVICE_NOT_FOUND"
+// @Failure      409   {object}  utils.Problem  "SERVICE_SUSPICIOUS or ILLEGAL_TRANSITION"
+// @Router       /v2/services/{type}/instances/{uuid}/heartbeat [post]
+func HeartbeatInstanceV2Handler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, registry env.RegistryConfig, serviceType, serviceUUID string) {
+	if _, ok := findInstance(w, r, rclient, serviceType, serviceUUID); !ok {
+		return
+	}
+	payload, ok := decodeHeartbeatPayload(w, r)
+	if !ok {
+		return
+	}
+	if !heartbeatService(w, r, rclient, registry, serviceUUID, payload) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeregisterInstanceV2Handler godoc
+// @Summary      Deregister an instance
+// @Tags         v2
+// @Param        type  path  string  true  "Service type"
+// @Param        uuid  path  string  true  "Service UUID"
+// @Success      204
+// @Failure      404   {object}  utils.Problem  "UNKNOWN_SERVICE_TYPE or SERVICE_NOT_FOUND"
+// @Router       /v2/services/{type}/instances/{uuid} [delete]
+func DeregisterInstanceV2Handler(w http.ResponseWriter, r *http.Request, rclient redisclient.Client, serviceType, serviceUUID string) {
+	if _, ok := findInstance(w, r, rclient, serviceType, serviceUUID); !ok {
+		return
+	}
+	if !deregisterService(w, r, rclient, serviceUUID) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	validators "github.com/tahakara/discogo/internal/api/validators"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+func TestRegisterValidationErrors(t *testing.T) {
+	const body = `{"Name":"ab","Type":"nope","Version":"1.0.0","Provider":"aws","Region":"r1","Zone":"z1","Cluster":"c","InstanceID":"i-1","NetworkID":"n","SubnetID":"s","NetworkDomain":"d","Addr4":"10.0.0.1","Port4":80,"Check":{"Type":"icmp"}}`
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantLanguage   string
+		wantDetail     string
+		wantMessages   map[string]string
+	}{
+		{
+			name:         "default language",
+			wantLanguage: "en",
+			wantDetail:   "The request has 3 invalid field(s).",
+			wantMessages: map[string]string{
+				"Name":       "Name must be at least 3 characters long.",
+				"Type":       "Invalid service type.",
+				"Check.Type": "Check.Type must be one of: http tcp grpc.",
+			},
+		},
+		{
+			name:           "negotiated language",
+			acceptLanguage: "de-DE, tr;q=0.8, en;q=0.5",
+			wantLanguage:   "tr",
+			wantDetail:     "İstekte 3 geçersiz alan var.",
+			wantMessages: map[string]string{
+				"Name":       "Name alanı en az 3 karakter olmalıdır.",
+				"Type":       "Geçersiz servis tipi.",
+				"Check.Type": "Check.Type alanı şunlardan biri olmalıdır: http tcp grpc.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/register", bytes.NewBufferString(body))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			// Validation fails before the storage backend is used
+			RegisterHandler(w, r, nil, env.Defaults().Registry)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("register returned %d, want 400: %s", w.Code, w.Body)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Fatalf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+			var problem struct {
+				Code   string                       `json:"code"`
+				Detail string                       `json:"detail"`
+				Errors []validators.ValidationError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Code != utils.CodeValidationFailed || problem.Detail != tt.wantDetail {
+				t.Fatalf("problem = %s %q, want %s %q", problem.Code, problem.Detail, utils.CodeValidationFailed, tt.wantDetail)
+			}
+
+			byField := map[string]validators.ValidationError{}
+			for _, e := range problem.Errors {
+				byField[e.Field] = e
+			}
+			if len(byField) != len(tt.wantMessages) {
+				t.Fatalf("errors = %+v, want one per field of %v", problem.Errors, tt.wantMessages)
+			}
+			for field, message := range tt.wantMessages {
+				if got := byField[field].Message; got != message {
+					t.Errorf("%s message = %q, want %q", field, got, message)
+				}
+			}
+
+			if e := byField["Name"]; e.Rule != "min" || e.Param != "3" || e.Allowed != nil {
+				t.Errorf("Name error = %+v, want rule min with param 3", e)
+			}
+			if e := byField["Check.Type"]; !reflect.DeepEqual(e.Allowed, []string{"http", "tcp", "grpc"}) {
+				t.Errorf("Check.Type allowed = %v, want the oneof values", e.Allowed)
+			}
+			found := false
+			for _, v := range byField["Type"].Allowed {
+				found = found || v == "gw"
+			}
+			if !found {
+				t.Errorf("Type allowed = %v, want the catalog's service types", byField["Type"].Allowed)
+			}
+		})
+	}
+}
@@ -18,17 +18,17 @@ type VersionResponse struct {
 // @Description  Retrieves the version information of the service
 // @Tags         DiscoGo
 // @Accept       json
-// @Produce      json
+// @Produce      json,application/problem+json
 // @Success      200 {object} VersionResponse
-// @Failure      406 {object} utils.JSONResponse
-// @Router       /disco/version [get]
-func VersionHandler(w http.ResponseWriter, r *http.Request) {
-	appName := env.GetDiscoGoName()
-	appVersion := env.GetDiscoGoVersion()
-	appVersionName := env.GetDiscoGoVersionName()
+// @Failure      406 {object} utils.Problem
+// @Router       /v1/version [get]
+func VersionHandler(w http.ResponseWriter, r *http.Request, app env.AppConfig) {
+	appName := app.Name
+	appVersion := app.Version
+	appVersionName := app.VersionName
 
 	if appName == "" || appVersion == "" || appVersionName == "" {
-		utils.WriteJSONResponse(w, http.StatusNotAcceptable, nil)
+		utils.WriteError(w, r, http.StatusNotAcceptable, utils.CodeNotAcceptable, "Version information is not configured")
 		return
 	}
 
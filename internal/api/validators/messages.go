@@ -0,0 +1,125 @@
+package validators
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultLanguage = "en"
+
+// messageBundles holds the validation messages per language, keyed by rule.
+// Rules whose wording depends on the field kind use a ".string" / ".number" /
+// ".items" suffix. {field} and {param} are replaced when the message is rendered.
+var messageBundles = map[string]map[string]string{
+	"en": {
+		"required":                     "{field} is required.",
+		"min.string":                   "{field} must be at least {param} characters long.",
+		"max.string":                   "{field} must be at most {param} characters long.",
+		"min.number":                   "{field} must be at least {param}.",
+		"max.number":                   "{field} must be at most {param}.",
+		"min.items":                    "{field} must have at least {param} entries.",
+		"max.items":                    "{field} must have at most {param} entries.",
+		"tagkey":                       "{field} must be a key of letters, digits, '.', '_', '/' and '-' that starts with a letter or digit.",
+		"tagvalue":                     "{field} must be printable and may not contain ':', '*', '?', '[', ']' or '\\'.",
+		"type":                         "Invalid service type.",
+		"version":                      "Invalid version format, expected dot-separated numbers such as 1.0.0.",
+		"provider":                     "Invalid provider.",
+		"ip4_addr":                     "{field} must be a valid IPv4 address.",
+		"ip6_addr":                     "{field} must be a valid IPv6 address.",
+		"alphanumanddashandunderscore": "{field} may only contain letters, digits, '-' and '_'.",
+		"name":                         "{field} may only contain printable characters.",
+		"address_required":             "Either (Addr4 and Port4) or (Addr6 and Port6) must be provided.",
+		"oneof":                        "{field} must be one of: {param}.",
+		"startswith":                   "{field} must start with '{param}'.",
+		"uuid":                         "{field} must be a valid UUID.",
+		"nefield":                      "{field} must differ from {param}.",
+		"default":                      "Invalid value for {field}.",
+		"summary":                      "The request has {param} invalid field(s).",
+	},
+	"tr": {
+		"required":                     "{field} alanı zorunludur.",
+		"min.string":                   "{field} alanı en az {param} karakter olmalıdır.",
+		"max.string":                   "{field} alanı en fazla {param} karakter olmalıdır.",
+		"min.number":                   "{field} alanı en az {param} olmalıdır.",
+		"max.number":                   "{field} alanı en fazla {param} olmalıdır.",
+		"min.items":                    "{field} alanı en az {param} öğe içermelidir.",
+		"max.items":                    "{field} alanı en fazla {param} öğe içerebilir.",
+		"tagkey":                       "{field} alanı harf veya rakamla başlayan; harf, rakam, '.', '_', '/' ve '-' içeren bir anahtar olmalıdır.",
+		"tagvalue":                     "{field} alanı yazdırılabilir olmalı ve ':', '*', '?', '[', ']' veya '\\' içermemelidir.",
+		"type":                         "Geçersiz servis tipi.",
+		"version":                      "Geçersiz versiyon formatı, 1.0.0 gibi noktayla ayrılmış sayılar beklenir.",
+		"provider":                     "Geçersiz provider.",
+		"ip4_addr":                     "{field} alanı geçerli bir IPv4 adresi olmalıdır.",
+		"ip6_addr":                     "{field} alanı geçerli bir IPv6 adresi olmalıdır.",
+		"alphanumanddashandunderscore": "{field} alanı yalnızca harf, rakam, '-' ve '_' içerebilir.",
+		"name":                         "{field} alanı yalnızca yazdırılabilir karakterler içerebilir.",
+		"address_required":             "(Addr4 ve Port4) veya (Addr6 ve Port6) alanlarından biri sağlanmalıdır.",
+		"oneof":                        "{field} alanı şunlardan biri olmalıdır: {param}.",
+		"startswith":                   "{field} alanı '{param}' ile başlamalıdır.",
+		"uuid":                         "{field} alanı geçerli bir UUID olmalıdır.",
+		"nefield":                      "{field} alanı {param} alanından farklı olmalıdır.",
+		"default":                      "{field} alanı için geçersiz değer.",
+		"summary":                      "İstekte {param} geçersiz alan var.",
+	},
+}
+
+// NegotiateLanguage picks the best supported language from an Accept-Language
+// header, honouring q-values. It falls back to DefaultLanguage.
+func NegotiateLanguage(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		candidates = append(candidates, candidate{lang: base, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.q <= 0 {
+			continue
+		}
+		if _, ok := messageBundles[c.lang]; ok {
+			return c.lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// renderMessage returns the message for key in lang, falling back to English
+// and then to the default message.
+func renderMessage(lang, key, field, param string) string {
+	bundle, ok := messageBundles[lang]
+	if !ok {
+		bundle = messageBundles[DefaultLanguage]
+	}
+	msg, ok := bundle[key]
+	if !ok {
+		msg, ok = messageBundles[DefaultLanguage][key]
+	}
+	if !ok {
+		msg = bundle["default"]
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(msg)
+}
+
+// Summary returns a one-line description of errs in lang.
+func Summary(lang string, errs []ValidationError) string {
+	return renderMessage(lang, "summary", "", strconv.Itoa(len(errs)))
+}
@@ -0,0 +1,19 @@
+package validators
+
+import (
+	"github.com/go-playground/validator/v10"
+	requestDTOs "github.com/tahakara/discogo/internal/api/dtos/requestdto"
+)
+
+// ValidateOutcomesRequest validates a request outcome submission. Messages
+// are rendered in lang (see NegotiateLanguage).
+func ValidateOutcomesRequest(req *requestDTOs.OutcomesRequestDTO, lang string) []ValidationError {
+	if err := validate.Struct(req); err != nil {
+		var errors []ValidationError
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, toValidationError(err, lang))
+		}
+		return errors
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package validators
 
 import (
-	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
+	"strings"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
 	requestDTOs "github.com/tahakara/discogo/internal/api/dtos/requestdto"
@@ -15,16 +18,12 @@ func init() {
 	validate = validator.New()
 	// Register custom version validation: dot-separated numbers, e.g. 1.0.0
 	validate.RegisterValidation("version", func(fl validator.FieldLevel) bool {
-		version := fl.Field().String()
-		// Accepts versions like 1.0, 1.0.0, 2.3.4.5 etc.
-		matched, _ := regexp.MatchString(`^\d+(\.\d+)*$`, version)
-		return matched
+		return IsVersion(fl.Field().String())
 	})
 
 	validate.RegisterValidation("type", func(fl validator.FieldLevel) bool {
 		typeStr := fl.Field().String()
-		matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, typeStr)
-		if matched {
+		if IsIdentifier(typeStr) {
 			return serviceconfigloader.IsValidServiceType(typeStr)
 		}
 		return false
@@ -32,53 +31,144 @@ func init() {
 
 	validate.RegisterValidation("provider", func(fl validator.FieldLevel) bool {
 		providerStr := fl.Field().String()
-		matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, providerStr)
-		if matched {
+		if IsIdentifier(providerStr) {
 			return serviceconfigloader.IsValidProvider(providerStr)
 		}
 		return false
 	})
 
 	validate.RegisterValidation("alphanumanddashandunderscore", func(fl validator.FieldLevel) bool {
-		str := fl.Field().String()
-		matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, str)
-		return matched
+		return IsIdentifier(fl.Field().String())
 	})
 
+	// Names are free text but are part of the storage key; keep them printable
+	validate.RegisterValidation("name", func(fl validator.FieldLevel) bool {
+		for _, r := range fl.Field().String() {
+			if !unicode.IsPrint(r) {
+				return false
+			}
+		}
+		return true
+	})
+
+	// Tag and metadata keys: a letter or digit followed by letters, digits, '.', '_', '/' and '-'
+	validate.RegisterValidation("tagkey", func(fl validator.FieldLevel) bool {
+		return tagKeyPattern.MatchString(fl.Field().String())
+	})
+
+	// Tag and metadata values: printable text without ':' and glob characters,
+	// which have a meaning in storage keys and key patterns
+	validate.RegisterValidation("tagvalue", func(fl validator.FieldLevel) bool {
+		for _, r := range fl.Field().String() {
+			if !unicode.IsPrint(r) || strings.ContainsRune(tagValueReserved, r) {
+				return false
+			}
+		}
+		return true
+	})
 }
 
-func validationErrorToText(fe validator.FieldError) string {
+var (
+	// Accepts versions like 1.0, 1.0.0, 2.3.4.5 etc.
+	versionPattern    = regexp.MustCompile(`^\d+(\.\d+)*$`)
+	identifierPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	tagKeyPattern     = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`)
+)
+
+// IsVersion reports whether s is a dot-separated version such as 1.0.0.
+func IsVersion(s string) bool {
+	return versionPattern.MatchString(s)
+}
+
+// IsIdentifier reports whether s is made of letters, digits, '-' and '_' only,
+// like the regions, zones and IDs of a registration.
+func IsIdentifier(s string) bool {
+	return identifierPattern.MatchString(s)
+}
+
+// tagValueReserved lists the characters tag and metadata values may not contain.
+const tagValueReserved = `:*?[]\`
+
+// ValidationError describes one failed rule of a request.
+type ValidationError struct {
+	Field   string   `json:"field"`
+	Rule    string   `json:"rule"`
+	Param   string   `json:"param,omitempty"`
+	Message string   `json:"message"`
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// messageKey selects the message variant for rules whose wording depends on
+// whether the field is a string or a number.
+func messageKey(fe validator.FieldError) string {
 	switch fe.Tag() {
-	case "required":
-		return fmt.Sprintf("%s alanı zorunludur.", fe.Field())
-	case "min":
-		return fmt.Sprintf("%s alanı en az %s karakter olmalıdır.", fe.Field(), fe.Param())
-	case "max":
-		return fmt.Sprintf("%s alanı en fazla %s karakter olmalıdır.", fe.Field(), fe.Param())
+	case "min", "max":
+		switch fe.Kind() {
+		case reflect.String:
+			return fe.Tag() + ".string"
+		case reflect.Map, reflect.Slice:
+			return fe.Tag() + ".items"
+		}
+		return fe.Tag() + ".number"
+	}
+	return fe.Tag()
+}
+
+// allowedValues lists the accepted values for rules backed by the catalog
+// and for oneof rules.
+func allowedValues(fe validator.FieldError) []string {
+	var all []string
+	switch fe.Tag() {
+	case "oneof":
+		return strings.Fields(fe.Param())
 	case "type":
-		return "Geçersiz servis tipi."
-	case "version":
-		return "Geçersiz versiyon formatı."
+		for _, short := range serviceconfigloader.GetAllServiceTypes() {
+			if serviceconfigloader.IsValidServiceType(short) {
+				all = append(all, short)
+			}
+		}
 	case "provider":
-		return "Geçersiz provider."
-	case "ip4_addr":
-		return fmt.Sprintf("%s alanı geçerli bir IPv4 adresi olmalıdır.", fe.Field())
-	case "ip6_addr":
-		return fmt.Sprintf("%s alanı geçerli bir IPv6 adresi olmalıdır.", fe.Field())
-	// Diğer tag'ler için de ekleyebilirsin
+		for _, short := range serviceconfigloader.GetAllProviders() {
+			if serviceconfigloader.IsValidProvider(short) {
+				all = append(all, short)
+			}
+		}
 	default:
-		return fmt.Sprintf("%s alanı için geçersiz değer.", fe.Field())
+		return nil
+	}
+	sort.Strings(all)
+	return all
+}
+
+// fieldPath names fe by its path below the request, e.g. "Check.Type" for a
+// nested field.
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func toValidationError(fe validator.FieldError, lang string) ValidationError {
+	return ValidationError{
+		Field:   fieldPath(fe),
+		Rule:    fe.Tag(),
+		Param:   fe.Param(),
+		Message: renderMessage(lang, messageKey(fe), fieldPath(fe), fe.Param()),
+		Allowed: allowedValues(fe),
 	}
 }
 
-// ValidateRegisterRequest validates a RegisterRequest instance.
-func ValidateRegisterRequest(req *requestDTOs.RegisterRequestDTO) []string {
+// ValidateRegisterRequest validates a RegisterRequest instance. Messages are
+// rendered in lang (see NegotiateLanguage).
+func ValidateRegisterRequest(req *requestDTOs.RegisterRequestDTO, lang string) []ValidationError {
 	// Önce field bazlı validasyonları uygula
 	if err := validate.Struct(req); err != nil {
 
-		var errors []string
+		var errors []ValidationError
 		for _, err := range err.(validator.ValidationErrors) {
-			errors = append(errors, validationErrorToText(err))
+			errors = append(errors, toValidationError(err, lang))
 		}
 		return errors
 	}
@@ -88,7 +178,11 @@ func ValidateRegisterRequest(req *requestDTOs.RegisterRequestDTO) []string {
 	addr6ok := req.Addr6 != "" && req.Port6 > 0
 
 	if !addr4ok && !addr6ok {
-		return []string{"either (Addr4 and Port4) or (Addr6 and Port6) must be provided"}
+		return []ValidationError{{
+			Field:   "Addr4",
+			Rule:    "address_required",
+			Message: renderMessage(lang, "address_required", "Addr4", ""),
+		}}
 	}
 
 	return nil
@@ -0,0 +1,322 @@
+package boltclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketName is the single bucket that holds every key/value pair.
+var bucketName = []byte("discogo")
+
+// Each stored value is prefixed with an 8 byte big-endian unix nano timestamp
+// of its expiry. A zero timestamp means the key never expires.
+const expiryHeaderSize = 8
+
+type client struct {
+	db *bolt.DB
+
+	stopSweeper chan struct{}
+	closeOnce   sync.Once
+
+	handlersMu      sync.RWMutex
+	expiredHandlers []func(key string)
+}
+
+// New opens (or creates) the embedded database file at path and starts the
+// TTL sweeper. The returned client satisfies redisclient.Client so it can be
+// used anywhere the Redis backend is used.
+func New(path string, sweepInterval time.Duration) (redisclient.Client, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c := &client{
+		db:          db,
+		stopSweeper: make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go c.sweep(sweepInterval)
+	}
+	return c, nil
+}
+
+func encodeValue(value []byte, expiration time.Duration) []byte {
+	buf := make([]byte, expiryHeaderSize+len(value))
+	if expiration > 0 {
+		binary.BigEndian.PutUint64(buf, uint64(time.Now().Add(expiration).UnixNano()))
+	}
+	copy(buf[expiryHeaderSize:], value)
+	return buf
+}
+
+// decodeValue splits a stored record into its expiry and payload. ok is false
+// when the record is malformed or already expired.
+func decodeValue(raw []byte, now time.Time) (expiresAt int64, value []byte, ok bool) {
+	if len(raw) < expiryHeaderSize {
+		return 0, nil, false
+	}
+	expiresAt = int64(binary.BigEndian.Uint64(raw[:expiryHeaderSize]))
+	if expiresAt != 0 && expiresAt <= now.UnixNano() {
+		return expiresAt, nil, false
+	}
+	value = make([]byte, len(raw)-expiryHeaderSize)
+	copy(value, raw[expiryHeaderSize:])
+	return expiresAt, value, true
+}
+
+func (c *client) Get(key string) ([]byte, error) {
+	var value []byte
+	err := c.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(bucketName).Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		_, v, ok := decodeValue(raw, time.Now())
+		if ok {
+			value = v
+		}
+		return nil
+	})
+	return value, err
+}
+
+func (c *client) Set(key string, value []byte, expiration time.Duration) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Put([]byte(key), encodeValue(value, expiration))
+	})
+}
+
+func (c *client) Delete(key string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Delete([]byte(key))
+	})
+}
+
+func (c *client) CompareAndDelete(key string, value []byte) (bool, error) {
+	deleted := false
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		if _, current, ok := decodeValue(raw, time.Now()); !ok || !bytes.Equal(current, value) {
+			return nil
+		}
+		deleted = true
+		return b.Delete([]byte(key))
+	})
+	return deleted, err
+}
+
+func (c *client) Add(key string, value []byte, expiration time.Duration) (bool, error) {
+	// Only set the key if it does not already exist (or has expired)
+	stored := false
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		if raw := b.Get([]byte(key)); raw != nil {
+			if _, _, ok := decodeValue(raw, time.Now()); ok {
+				return nil
+			}
+		}
+		if err := b.Put([]byte(key), encodeValue(value, expiration)); err != nil {
+			return err
+		}
+		stored = true
+		return nil
+	})
+	return stored, err
+}
+
+func (c *client) Replace(key string, value []byte, expiration time.Duration) error {
+	// Only set the key if it already exists
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		if _, _, ok := decodeValue(raw, time.Now()); !ok {
+			return nil
+		}
+		return b.Put([]byte(key), encodeValue(value, expiration))
+	})
+}
+
+func (c *client) Increment(key string, delta int64) (int64, error) {
+	var result int64
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		var (
+			current   int64
+			expiresAt int64
+		)
+		if raw := b.Get([]byte(key)); raw != nil {
+			exp, v, ok := decodeValue(raw, time.Now())
+			if ok {
+				n, err := strconv.ParseInt(string(v), 10, 64)
+				if err != nil {
+					return errors.New("value is not an integer")
+				}
+				current = n
+				expiresAt = exp
+			}
+		}
+		result = current + delta
+
+		// Like INCRBY, keep the existing expiry of the key
+		buf := make([]byte, expiryHeaderSize)
+		binary.BigEndian.PutUint64(buf, uint64(expiresAt))
+		buf = append(buf, strconv.FormatInt(result, 10)...)
+		return b.Put([]byte(key), buf)
+	})
+	return result, err
+}
+
+func (c *client) Decrement(key string, delta int64) (int64, error) {
+	return c.Increment(key, -delta)
+}
+
+func (c *client) FlushAll() error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketName); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		if err := tx.DeleteBucket(streamsBucketName); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		_, err := tx.CreateBucket(bucketName)
+		return err
+	})
+}
+
+func (c *client) Ping() error {
+	return c.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketName) == nil {
+			return errors.New("bucket not found")
+		}
+		return nil
+	})
+}
+
+func (c *client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stopSweeper)
+	})
+	return c.db.Close()
+}
+
+// FindKeys returns all live keys matching the given Redis-style glob pattern.
+func (c *client) FindKeys(pattern string) ([]string, error) {
+	var keys []string
+	now := time.Now()
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).ForEach(func(k, v []byte) error {
+			if _, _, ok := decodeValue(v, now); !ok {
+				return nil
+			}
+			if matchPattern(pattern, string(k)) {
+				keys = append(keys, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (c *client) TTL(key string) (time.Duration, error) {
+	ttl := time.Duration(-1)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(bucketName).Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		now := time.Now()
+		expiresAt, _, ok := decodeValue(raw, now)
+		if !ok {
+			return nil
+		}
+		if expiresAt == 0 {
+			ttl = 0
+			return nil
+		}
+		ttl = time.Duration(expiresAt - now.UnixNano())
+		return nil
+	})
+	return ttl, err
+}
+
+// sweep periodically removes expired keys so the database file does not grow
+// with registrations that stopped heartbeating.
+func (c *client) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopSweeper:
+			return
+		case <-ticker.C:
+			c.removeExpired()
+		}
+	}
+}
+
+// SubscribeExpired registers handler to be called by the sweeper for every
+// key it removes.
+func (c *client) SubscribeExpired(handler func(key string)) error {
+	c.handlersMu.Lock()
+	c.expiredHandlers = append(c.expiredHandlers, handler)
+	c.handlersMu.Unlock()
+	return nil
+}
+
+func (c *client) removeExpired() {
+	now := time.Now()
+	var expired [][]byte
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		b.ForEach(func(k, v []byte) error {
+			if _, _, ok := decodeValue(v, now); !ok {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	for _, k := range expired {
+		for _, handler := range c.expiredHandlers {
+			handler(string(k))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package boltclient
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/redis/redistest"
+)
+
+// testSweepInterval keeps the sweeper quick enough for expiry events.
+const testSweepInterval = 20 * time.Millisecond
+
+func open(t *testing.T, path string) redisclient.Client {
+	t.Helper()
+	c, err := New(path, testSweepInterval)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	paths := map[redisclient.Client]string{}
+	redistest.Run(t, redistest.Backend{
+		New: func(t *testing.T) redisclient.Client {
+			path := filepath.Join(t.TempDir(), "discogo.db")
+			c := open(t, path)
+			paths[c] = path
+			return c
+		},
+		Reopen: func(t *testing.T, c redisclient.Client) redisclient.Client {
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			reopened := open(t, paths[c])
+			paths[reopened] = paths[c]
+			return reopened
+		},
+		Elapse: func(t *testing.T, c redisclient.Client, d time.Duration) {
+			time.Sleep(d)
+		},
+		ExpiryEvents: true,
+	})
+}
@@ -0,0 +1,97 @@
+package boltclient
+
+// matchPattern reports whether str matches the Redis glob-style pattern.
+// Supported syntax mirrors SCAN MATCH: '*', '?', '[abc]', '[^a]', '[a-z]' and
+// '\' to escape the next character.
+func matchPattern(pattern, str string) bool {
+	p, s := []rune(pattern), []rune(str)
+	return matchRunes(p, s)
+}
+
+func matchRunes(p, s []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for len(p) > 1 && p[1] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchRunes(p[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			p, s = p[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			matched, rest, ok := matchClass(p[1:], s[0])
+			if !ok {
+				// Unterminated class, treat '[' literally
+				if s[0] != '[' {
+					return false
+				}
+				p, s = p[1:], s[1:]
+				continue
+			}
+			if !matched {
+				return false
+			}
+			p, s = rest, s[1:]
+		case '\\':
+			if len(p) >= 2 {
+				p = p[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || p[0] != s[0] {
+				return false
+			}
+			p, s = p[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass matches c against the character class starting right after '['.
+// It returns the remaining pattern after the closing ']'.
+func matchClass(p []rune, c rune) (matched bool, rest []rune, ok bool) {
+	negate := false
+	if len(p) > 0 && p[0] == '^' {
+		negate = true
+		p = p[1:]
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == ']':
+			return matched != negate, p[i+1:], true
+		case p[i] == '\\' && i+1 < len(p):
+			i++
+			if p[i] == c {
+				matched = true
+			}
+		case i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']':
+			lo, hi := p[i], p[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 2
+		default:
+			if p[i] == c {
+				matched = true
+			}
+		}
+	}
+	return false, nil, false
+}
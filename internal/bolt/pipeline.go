@@ -0,0 +1,67 @@
+package boltclient
+
+import (
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	bolt "go.etcd.io/bbolt"
+)
+
+// pipeline runs the queued commands in a single read-write transaction.
+type pipeline struct {
+	c   *client
+	ops []func(b *bolt.Bucket, now time.Time) redisclient.PipelineResult
+}
+
+func (c *client) Pipeline() redisclient.Pipeline {
+	return &pipeline{c: c}
+}
+
+func (p *pipeline) Get(key string) {
+	p.ops = append(p.ops, func(b *bolt.Bucket, now time.Time) redisclient.PipelineResult {
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return redisclient.PipelineResult{}
+		}
+		_, value, ok := decodeValue(raw, now)
+		if !ok {
+			return redisclient.PipelineResult{}
+		}
+		return redisclient.PipelineResult{Value: value}
+	})
+}
+
+func (p *pipeline) Set(key string, value []byte, expiration time.Duration) {
+	p.ops = append(p.ops, func(b *bolt.Bucket, now time.Time) redisclient.PipelineResult {
+		return redisclient.PipelineResult{Err: b.Put([]byte(key), encodeValue(value, expiration))}
+	})
+}
+
+func (p *pipeline) Delete(key string) {
+	p.ops = append(p.ops, func(b *bolt.Bucket, now time.Time) redisclient.PipelineResult {
+		return redisclient.PipelineResult{Err: b.Delete([]byte(key))}
+	})
+}
+
+func (p *pipeline) Exec() ([]redisclient.PipelineResult, error) {
+	if len(p.ops) == 0 {
+		return nil, nil
+	}
+	results := make([]redisclient.PipelineResult, len(p.ops))
+	err := p.c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		now := time.Now()
+		for i, op := range p.ops {
+			results[i] = op(b, now)
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing was committed
+		for i := range results {
+			results[i] = redisclient.PipelineResult{Err: err}
+		}
+		return results, err
+	}
+	return results, nil
+}
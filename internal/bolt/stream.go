@@ -0,0 +1,173 @@
+package boltclient
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	bolt "go.etcd.io/bbolt"
+)
+
+// streamsBucketName holds one nested bucket per stream. Entries are keyed by
+// 8 byte big-endian millisecond timestamp + 8 byte sequence number, which
+// keeps them in the same order as Redis stream IDs.
+var streamsBucketName = []byte("streams")
+
+var errInvalidStreamID = errors.New("invalid stream ID")
+
+type streamID struct {
+	ms  uint64
+	seq uint64
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+func (id streamID) key() []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], id.ms)
+	binary.BigEndian.PutUint64(buf[8:], id.seq)
+	return buf
+}
+
+func streamIDFromKey(k []byte) streamID {
+	return streamID{
+		ms:  binary.BigEndian.Uint64(k[:8]),
+		seq: binary.BigEndian.Uint64(k[8:16]),
+	}
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// parseStreamBound parses a range bound. A bare millisecond value expands to
+// its lowest sequence for start bounds and its highest for end bounds.
+func parseStreamBound(s string, isStart bool) (id streamID, exclusive bool, err error) {
+	switch s {
+	case "-":
+		return streamID{}, false, nil
+	case "+":
+		return streamID{ms: math.MaxUint64, seq: math.MaxUint64}, false, nil
+	}
+	if strings.HasPrefix(s, "(") {
+		exclusive = true
+		s = s[1:]
+	}
+
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	id.ms, err = strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return streamID{}, false, errInvalidStreamID
+	}
+	switch {
+	case hasSeq:
+		id.seq, err = strconv.ParseUint(seqPart, 10, 64)
+		if err != nil {
+			return streamID{}, false, errInvalidStreamID
+		}
+	case !isStart:
+		id.seq = math.MaxUint64
+	}
+	return id, exclusive, nil
+}
+
+func (c *client) StreamAdd(stream string, values map[string]string, maxAge time.Duration) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	var id streamID
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		streams, err := tx.CreateBucketIfNotExists(streamsBucketName)
+		if err != nil {
+			return err
+		}
+		b, err := streams.CreateBucketIfNotExists([]byte(stream))
+		if err != nil {
+			return err
+		}
+
+		id = streamID{ms: uint64(time.Now().UnixMilli())}
+		// IDs must be strictly increasing even if the clock goes backwards
+		if k, _ := b.Cursor().Last(); k != nil {
+			if last := streamIDFromKey(k); !last.less(id) {
+				id = streamID{ms: last.ms, seq: last.seq + 1}
+			}
+		}
+		if err := b.Put(id.key(), data); err != nil {
+			return err
+		}
+
+		if maxAge > 0 {
+			minID := streamID{ms: uint64(time.Now().Add(-maxAge).UnixMilli())}
+			cursor := b.Cursor()
+			for k, _ := cursor.First(); k != nil && streamIDFromKey(k).less(minID); k, _ = cursor.Next() {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+func (c *client) StreamRange(stream string, start string, end string, count int64) ([]redisclient.StreamEntry, error) {
+	startID, startExclusive, err := parseStreamBound(start, true)
+	if err != nil {
+		return nil, err
+	}
+	endID, endExclusive, err := parseStreamBound(end, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []redisclient.StreamEntry{}
+	err = c.db.View(func(tx *bolt.Tx) error {
+		streams := tx.Bucket(streamsBucketName)
+		if streams == nil {
+			return nil
+		}
+		b := streams.Bucket([]byte(stream))
+		if b == nil {
+			return nil
+		}
+
+		cursor := b.Cursor()
+		for k, v := cursor.Seek(startID.key()); k != nil; k, v = cursor.Next() {
+			id := streamIDFromKey(k)
+			if startExclusive && id == startID {
+				continue
+			}
+			if endID.less(id) || (endExclusive && id == endID) {
+				break
+			}
+
+			values := map[string]string{}
+			if err := json.Unmarshal(v, &values); err != nil {
+				continue
+			}
+			entries = append(entries, redisclient.StreamEntry{ID: id.String(), Values: values})
+			if count > 0 && int64(len(entries)) >= count {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
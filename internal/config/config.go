@@ -0,0 +1,423 @@
+package env
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StorageBackendRedis = "redis"
+	StorageBackendBolt  = "bolt"
+)
+
+// Config holds every DiscoGo setting. It is built once at startup by Load and
+// passed to the components that need it.
+type Config struct {
+	HTTP      HTTPConfig      `yaml:"http"`
+	App       AppConfig       `yaml:"app"`
+	Storage   StorageConfig   `yaml:"storage"`
+	Registry  RegistryConfig  `yaml:"registry"`
+	Admin     AdminConfig     `yaml:"admin"`
+	Probe     ProbeConfig     `yaml:"probe"`
+	Outlier   OutlierConfig   `yaml:"outlier"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+	Catalog   CatalogConfig   `yaml:"catalog"`
+	Log       LogConfig       `yaml:"log"`
+}
+
+type HTTPConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// MaxBodyBytes caps every JSON request body; larger bodies get 413
+	MaxBodyBytes int64 `yaml:"maxBodyBytes"`
+	// MaxSnapshotBytes caps snapshot imports, which carry the whole registry
+	MaxSnapshotBytes int64 `yaml:"maxSnapshotBytes"`
+	// TrustedProxies lists the IPs and CIDRs of the proxies in front of
+	// DiscoGo. X-Forwarded-For is only honoured on requests from them.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// Addr returns the listen address of the HTTP server.
+func (c HTTPConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// TrustedProxyNets parses TrustedProxies; a plain IP is a single-address
+// network.
+func (c HTTPConfig) TrustedProxyNets() ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP or CIDR", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR", proxy)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+type AppConfig struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	VersionName string `yaml:"versionName"`
+}
+
+type StorageConfig struct {
+	// Backend is "redis" or "bolt".
+	Backend string      `yaml:"backend"`
+	Redis   RedisConfig `yaml:"redis"`
+	Bolt    BoltConfig  `yaml:"bolt"`
+}
+
+type RedisConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// Addr returns the Redis server address.
+func (c RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+type BoltConfig struct {
+	Path string `yaml:"path"`
+	// SweepInterval is how often expired keys are purged, in seconds.
+	SweepInterval int `yaml:"sweepInterval"`
+}
+
+type RegistryConfig struct {
+	// HealthCheckInterval is the heartbeat cycle handed to registering services, in seconds.
+	HealthCheckInterval int `yaml:"healthCheckInterval"`
+	// ReportToleranceCount is how many distinct reporters within ReportWindow
+	// make a service suspicious.
+	ReportToleranceCount int64 `yaml:"reportToleranceCount"`
+	// ReportWindow is how long a report counts, in seconds.
+	ReportWindow int `yaml:"reportWindow"`
+	// ReportHalfLife halves the weight of a report every ReportHalfLife
+	// seconds of its age. 0 disables decay.
+	ReportHalfLife int `yaml:"reportHalfLife"`
+	// ReportReputation weights reports by the reporter's own status.
+	ReportReputation bool `yaml:"reportReputation"`
+	// RecoveryHeartbeats is how many good heartbeats in a row bring a
+	// suspicious service back. 0 keeps suspicious services suspicious.
+	RecoveryHeartbeats int `yaml:"recoveryHeartbeats"`
+	// IdempotencyKeyTTL is how long an Idempotency-Key is remembered, in seconds.
+	IdempotencyKeyTTL int `yaml:"idempotencyKeyTTL"`
+	// BulkMaxItems is the largest batch the bulk endpoints accept.
+	BulkMaxItems int `yaml:"bulkMaxItems"`
+}
+
+// IdempotencyKeyExpiration returns IdempotencyKeyTTL as a duration.
+func (c RegistryConfig) IdempotencyKeyExpiration() time.Duration {
+	return time.Duration(c.IdempotencyKeyTTL) * time.Second
+}
+
+type AdminConfig struct {
+	// Token guards the admin API. Admin routes are disabled when it is empty.
+	Token string `yaml:"token"`
+	// AuditRetentionHours is how long audit events are kept. 0 keeps them forever.
+	AuditRetentionHours int `yaml:"auditRetentionHours"`
+}
+
+// AuditRetention returns AuditRetentionHours as a duration.
+func (c AdminConfig) AuditRetention() time.Duration {
+	return time.Duration(c.AuditRetentionHours) * time.Hour
+}
+
+// ProbeConfig controls the active health checks declared by registrations.
+type ProbeConfig struct {
+	// Workers is how many checks run at once. 0 disables active checking.
+	Workers int `yaml:"workers"`
+	// Interval is the check interval in seconds when a check sets none.
+	Interval int `yaml:"interval"`
+	// Timeout is the check timeout in seconds when a check sets none.
+	Timeout int `yaml:"timeout"`
+	// FailureThreshold is how many checks in a row must fail before the
+	// instance is no longer considered healthy.
+	FailureThreshold int `yaml:"failureThreshold"`
+}
+
+// OutlierConfig controls the ejection of instances whose client-reported
+// error rate stands out among the instances of their type.
+type OutlierConfig struct {
+	// Interval is the length of an aggregation interval in seconds. 0
+	// disables ejection.
+	Interval int `yaml:"interval"`
+	// MinRequests is how many requests an instance needs in an interval to
+	// be evaluated.
+	MinRequests int64 `yaml:"minRequests"`
+	// MinHosts is how many instances of a type need MinRequests before any
+	// of them is ejected.
+	MinHosts int `yaml:"minHosts"`
+	// StdevFactor is how many thousandths of a standard deviation above the
+	// mean error rate of its type make an instance an outlier (1900 = 1.9).
+	StdevFactor int `yaml:"stdevFactor"`
+	// BaseEjectionTime is the first ejection in seconds; each further one
+	// doubles it.
+	BaseEjectionTime int `yaml:"baseEjectionTime"`
+	// MaxEjectionTime caps the ejection time, in seconds.
+	MaxEjectionTime int `yaml:"maxEjectionTime"`
+	// MaxEjectionPercent caps the share of a type's instances ejected at
+	// once. At least one and never all instances may be ejected.
+	MaxEjectionPercent int `yaml:"maxEjectionPercent"`
+}
+
+// AggregationInterval returns Interval as a duration.
+func (c OutlierConfig) AggregationInterval() time.Duration {
+	return time.Duration(c.Interval) * time.Second
+}
+
+// RateLimitConfig limits how often one client, identified by its API key or
+// source IP, may call a route.
+type RateLimitConfig struct {
+	// APIKeys lists the keys clients may send in X-API-Key to get buckets of
+	// their own. Requests with any other key are limited by source IP.
+	APIKeys []string `yaml:"apiKeys"`
+	// Rate is how many requests per second a client may make to a route
+	// without a limit of its own. 0 leaves those routes unlimited.
+	Rate int `yaml:"rate"`
+	// Burst is how many requests a client may make at once. 0 uses Rate.
+	Burst int `yaml:"burst"`
+	// Routes overrides the limit per route, keyed by the route's v1 path
+	// without the version (e.g. "/register") or its full v2 path.
+	Routes map[string]RouteRateLimit `yaml:"routes"`
+	// Shared keeps the buckets in the storage backend so replicas enforce
+	// one limit together, instead of one limit each.
+	Shared bool `yaml:"shared"`
+}
+
+// RouteRateLimit is the limit of one route. A Rate of 0 leaves it unlimited.
+type RouteRateLimit struct {
+	Rate  int `yaml:"rate"`
+	Burst int `yaml:"burst"`
+}
+
+// Limit returns the limit of route, with Burst defaulting to Rate.
+func (c RateLimitConfig) Limit(route string) RouteRateLimit {
+	limit, ok := c.Routes[route]
+	if !ok {
+		limit = RouteRateLimit{Rate: c.Rate, Burst: c.Burst}
+	}
+	if limit.Burst == 0 {
+		limit.Burst = limit.Rate
+	}
+	return limit
+}
+
+type CatalogConfig struct {
+	// Path is the service catalog file. Files ending in .yaml or .yml are
+	// parsed as YAML, everything else as JSON.
+	Path string `yaml:"path"`
+	// WatchInterval is how often the catalog file is polled for changes, in
+	// seconds. 0 disables polling; SIGHUP and the reload endpoint still work.
+	WatchInterval int `yaml:"watchInterval"`
+}
+
+type LogConfig struct {
+	Color bool `yaml:"color"`
+}
+
+// Defaults returns the configuration used when nothing else is set.
+func Defaults() Config {
+	return Config{
+		HTTP: HTTPConfig{Host: "127.0.0.1", Port: 8080, MaxBodyBytes: 1 << 20, MaxSnapshotBytes: 64 << 20},
+		App:  AppConfig{Name: "discoGo", Version: "1.0.0", VersionName: "Artemis"},
+		Storage: StorageConfig{
+			Backend: StorageBackendRedis,
+			Redis:   RedisConfig{Host: "127.0.0.1", Port: 6379},
+			Bolt:    BoltConfig{Path: "discogo.db", SweepInterval: 10},
+		},
+		Registry: RegistryConfig{HealthCheckInterval: 30, ReportToleranceCount: 5, ReportWindow: 300, ReportHalfLife: 120, RecoveryHeartbeats: 5, IdempotencyKeyTTL: 86400, BulkMaxItems: 100},
+		Admin:    AdminConfig{AuditRetentionHours: 168},
+		Probe:    ProbeConfig{Workers: 8, Interval: 10, Timeout: 2, FailureThreshold: 3},
+		Outlier:  OutlierConfig{Interval: 10, MinRequests: 100, MinHosts: 5, StdevFactor: 1900, BaseEjectionTime: 30, MaxEjectionTime: 300, MaxEjectionPercent: 10},
+		Catalog:  CatalogConfig{Path: "conf.json", WatchInterval: 5},
+	}
+}
+
+// ConfigError lists every problem found while building the configuration.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the whole configuration and reports every problem at once.
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Host != "", "http.host (DISCOGO_HTTP_HOST) must not be empty")
+	check(c.HTTP.Port > 0 && c.HTTP.Port <= 65535, "http.port (DISCOGO_HTTP_PORT) must be 1-65535, got %d", c.HTTP.Port)
+	check(c.HTTP.MaxBodyBytes > 0, "http.maxBodyBytes (DISCOGO_HTTP_MAX_BODY_BYTES) must be > 0, got %d", c.HTTP.MaxBodyBytes)
+	check(c.HTTP.MaxSnapshotBytes >= c.HTTP.MaxBodyBytes, "http.maxSnapshotBytes (DISCOGO_HTTP_MAX_SNAPSHOT_BYTES) must be >= http.maxBodyBytes, got %d", c.HTTP.MaxSnapshotBytes)
+	if _, err := c.HTTP.TrustedProxyNets(); err != nil {
+		check(false, "http.trustedProxies (DISCOGO_HTTP_TRUSTED_PROXIES): %v", err)
+	}
+
+	check(c.App.Name != "", "app.name (DISCOGO_NAME) must not be empty")
+	check(c.App.Version != "", "app.version (DISCOGO_VERSION) must not be empty")
+	check(c.App.VersionName != "", "app.versionName (DISCOGO_VERSION_NAME) must not be empty")
+
+	switch c.Storage.Backend {
+	case StorageBackendRedis:
+		check(c.Storage.Redis.Host != "", "storage.redis.host (REDIS_HOST) must not be empty")
+		check(c.Storage.Redis.Port > 0 && c.Storage.Redis.Port <= 65535, "storage.redis.port (REDIS_PORT) must be 1-65535, got %d", c.Storage.Redis.Port)
+		check(c.Storage.Redis.DB >= 0, "storage.redis.db (REDIS_DB) must be >= 0, got %d", c.Storage.Redis.DB)
+	case StorageBackendBolt:
+		check(c.Storage.Bolt.Path != "", "storage.bolt.path (BOLT_DB_PATH) must not be empty")
+		check(c.Storage.Bolt.SweepInterval > 0, "storage.bolt.sweepInterval (BOLT_SWEEP_INTERVAL) must be > 0, got %d", c.Storage.Bolt.SweepInterval)
+	default:
+		check(false, "storage.backend (DISCOGO_STORAGE_BACKEND) must be %q or %q, got %q", StorageBackendRedis, StorageBackendBolt, c.Storage.Backend)
+	}
+
+	check(c.Registry.HealthCheckInterval > 0, "registry.healthCheckInterval (HEALTH_CHECK_INTERVAL) must be > 0, got %d", c.Registry.HealthCheckInterval)
+	check(c.Registry.ReportToleranceCount > 0, "registry.reportToleranceCount (REPORT_TOLERANCE_COUNT) must be > 0, got %d", c.Registry.ReportToleranceCount)
+	check(c.Registry.ReportWindow > 0, "registry.reportWindow (REPORT_WINDOW) must be > 0, got %d", c.Registry.ReportWindow)
+	check(c.Registry.ReportHalfLife >= 0, "registry.reportHalfLife (REPORT_HALF_LIFE) must be >= 0, got %d", c.Registry.ReportHalfLife)
+	check(c.Registry.RecoveryHeartbeats >= 0, "registry.recoveryHeartbeats (RECOVERY_HEARTBEATS) must be >= 0, got %d", c.Registry.RecoveryHeartbeats)
+	check(c.Registry.IdempotencyKeyTTL > 0, "registry.idempotencyKeyTTL (IDEMPOTENCY_KEY_TTL) must be > 0, got %d", c.Registry.IdempotencyKeyTTL)
+	check(c.Registry.BulkMaxItems > 0, "registry.bulkMaxItems (BULK_MAX_ITEMS) must be > 0, got %d", c.Registry.BulkMaxItems)
+
+	check(c.Admin.AuditRetentionHours >= 0, "admin.auditRetentionHours (AUDIT_RETENTION_HOURS) must be >= 0, got %d", c.Admin.AuditRetentionHours)
+
+	check(c.Probe.Workers >= 0, "probe.workers (PROBE_WORKERS) must be >= 0, got %d", c.Probe.Workers)
+	check(c.Probe.Interval > 0, "probe.interval (PROBE_INTERVAL) must be > 0, got %d", c.Probe.Interval)
+	check(c.Probe.Timeout > 0, "probe.timeout (PROBE_TIMEOUT) must be > 0, got %d", c.Probe.Timeout)
+	check(c.Probe.FailureThreshold > 0, "probe.failureThreshold (PROBE_FAILURE_THRESHOLD) must be > 0, got %d", c.Probe.FailureThreshold)
+
+	check(c.Outlier.Interval >= 0, "outlier.interval (OUTLIER_INTERVAL) must be >= 0, got %d", c.Outlier.Interval)
+	check(c.Outlier.MinRequests > 0, "outlier.minRequests (OUTLIER_MIN_REQUESTS) must be > 0, got %d", c.Outlier.MinRequests)
+	check(c.Outlier.MinHosts >= 2, "outlier.minHosts (OUTLIER_MIN_HOSTS) must be >= 2, got %d", c.Outlier.MinHosts)
+	check(c.Outlier.StdevFactor > 0, "outlier.stdevFactor (OUTLIER_STDEV_FACTOR) must be > 0, got %d", c.Outlier.StdevFactor)
+	check(c.Outlier.BaseEjectionTime > 0, "outlier.baseEjectionTime (OUTLIER_BASE_EJECTION_TIME) must be > 0, got %d", c.Outlier.BaseEjectionTime)
+	check(c.Outlier.MaxEjectionTime >= c.Outlier.BaseEjectionTime, "outlier.maxEjectionTime (OUTLIER_MAX_EJECTION_TIME) must be >= outlier.baseEjectionTime, got %d", c.Outlier.MaxEjectionTime)
+	check(c.Outlier.MaxEjectionPercent > 0 && c.Outlier.MaxEjectionPercent <= 100, "outlier.maxEjectionPercent (OUTLIER_MAX_EJECTION_PERCENT) must be 1-100, got %d", c.Outlier.MaxEjectionPercent)
+
+	check(c.RateLimit.Rate >= 0, "rateLimit.rate (RATE_LIMIT_RATE) must be >= 0, got %d", c.RateLimit.Rate)
+	check(c.RateLimit.Burst >= 0, "rateLimit.burst (RATE_LIMIT_BURST) must be >= 0, got %d", c.RateLimit.Burst)
+	for _, key := range c.RateLimit.APIKeys {
+		check(key != "", "rateLimit.apiKeys (RATE_LIMIT_API_KEYS) must not contain empty keys")
+	}
+	for route, limit := range c.RateLimit.Routes {
+		check(limit.Rate >= 0 && limit.Burst >= 0, "rateLimit.routes (RATE_LIMIT_ROUTES) %s must have rate and burst >= 0, got %d:%d", route, limit.Rate, limit.Burst)
+	}
+
+	check(c.Catalog.Path != "", "catalog.path (DISCOGO_CONFIG_PATH) must not be empty")
+	check(c.Catalog.WatchInterval >= 0, "catalog.watchInterval (CATALOG_WATCH_INTERVAL) must be >= 0, got %d", c.Catalog.WatchInterval)
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// loadFile overlays the YAML settings file at path onto c.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("settings file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load builds the configuration from, in increasing priority: defaults, the
+// optional settings file (-settings or DISCOGO_SETTINGS_FILE), environment
+// variables (an optional .env file is read first and never overrides real
+// variables) and command-line flags. It returns the arguments left after the
+// flags and an error listing every problem found.
+func Load(args []string) (Config, []string, error) {
+	cfg := Defaults()
+	var problems []string
+
+	if err := loadDotEnv(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	fs := flag.NewFlagSet("discogo", flag.ContinueOnError)
+	settingsFile := fs.String("settings", os.Getenv("DISCOGO_SETTINGS_FILE"), "optional YAML settings file")
+	var flagValues []func(*Config) error
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, s.usage+" (env "+s.env+")", func(v string) error {
+			flagValues = append(flagValues, func(c *Config) error {
+				if err := s.set(c, v); err != nil {
+					return fmt.Errorf("-%s: %v", s.flag, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	if *settingsFile != "" {
+		if err := cfg.loadFile(*settingsFile); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, s := range settings {
+		v, ok := os.LookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := s.set(&cfg, v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+		}
+	}
+
+	for _, apply := range flagValues {
+		if err := apply(&cfg); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var configErr *ConfigError
+		if errors.As(err, &configErr) {
+			problems = append(problems, configErr.Problems...)
+		}
+	}
+	if len(problems) > 0 {
+		return cfg, fs.Args(), &ConfigError{Problems: problems}
+	}
+	return cfg, fs.Args(), nil
+}
@@ -1,146 +1,187 @@
 package env
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"io/fs"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
-func LoadEnv() {
+// loadDotEnv reads .env into the environment when it exists. Variables that
+// are already set are left alone, and a missing file is not an error so
+// deployments can rely on real environment variables only.
+func loadDotEnv() error {
 	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-		os.Exit(1)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return nil
 	}
-
-	if !CheckEnvs() {
-		os.Exit(1)
-	} else {
-		log.Println("All required environment variables are set")
-	}
-
-}
-
-func CheckEnvs() bool {
-	requiredEnvs := []string{
-		"DISCOGO_HTTP_HOST",
-		"DISCOGO_HTTP_PORT",
-		"DISCOGO_VERSION",
-		"DISCOGO_NAME",
-		"DISCOGO_VERSION_NAME",
-
-		"REDIS_HOST",
-		"REDIS_PORT",
-		"REDIS_PASSWORD",
-		"REDIS_DB",
-
-		"HEALTH_CHECK_INTERVAL",
-		"REPORT_TOLERANCE_COUNT",
-	}
-
-	for _, env := range requiredEnvs {
-		if os.Getenv(env) == "" {
-			log.Fatal("Required environment variable not set: " + env)
-			return false
-		}
-	}
-	return true
+	return fmt.Errorf(".env: %v", err)
 }
 
-func GetRedisServerAddr() string {
-	if os.Getenv("REDIS_HOST") == "" || os.Getenv("REDIS_PORT") == "" {
-		return "127.0.0.1:6379"
-	}
-	return os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT")
-}
-
-func GetRedisCredentials() (string, string, int) {
-	if os.Getenv("REDIS_HOST") == "" || os.Getenv("REDIS_PORT") == "" || os.Getenv("REDIS_PASSWORD") == "" {
-		return "127.0.0.1:6379", "default_pass", 0
-	}
-
-	return os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT"),
-		os.Getenv("REDIS_PASSWORD"),
-		getEnvAsInt("REDIS_DB", 0)
-}
-
-func GetRedisDB() int {
-	return getEnvAsInt("REDIS_DB", 0)
-}
-
-func GetRedisPassword() string {
-	val := os.Getenv("REDIS_PASSWORD")
-	if val == "" {
-		return "default_password"
-	}
-	return val
+// setting binds one Config field to an environment variable and a flag. An
+// empty value resets the field to its zero value, so a variable that is set
+// but empty clears a value from the settings file.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(c *Config, v string) error
 }
 
-func GetDiscoGoHTTPAddr() string {
-	if os.Getenv("DISCOGO_HTTP_HOST") == "" || os.Getenv("DISCOGO_HTTP_PORT") == "" {
-		return "127.0.0.1:8080"
-	}
-	return os.Getenv("DISCOGO_HTTP_HOST") + ":" + os.Getenv("DISCOGO_HTTP_PORT")
+func stringSetting(envName, flagName, usage string, field func(c *Config) *string) setting {
+	return setting{envName, flagName, usage, func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}}
 }
 
-func GetDiscoGoVersion() string {
-	val := os.Getenv("DISCOGO_VERSION")
-	if val == "" {
-		return "1.0.0"
-	}
-	return val
+// stringListSetting parses a comma separated list, trimming each item.
+func stringListSetting(envName, flagName, usage string, field func(c *Config) *[]string) setting {
+	return setting{envName, flagName, usage, func(c *Config, v string) error {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*field(c) = items
+		return nil
+	}}
 }
 
-func GetDiscoGoName() string {
-	val := os.Getenv("DISCOGO_NAME")
-	if val == "" {
-		return "discoGo"
-	}
-	return val
+func intSetting(envName, flagName, usage string, field func(c *Config) *int) setting {
+	return setting{envName, flagName, usage, func(c *Config, v string) error {
+		if v == "" {
+			*field(c) = 0
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+		*field(c) = n
+		return nil
+	}}
 }
 
-func GetDiscoGoVersionName() string {
-	val := os.Getenv("DISCOGO_VERSION_NAME")
-	if val == "" {
-		return "Artemis"
-	}
-	return val
-}
-func IsColorEnabled() bool {
-	val := os.Getenv("DISCOGO_LOG_COLOR")
-	if val == "" {
-		return false
-	}
-	return val == "true" || val == "1"
+func int64Setting(envName, flagName, usage string, field func(c *Config) *int64) setting {
+	return setting{envName, flagName, usage, func(c *Config, v string) error {
+		if v == "" {
+			*field(c) = 0
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+		*field(c) = n
+		return nil
+	}}
 }
 
-func GetHealthCheckInterval() int {
-	return getEnvAsInt("HEALTH_CHECK_INTERVAL", 30)
+func boolSetting(envName, flagName, usage string, field func(c *Config) *bool) setting {
+	return setting{envName, flagName, usage, func(c *Config, v string) error {
+		if v == "" {
+			*field(c) = false
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		*field(c) = b
+		return nil
+	}}
 }
 
-func GetReportToleranceCount() int64 {
-	valStr := os.Getenv("REPORT_TOLERANCE_COUNT")
-	if valStr == "" {
-		return 5 // Default value if not set
-	}
-	val, err := strconv.ParseInt(valStr, 10, 64)
-	if err != nil {
-		return 5
-	}
-	return val
+// routeLimitsSetting parses route limits written as
+// "/register=5:10,/discover=20", i.e. route=rate[:burst] pairs.
+func routeLimitsSetting(envName, flagName, usage string, field func(c *Config) *map[string]RouteRateLimit) setting {
+	return setting{envName, flagName, usage, func(c *Config, v string) error {
+		if v == "" {
+			*field(c) = nil
+			return nil
+		}
+		limits := map[string]RouteRateLimit{}
+		for _, pair := range strings.Split(v, ",") {
+			route, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || route == "" {
+				return fmt.Errorf("%q is not a route=rate[:burst] pair", pair)
+			}
+			rate, burst, hasBurst := strings.Cut(value, ":")
+			var limit RouteRateLimit
+			var err error
+			if limit.Rate, err = strconv.Atoi(rate); err != nil {
+				return fmt.Errorf("%q: %q is not an integer", pair, rate)
+			}
+			if hasBurst {
+				if limit.Burst, err = strconv.Atoi(burst); err != nil {
+					return fmt.Errorf("%q: %q is not an integer", pair, burst)
+				}
+			}
+			limits[route] = limit
+		}
+		*field(c) = limits
+		return nil
+	}}
 }
 
-// getEnvAsInt retrieves an environment variable as an int, or returns the default value if not set or invalid.
-func getEnvAsInt(key string, defaultVal int) int {
-	valStr := os.Getenv(key)
-	if valStr == "" {
-		return defaultVal
-	}
-	val, err := strconv.Atoi(valStr)
-	if err != nil {
-		return defaultVal
-	}
-	return val
+// settings lists every environment variable and flag that maps onto Config.
+var settings = []setting{
+	stringSetting("DISCOGO_HTTP_HOST", "http-host", "HTTP listen host", func(c *Config) *string { return &c.HTTP.Host }),
+	intSetting("DISCOGO_HTTP_PORT", "http-port", "HTTP listen port", func(c *Config) *int { return &c.HTTP.Port }),
+	int64Setting("DISCOGO_HTTP_MAX_BODY_BYTES", "http-max-body-bytes", "largest accepted JSON request body in bytes", func(c *Config) *int64 { return &c.HTTP.MaxBodyBytes }),
+	int64Setting("DISCOGO_HTTP_MAX_SNAPSHOT_BYTES", "http-max-snapshot-bytes", "largest accepted snapshot import in bytes", func(c *Config) *int64 { return &c.HTTP.MaxSnapshotBytes }),
+	stringListSetting("DISCOGO_HTTP_TRUSTED_PROXIES", "http-trusted-proxies", "IPs and CIDRs of proxies whose X-Forwarded-For is honoured, comma separated", func(c *Config) *[]string { return &c.HTTP.TrustedProxies }),
+
+	stringSetting("DISCOGO_NAME", "name", "application name", func(c *Config) *string { return &c.App.Name }),
+	stringSetting("DISCOGO_VERSION", "version", "application version", func(c *Config) *string { return &c.App.Version }),
+	stringSetting("DISCOGO_VERSION_NAME", "version-name", "application version name", func(c *Config) *string { return &c.App.VersionName }),
+
+	stringSetting("DISCOGO_STORAGE_BACKEND", "storage", "storage backend: redis or bolt", func(c *Config) *string { return &c.Storage.Backend }),
+	stringSetting("REDIS_HOST", "redis-host", "Redis host", func(c *Config) *string { return &c.Storage.Redis.Host }),
+	intSetting("REDIS_PORT", "redis-port", "Redis port", func(c *Config) *int { return &c.Storage.Redis.Port }),
+	stringSetting("REDIS_PASSWORD", "redis-password", "Redis password", func(c *Config) *string { return &c.Storage.Redis.Password }),
+	intSetting("REDIS_DB", "redis-db", "Redis database number", func(c *Config) *int { return &c.Storage.Redis.DB }),
+	stringSetting("BOLT_DB_PATH", "bolt-path", "embedded database file", func(c *Config) *string { return &c.Storage.Bolt.Path }),
+	intSetting("BOLT_SWEEP_INTERVAL", "bolt-sweep-interval", "embedded database expiry sweep interval in seconds", func(c *Config) *int { return &c.Storage.Bolt.SweepInterval }),
+
+	intSetting("HEALTH_CHECK_INTERVAL", "health-check-interval", "heartbeat cycle in seconds", func(c *Config) *int { return &c.Registry.HealthCheckInterval }),
+	int64Setting("REPORT_TOLERANCE_COUNT", "report-tolerance", "distinct reporters within the report window that make a service suspicious", func(c *Config) *int64 { return &c.Registry.ReportToleranceCount }),
+	intSetting("REPORT_WINDOW", "report-window", "how long a report counts in seconds", func(c *Config) *int { return &c.Registry.ReportWindow }),
+	intSetting("REPORT_HALF_LIFE", "report-half-life", "report weight half-life in seconds, 0 disables decay", func(c *Config) *int { return &c.Registry.ReportHalfLife }),
+	boolSetting("REPORT_REPUTATION", "report-reputation", "weight reports by the reporter's status", func(c *Config) *bool { return &c.Registry.ReportReputation }),
+	intSetting("RECOVERY_HEARTBEATS", "recovery-heartbeats", "good heartbeats before a suspicious service recovers, 0 disables", func(c *Config) *int { return &c.Registry.RecoveryHeartbeats }),
+	intSetting("IDEMPOTENCY_KEY_TTL", "idempotency-key-ttl", "how long idempotency keys are remembered in seconds", func(c *Config) *int { return &c.Registry.IdempotencyKeyTTL }),
+	intSetting("BULK_MAX_ITEMS", "bulk-max-items", "largest batch accepted by the bulk endpoints", func(c *Config) *int { return &c.Registry.BulkMaxItems }),
+
+	stringSetting("DISCOGO_ADMIN_TOKEN", "admin-token", "admin API bearer token", func(c *Config) *string { return &c.Admin.Token }),
+	intSetting("AUDIT_RETENTION_HOURS", "audit-retention-hours", "audit log retention in hours", func(c *Config) *int { return &c.Admin.AuditRetentionHours }),
+
+	intSetting("PROBE_WORKERS", "probe-workers", "concurrent active health checks, 0 disables", func(c *Config) *int { return &c.Probe.Workers }),
+	intSetting("PROBE_INTERVAL", "probe-interval", "default active health check interval in seconds", func(c *Config) *int { return &c.Probe.Interval }),
+	intSetting("PROBE_TIMEOUT", "probe-timeout", "default active health check timeout in seconds", func(c *Config) *int { return &c.Probe.Timeout }),
+	intSetting("PROBE_FAILURE_THRESHOLD", "probe-failure-threshold", "failed checks in a row before an instance is unhealthy", func(c *Config) *int { return &c.Probe.FailureThreshold }),
+
+	intSetting("OUTLIER_INTERVAL", "outlier-interval", "outlier ejection interval in seconds, 0 disables", func(c *Config) *int { return &c.Outlier.Interval }),
+	int64Setting("OUTLIER_MIN_REQUESTS", "outlier-min-requests", "requests an instance needs in an interval to be evaluated", func(c *Config) *int64 { return &c.Outlier.MinRequests }),
+	intSetting("OUTLIER_MIN_HOSTS", "outlier-min-hosts", "evaluated instances of a type needed before any is ejected", func(c *Config) *int { return &c.Outlier.MinHosts }),
+	intSetting("OUTLIER_STDEV_FACTOR", "outlier-stdev-factor", "error rate standard deviations above the mean, in thousandths, that make an outlier", func(c *Config) *int { return &c.Outlier.StdevFactor }),
+	intSetting("OUTLIER_BASE_EJECTION_TIME", "outlier-base-ejection-time", "first ejection in seconds, doubled on each further one", func(c *Config) *int { return &c.Outlier.BaseEjectionTime }),
+	intSetting("OUTLIER_MAX_EJECTION_TIME", "outlier-max-ejection-time", "longest ejection in seconds", func(c *Config) *int { return &c.Outlier.MaxEjectionTime }),
+	intSetting("OUTLIER_MAX_EJECTION_PERCENT", "outlier-max-ejection-percent", "largest share of a type's instances ejected at once", func(c *Config) *int { return &c.Outlier.MaxEjectionPercent }),
+
+	intSetting("RATE_LIMIT_RATE", "rate-limit-rate", "requests per second per client on routes without their own limit, 0 disables", func(c *Config) *int { return &c.RateLimit.Rate }),
+	intSetting("RATE_LIMIT_BURST", "rate-limit-burst", "requests per client at once, 0 uses the rate", func(c *Config) *int { return &c.RateLimit.Burst }),
+	routeLimitsSetting("RATE_LIMIT_ROUTES", "rate-limit-routes", "per-route limits as route=rate[:burst], comma separated", func(c *Config) *map[string]RouteRateLimit { return &c.RateLimit.Routes }),
+	stringListSetting("RATE_LIMIT_API_KEYS", "rate-limit-api-keys", "X-API-Key values that get buckets of their own, comma separated", func(c *Config) *[]string { return &c.RateLimit.APIKeys }),
+	boolSetting("RATE_LIMIT_SHARED", "rate-limit-shared", "share rate limits between replicas through the storage backend", func(c *Config) *bool { return &c.RateLimit.Shared }),
+
+	stringSetting("DISCOGO_CONFIG_PATH", "catalog", "service catalog file (.json, .yaml or .yml)", func(c *Config) *string { return &c.Catalog.Path }),
+	intSetting("CATALOG_WATCH_INTERVAL", "catalog-watch-interval", "catalog file poll interval in seconds, 0 disables", func(c *Config) *int { return &c.Catalog.WatchInterval }),
+
+	boolSetting("DISCOGO_LOG_COLOR", "log-color", "colored log levels", func(c *Config) *bool { return &c.Log.Color }),
 }
@@ -0,0 +1,55 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmptyEnvClearsSettingsFile(t *testing.T) {
+	settings := filepath.Join(t.TempDir(), "discogo.yaml")
+	yaml := `
+admin:
+  token: secret
+rateLimit:
+  rate: 10
+  apiKeys: [agent-1]
+  routes:
+    /register:
+      rate: 5
+`
+	if err := os.WriteFile(settings, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DISCOGO_ADMIN_TOKEN", "")
+	t.Setenv("RATE_LIMIT_RATE", "")
+	t.Setenv("RATE_LIMIT_API_KEYS", "")
+	t.Setenv("RATE_LIMIT_ROUTES", "")
+
+	cfg, _, err := Load([]string{"-settings", settings})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Admin.Token != "" || cfg.RateLimit.Rate != 0 || len(cfg.RateLimit.APIKeys) != 0 || len(cfg.RateLimit.Routes) != 0 {
+		t.Fatalf("empty variables kept settings file values: token=%q rate=%d apiKeys=%v routes=%v",
+			cfg.Admin.Token, cfg.RateLimit.Rate, cfg.RateLimit.APIKeys, cfg.RateLimit.Routes)
+	}
+}
+
+func TestUnsetEnvKeepsSettingsFile(t *testing.T) {
+	settings := filepath.Join(t.TempDir(), "discogo.yaml")
+	if err := os.WriteFile(settings, []byte("admin:\n  token: secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Setenv restores the variable after the test, then it is unset for Load
+	t.Setenv("DISCOGO_ADMIN_TOKEN", "")
+	os.Unsetenv("DISCOGO_ADMIN_TOKEN")
+
+	cfg, _, err := Load([]string{"-settings", settings})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Admin.Token != "secret" {
+		t.Fatalf("token = %q, want the settings file value", cfg.Admin.Token)
+	}
+}
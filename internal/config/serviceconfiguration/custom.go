@@ -0,0 +1,123 @@
+package serviceconfigloader
+
+import (
+	"regexp"
+	"sync"
+)
+
+// CustomGroupKey is the group custom service types fall into when none is given.
+const CustomGroupKey = "custom"
+
+// CustomServiceType is a service type managed through the admin API and
+// stored in the registry backend rather than in conf.json.
+type CustomServiceType struct {
+	ServiceDef
+	Group     string `json:"group"`
+	Retired   bool   `json:"retired"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// CustomProvider is a provider managed through the admin API.
+type CustomProvider struct {
+	ProviderDef
+	Retired   bool   `json:"retired"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+var (
+	customMu        sync.RWMutex
+	customTypes     = map[string]CustomServiceType{}
+	customProviders = map[string]CustomProvider{}
+)
+
+// Short names end up as service key segments, so they use the same character
+// set the register validator accepts for types and providers.
+var shortNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+func IsValidShortName(short string) bool {
+	return shortNameRegex.MatchString(short)
+}
+
+// SetCustomCatalog replaces the custom service types and providers merged into
+// every catalog lookup.
+func SetCustomCatalog(types []CustomServiceType, providers []CustomProvider) {
+	typeMap := make(map[string]CustomServiceType, len(types))
+	for _, t := range types {
+		typeMap[t.Short] = t
+	}
+	providerMap := make(map[string]CustomProvider, len(providers))
+	for _, p := range providers {
+		providerMap[p.Short] = p
+	}
+
+	customMu.Lock()
+	customTypes = typeMap
+	customProviders = providerMap
+	customMu.Unlock()
+}
+
+func getCustomServiceType(short string) (CustomServiceType, bool) {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	t, ok := customTypes[short]
+	return t, ok
+}
+
+func getCustomProvider(short string) (CustomProvider, bool) {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	p, ok := customProviders[short]
+	return p, ok
+}
+
+func getCustomServiceTypes() []CustomServiceType {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	all := make([]CustomServiceType, 0, len(customTypes))
+	for _, t := range customTypes {
+		all = append(all, t)
+	}
+	return all
+}
+
+func getCustomProviders() []CustomProvider {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	all := make([]CustomProvider, 0, len(customProviders))
+	for _, p := range customProviders {
+		all = append(all, p)
+	}
+	return all
+}
+
+// IsStaticServiceType reports whether short is defined in conf.json.
+func IsStaticServiceType(short string) bool {
+	_, _, ok := getCatalog().findServiceType(short)
+	return ok
+}
+
+// IsStaticProvider reports whether short is defined in conf.json.
+func IsStaticProvider(short string) bool {
+	_, ok := getCatalog().providers[short]
+	return ok
+}
+
+// IsCustomServiceType reports whether short is a runtime-managed service type.
+func IsCustomServiceType(short string) bool {
+	_, ok := getCustomServiceType(short)
+	return ok && !IsStaticServiceType(short)
+}
+
+// IsRetiredServiceType reports whether short is a retired custom service type.
+func IsRetiredServiceType(short string) bool {
+	t, ok := getCustomServiceType(short)
+	return ok && t.Retired && !IsStaticServiceType(short)
+}
+
+// IsRetiredProvider reports whether short is a retired custom provider.
+func IsRetiredProvider(short string) bool {
+	p, ok := getCustomProvider(short)
+	return ok && p.Retired && !IsStaticProvider(short)
+}
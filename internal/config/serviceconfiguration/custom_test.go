@@ -0,0 +1,35 @@
+package serviceconfigloader
+
+import "testing"
+
+func TestRetiredCustomServiceType(t *testing.T) {
+	loadTestCatalog(t)
+	t.Cleanup(func() { SetCustomCatalog(nil, nil) })
+	SetCustomCatalog([]CustomServiceType{
+		{ServiceDef: ServiceDef{Name: "Legacy", Short: "legacy"}, Retired: true},
+		{ServiceDef: ServiceDef{Name: "Billing", Short: "billing"}},
+		// A static type cannot be retired through the custom catalog
+		{ServiceDef: ServiceDef{Name: "Gateway", Short: "gw"}, Retired: true},
+	}, nil)
+
+	tests := []struct {
+		short                 string
+		known, valid, retired bool
+	}{
+		{"legacy", true, false, true},
+		{"billing", true, true, false},
+		{"gw", true, true, false},
+		{"missing", false, false, false},
+	}
+	for _, tt := range tests {
+		if got := IsKnownServiceType(tt.short); got != tt.known {
+			t.Errorf("IsKnownServiceType(%q) = %v, want %v", tt.short, got, tt.known)
+		}
+		if got := IsValidServiceType(tt.short); got != tt.valid {
+			t.Errorf("IsValidServiceType(%q) = %v, want %v", tt.short, got, tt.valid)
+		}
+		if got := IsRetiredServiceType(tt.short); got != tt.retired {
+			t.Errorf("IsRetiredServiceType(%q) = %v, want %v", tt.short, got, tt.retired)
+		}
+	}
+}
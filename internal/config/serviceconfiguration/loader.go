@@ -2,99 +2,372 @@ package serviceconfigloader
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/tahakara/discogo/internal/logger"
+	"gopkg.in/yaml.v3"
 )
 
 type ServiceDef struct {
-	Name        string `json:"name"`
-	Short       string `json:"short"`
-	Description string `json:"description"`
+	Name        string `json:"name" yaml:"name"`
+	Short       string `json:"short" yaml:"short"`
+	Description string `json:"description" yaml:"description"`
 }
 
 type ServiceTypeGroup struct {
-	Name     string       `json:"name"`
-	Services []ServiceDef `json:"services"`
+	Name     string       `json:"name" yaml:"name"`
+	Services []ServiceDef `json:"services" yaml:"services"`
 }
 
 type ServiceTypesConfig map[string]ServiceTypeGroup
 
+type ProviderDef struct {
+	Name  string `json:"name" yaml:"name"`
+	Short string `json:"short" yaml:"short"`
+}
+
+// catalogFile is the on-disk layout of the catalog, shared by JSON and YAML.
+type catalogFile struct {
+	Info struct {
+		Version     string `json:"version" yaml:"version"`
+		Description string `json:"description" yaml:"description"`
+	} `json:"info" yaml:"info"`
+	Providers    map[string]ProviderDef      `json:"providers" yaml:"providers"`
+	ServiceTypes map[string]ServiceTypeGroup `json:"service_types" yaml:"service_types"`
+}
+
+// catalog is the parsed content of the catalog file. It is replaced as a
+// whole on reload and never mutated in place.
+type catalog struct {
+	serviceTypes ServiceTypesConfig
+	providers    map[string]ProviderDef
+}
+
 var (
-	serviceTypes     ServiceTypesConfig
-	serviceTypesOnce sync.Once
-	loadErr          error
+	catalogMu      sync.RWMutex
+	currentCatalog *catalog
+	// catalogPath is the file the catalog was loaded from.
+	catalogPath string
+	// seenModTime is the catalog file modification time of the last load
+	// attempt, so a broken file is reported once rather than on every poll.
+	seenModTime time.Time
 )
 
-func LoadConfig() error {
-	if err := loadServiceTypes(); err != nil {
-		return err
+// CatalogDiff lists the service types and providers added or removed by a reload.
+type CatalogDiff struct {
+	AddedTypes       []string `json:"addedTypes"`
+	RemovedTypes     []string `json:"removedTypes"`
+	AddedProviders   []string `json:"addedProviders"`
+	RemovedProviders []string `json:"removedProviders"`
+}
+
+func (d CatalogDiff) IsEmpty() bool {
+	return len(d.AddedTypes) == 0 && len(d.RemovedTypes) == 0 && len(d.AddedProviders) == 0 && len(d.RemovedProviders) == 0
+}
+
+func (d CatalogDiff) String() string {
+	if d.IsEmpty() {
+		return "no type or provider changes"
 	}
-	return nil
+	return fmt.Sprintf("types +[%s] -[%s], providers +[%s] -[%s]",
+		strings.Join(d.AddedTypes, ","), strings.Join(d.RemovedTypes, ","),
+		strings.Join(d.AddedProviders, ","), strings.Join(d.RemovedProviders, ","))
 }
 
-// conf.json dosyasından service_types'ı yükler
-func loadServiceTypes() error {
-	startTime := time.Now()
-	serviceTypesOnce.Do(func() {
-		f, err := os.Open("conf.json")
-		if err != nil {
-			loadErr = err
-			return
+// CatalogValidationError lists every problem found in a catalog file.
+type CatalogValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *CatalogValidationError) Error() string {
+	return fmt.Sprintf("invalid catalog %s:\n  - %s", e.Path, strings.Join(e.Problems, "\n  - "))
+}
+
+func isYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Katalog dosyasını (JSON veya YAML) tek seferde okur ve doğrular
+func parseCatalog(path string) (*catalog, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var raw catalogFile
+	if isYAMLPath(path) {
+		decoder := yaml.NewDecoder(f)
+		decoder.KnownFields(true)
+		err = decoder.Decode(&raw)
+	} else {
+		decoder := json.NewDecoder(f)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&raw)
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("parse catalog %s: %w", path, err)
+	}
+
+	c := &catalog{
+		serviceTypes: raw.ServiceTypes,
+		providers:    raw.Providers,
+	}
+	if problems := c.validate(); len(problems) > 0 {
+		return nil, time.Time{}, &CatalogValidationError{Path: path, Problems: problems}
+	}
+	return c, info.ModTime(), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validate returns every problem in the catalog: missing sections, empty
+// names, short names with invalid characters and duplicate shorts.
+func (c *catalog) validate() []string {
+	var problems []string
+	if len(c.serviceTypes) == 0 {
+		problems = append(problems, "service_types: at least one group is required")
+	}
+	if len(c.providers) == 0 {
+		problems = append(problems, "providers: at least one provider is required")
+	}
+
+	seenTypes := map[string]string{}
+	for _, groupKey := range sortedKeys(c.serviceTypes) {
+		group := c.serviceTypes[groupKey]
+		if strings.TrimSpace(group.Name) == "" {
+			problems = append(problems, fmt.Sprintf("service_types.%s: name is empty", groupKey))
+		}
+		if len(group.Services) == 0 {
+			problems = append(problems, fmt.Sprintf("service_types.%s: no services", groupKey))
+		}
+		for i, svc := range group.Services {
+			field := fmt.Sprintf("service_types.%s.services[%d]", groupKey, i)
+			if strings.TrimSpace(svc.Name) == "" {
+				problems = append(problems, field+": name is empty")
+			}
+			switch {
+			case svc.Short == "":
+				problems = append(problems, field+": short is empty")
+			case !IsValidShortName(svc.Short):
+				problems = append(problems, fmt.Sprintf("%s: short %q may only contain letters, digits, '-' and '_' (max 32)", field, svc.Short))
+			case seenTypes[svc.Short] != "":
+				problems = append(problems, fmt.Sprintf("%s: duplicate short %q, already used by %s", field, svc.Short, seenTypes[svc.Short]))
+			default:
+				seenTypes[svc.Short] = field
+			}
 		}
-		defer f.Close()
+	}
 
-		var raw struct {
-			ServiceTypes map[string]ServiceTypeGroup `json:"service_types"`
+	seenProviders := map[string]string{}
+	for _, key := range sortedKeys(c.providers) {
+		provider := c.providers[key]
+		field := "providers." + key
+		if strings.TrimSpace(provider.Name) == "" {
+			problems = append(problems, field+": name is empty")
 		}
-		if err := json.NewDecoder(f).Decode(&raw); err != nil {
-			loadErr = err
-			return
+		switch {
+		case provider.Short == "":
+			problems = append(problems, field+": short is empty")
+		case !IsValidShortName(provider.Short):
+			problems = append(problems, fmt.Sprintf("%s: short %q may only contain letters, digits, '-' and '_' (max 32)", field, provider.Short))
+		case provider.Short != key:
+			problems = append(problems, fmt.Sprintf("%s: short %q must match its key", field, provider.Short))
+		case seenProviders[provider.Short] != "":
+			problems = append(problems, fmt.Sprintf("%s: duplicate short %q, already used by %s", field, provider.Short, seenProviders[provider.Short]))
+		default:
+			seenProviders[provider.Short] = field
 		}
+	}
+	return problems
+}
 
-		serviceTypes = raw.ServiceTypes
-		if b, err := json.Marshal(serviceTypes); err == nil {
-			logger.Info(string(b), time.Since(startTime))
-		} else {
-			logger.Info("Failed to marshal serviceTypes: ", time.Since(startTime))
+func (c *catalog) typeShorts() map[string]bool {
+	shorts := map[string]bool{}
+	for _, group := range c.serviceTypes {
+		for _, svc := range group.Services {
+			shorts[svc.Short] = true
 		}
-	})
-	return loadErr
+	}
+	return shorts
 }
 
-// shortname ile tam isim ve açıklama döndürür (tüm gruplardaki servislerde arar)
-func GetServiceTypeInfo(short string) (string, string, bool) {
-	if err := loadServiceTypes(); err != nil {
-		return "", "", false
+func (c *catalog) providerShorts() map[string]bool {
+	shorts := map[string]bool{}
+	for _, provider := range c.providers {
+		shorts[provider.Short] = true
+	}
+	return shorts
+}
+
+func diffSets(oldSet, newSet map[string]bool) (added []string, removed []string) {
+	added, removed = []string{}, []string{}
+	for k := range newSet {
+		if !oldSet[k] {
+			added = append(added, k)
+		}
 	}
-	for _, group := range serviceTypes {
+	for k := range oldSet {
+		if !newSet[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func diffCatalogs(oldCatalog, newCatalog *catalog) CatalogDiff {
+	var d CatalogDiff
+	d.AddedTypes, d.RemovedTypes = diffSets(oldCatalog.typeShorts(), newCatalog.typeShorts())
+	d.AddedProviders, d.RemovedProviders = diffSets(oldCatalog.providerShorts(), newCatalog.providerShorts())
+	return d
+}
+
+// getCatalog returns the active catalog, or an empty one before LoadAllConfigs.
+func getCatalog() *catalog {
+	catalogMu.RLock()
+	c := currentCatalog
+	catalogMu.RUnlock()
+	if c != nil {
+		return c
+	}
+	return &catalog{}
+}
+
+func getCatalogPath() string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return catalogPath
+}
+
+func LoadConfig(path string) error {
+	return LoadAllConfigs(path)
+}
+
+// Ortak yükleme fonksiyonu, path sonraki yeniden yüklemeler için saklanır
+func LoadAllConfigs(path string) error {
+	startTime := time.Now()
+	c, modTime, err := parseCatalog(path)
+	if err != nil {
+		return err
+	}
+
+	catalogMu.Lock()
+	catalogPath = path
+	currentCatalog = c
+	seenModTime = modTime
+	catalogMu.Unlock()
+
+	if b, err := json.Marshal(c.serviceTypes); err == nil {
+		logger.Info(string(b), time.Since(startTime))
+	} else {
+		logger.Info("Failed to marshal serviceTypes: ", time.Since(startTime))
+	}
+	return nil
+}
+
+// ReloadConfigs re-reads the catalog file and atomically swaps in the new catalog.
+// On parse or validation errors the current catalog is kept.
+func ReloadConfigs() (CatalogDiff, error) {
+	startTime := time.Now()
+	path := getCatalogPath()
+	c, modTime, err := parseCatalog(path)
+	if err != nil {
+		if info, statErr := os.Stat(path); statErr == nil {
+			catalogMu.Lock()
+			seenModTime = info.ModTime()
+			catalogMu.Unlock()
+		}
+		logger.Error(fmt.Sprintf("Catalog reload failed, keeping current catalog: %v", err), time.Since(startTime))
+		return CatalogDiff{}, err
+	}
+
+	catalogMu.Lock()
+	oldCatalog := currentCatalog
+	currentCatalog = c
+	seenModTime = modTime
+	catalogMu.Unlock()
+
+	diff := CatalogDiff{}
+	if oldCatalog != nil {
+		diff = diffCatalogs(oldCatalog, c)
+	}
+	logger.Info(fmt.Sprintf("Catalog reloaded: %s", diff), time.Since(startTime))
+	return diff, nil
+}
+
+// HasConfigChanged reports whether the catalog file was modified since it was last read.
+func HasConfigChanged() bool {
+	info, err := os.Stat(getCatalogPath())
+	if err != nil {
+		return false
+	}
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return !info.ModTime().Equal(seenModTime)
+}
+
+func (c *catalog) findServiceType(short string) (string, ServiceDef, bool) {
+	for key, group := range c.serviceTypes {
 		for _, svc := range group.Services {
 			if svc.Short == short {
-				return svc.Name, svc.Description, true
+				return key, svc, true
 			}
 		}
 	}
+	return "", ServiceDef{}, false
+}
+
+// shortname ile tam isim ve açıklama döndürür (tüm gruplardaki servislerde arar)
+func GetServiceTypeInfo(short string) (string, string, bool) {
+	if _, svc, ok := getCatalog().findServiceType(short); ok {
+		return svc.Name, svc.Description, true
+	}
+	if t, ok := getCustomServiceType(short); ok {
+		return t.Name, t.Description, true
+	}
 	return "", "", false
 }
 
-// ServiceType'ın geçerliliğini kontrol eden fonksiyon
+// ServiceType'ın kayıt için geçerliliğini kontrol eden fonksiyon (emekli tipler hariç)
 func IsValidServiceType(short string) bool {
-	for _, group := range serviceTypes {
-		for _, svc := range group.Services {
-			if svc.Short == short {
-				return true
-			}
-		}
+	return IsKnownServiceType(short) && !IsRetiredServiceType(short)
+}
+
+// ServiceType conf.json'da veya özel tiplerde tanımlı mı (emekli tipler dahil)
+func IsKnownServiceType(short string) bool {
+	if _, _, ok := getCatalog().findServiceType(short); ok {
+		return true
 	}
-	return false
+	_, ok := getCustomServiceType(short)
+	return ok
 }
 
 // Tüm grupları ve servisleri döndürür
 func GetAllServiceTypes() []string {
 	var all []string
-	for _, group := range serviceTypes {
+	for _, group := range GetServiceTypeGroups() {
 		for _, svc := range group.Services {
 			all = append(all, svc.Short)
 		}
@@ -104,21 +377,12 @@ func GetAllServiceTypes() []string {
 
 // Tüm servislerin kısa isimlerini döndürür
 func GetAllServiceShortNames() []string {
-	if err := loadServiceTypes(); err != nil {
-		return nil
-	}
-	var shorts []string
-	for _, group := range serviceTypes {
-		for _, svc := range group.Services {
-			shorts = append(shorts, svc.Short)
-		}
-	}
-	return shorts
+	return GetAllServiceTypes()
 }
 
 // Bir grup adı ile o gruptaki tüm servisleri döndürür
 func GetServicesByGroup(groupName string) []ServiceDef {
-	group, ok := serviceTypes[groupName]
+	group, ok := GetServiceTypeGroups()[groupName]
 	if !ok {
 		return nil
 	}
@@ -127,61 +391,73 @@ func GetServicesByGroup(groupName string) []ServiceDef {
 
 func GetAllProviders() []string {
 	var all []string
-	for _, provider := range providers {
+	for _, provider := range GetProviderDefs() {
 		all = append(all, provider.Short)
 	}
 	return all
 }
 
-type ProviderDef struct {
-	Name  string `json:"name"`
-	Short string `json:"short"`
+// Provider'ın kayıt için geçerliliğini kontrol eden fonksiyon (emekli provider'lar hariç)
+func IsValidProvider(short string) bool {
+	return IsKnownProvider(short) && !IsRetiredProvider(short)
 }
 
-var (
-	providers     map[string]ProviderDef
-	providersOnce sync.Once
-	providerErr   error
-)
+// Provider conf.json'da veya özel provider'larda tanımlı mı (emekli olanlar dahil)
+func IsKnownProvider(short string) bool {
+	if _, ok := getCatalog().providers[short]; ok {
+		return true
+	}
+	_, ok := getCustomProvider(short)
+	return ok
+}
 
-// conf.json dosyasından providers'ı yükler
-func loadProviders() error {
-	providersOnce.Do(func() {
-		f, err := os.Open("conf.json")
-		if err != nil {
-			providerErr = err
-			return
+// Tüm grupları servis tanımlarıyla birlikte döndürür, özel tipler kendi gruplarına eklenir
+func GetServiceTypeGroups() ServiceTypesConfig {
+	c := getCatalog()
+	groups := ServiceTypesConfig{}
+	for key, group := range c.serviceTypes {
+		groups[key] = ServiceTypeGroup{
+			Name:     group.Name,
+			Services: append([]ServiceDef(nil), group.Services...),
 		}
-		defer f.Close()
-
-		var raw struct {
-			Providers map[string]ProviderDef `json:"providers"`
+	}
+	for _, t := range getCustomServiceTypes() {
+		if _, _, ok := c.findServiceType(t.Short); ok {
+			continue
 		}
-		if err := json.NewDecoder(f).Decode(&raw); err != nil {
-			providerErr = err
-			return
+		group, ok := groups[t.Group]
+		if !ok {
+			group = ServiceTypeGroup{Name: t.Group}
 		}
-		providers = raw.Providers
-	})
-	return providerErr
+		group.Services = append(group.Services, t.ServiceDef)
+		groups[t.Group] = group
+	}
+	return groups
 }
 
-// Provider'ın geçerliliğini kontrol eden fonksiyon
-func IsValidProvider(short string) bool {
-	_, ok := providers[short]
-	return ok
+// shortname'in ait olduğu grubun anahtarını döndürür
+func GetServiceTypeGroupKey(short string) (string, bool) {
+	if key, _, ok := getCatalog().findServiceType(short); ok {
+		return key, true
+	}
+	if t, ok := getCustomServiceType(short); ok {
+		return t.Group, true
+	}
+	return "", false
 }
 
-// Ortak yükleme fonksiyonu
-func LoadAllConfigs() error {
-	var err1, err2 error
-	err1 = loadServiceTypes()
-	err2 = loadProviders()
-	if err1 != nil {
-		return err1
+// Tüm provider tanımlarını döndürür
+func GetProviderDefs() []ProviderDef {
+	c := getCatalog()
+	var all []ProviderDef
+	for _, provider := range c.providers {
+		all = append(all, provider)
 	}
-	if err2 != nil {
-		return err2
+	for _, provider := range getCustomProviders() {
+		if _, ok := c.providers[provider.Short]; ok {
+			continue
+		}
+		all = append(all, provider.ProviderDef)
 	}
-	return nil
+	return all
 }
@@ -0,0 +1,119 @@
+package serviceconfigloader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testCatalog = `{
+	"providers": {"aws": {"name": "Amazon Web Services", "short": "aws"}},
+	"service_types": {"api": {"name": "API Services", "services": [
+		{"name": "API Gateway", "short": "gw"},
+		{"name": "REST API", "short": "rest"}
+	]}}
+}`
+
+// catalogWrites gives every catalog written by a test its own modification
+// time, so HasConfigChanged never misses a rewrite within the same tick.
+var catalogWrites int
+
+func writeCatalog(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	catalogWrites++
+	modTime := time.Now().Add(time.Duration(catalogWrites) * time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadTestCatalog loads testCatalog from a temporary file.
+func loadTestCatalog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "conf.json")
+	writeCatalog(t, path, testCatalog)
+	if err := LoadAllConfigs(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReloadKeepsCatalogOnError(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unparsable", `{"providers": {`},
+		{"unknown field", `{"providers": {}, "service_types": {}, "extra": 1}`},
+		{"invalid", `{"providers": {"aws": {"name": "", "short": "aws"}}, "service_types": {"api": {"name": "API", "services": [{"name": "Gateway", "short": "g w"}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := loadTestCatalog(t)
+			writeCatalog(t, path, tt.content)
+			if !HasConfigChanged() {
+				t.Fatal("the rewritten catalog is not reported as changed")
+			}
+
+			if _, err := ReloadConfigs(); err == nil {
+				t.Fatal("reload of a broken catalog succeeded")
+			}
+			if !IsValidServiceType("gw") || !IsValidProvider("aws") {
+				t.Fatal("a failed reload dropped the current catalog")
+			}
+			// The broken file is reported once, not on every poll
+			if HasConfigChanged() {
+				t.Fatal("a failed reload is reported as a change again")
+			}
+		})
+	}
+
+	var validationErr *CatalogValidationError
+	path := loadTestCatalog(t)
+	writeCatalog(t, path, `{"providers": {"aws": {"name": "AWS", "short": "amazon"}}, "service_types": {"api": {"name": "API", "services": [{"name": "", "short": "gw"}]}}}`)
+	if _, err := ReloadConfigs(); !errors.As(err, &validationErr) || len(validationErr.Problems) != 2 {
+		t.Fatalf("reload error = %v, want a CatalogValidationError listing both problems", err)
+	}
+}
+
+func TestReloadReportsDiff(t *testing.T) {
+	path := loadTestCatalog(t)
+	writeCatalog(t, path, `{
+		"providers": {
+			"aws": {"name": "Amazon Web Services", "short": "aws"},
+			"gcp": {"name": "Google Cloud", "short": "gcp"}
+		},
+		"service_types": {"api": {"name": "API Services", "services": [
+			{"name": "API Gateway", "short": "gw"},
+			{"name": "GraphQL API", "short": "graphql"},
+			{"name": "WebSocket", "short": "ws"}
+		]}}
+	}`)
+
+	diff, err := ReloadConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CatalogDiff{
+		AddedTypes:       []string{"graphql", "ws"},
+		RemovedTypes:     []string{"rest"},
+		AddedProviders:   []string{"gcp"},
+		RemovedProviders: []string{},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("diff = %+v, want %+v", diff, want)
+	}
+	if IsKnownServiceType("rest") || !IsValidServiceType("ws") {
+		t.Fatal("the reloaded catalog is not active")
+	}
+
+	if diff, err := ReloadConfigs(); err != nil || !diff.IsEmpty() {
+		t.Fatalf("reload of an unchanged catalog = %+v, %v, want an empty diff", diff, err)
+	}
+}
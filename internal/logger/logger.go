@@ -6,10 +6,16 @@ import (
 	"path/filepath"
 	"runtime"
 	"time"
-
-	env "github.com/tahakara/discogo/internal/config"
 )
 
+// colorEnabled toggles ANSI colored level names. It is set once at startup.
+var colorEnabled bool
+
+// SetColorEnabled enables or disables colored level names.
+func SetColorEnabled(enabled bool) {
+	colorEnabled = enabled
+}
+
 const (
 	red          = "\033[31m"
 	green        = "\033[32m"
@@ -66,13 +72,17 @@ func Discovery(message string, elapsedTime time.Duration, showLocation ...bool)
 	log("DISCOVERY", message, elapsedTime, showLocation...)
 }
 
+func Audit(message string, elapsedTime time.Duration, showLocation ...bool) {
+	log("AUDIT", message, elapsedTime, showLocation...)
+}
+
 func log(level, message string, elapsedTime time.Duration, showLocation ...bool) {
 	showLoc := false
 	if len(showLocation) > 0 {
 		showLoc = showLocation[0]
 	}
 	now := time.Now().Format("2006-01-02 15:04:05")
-	useColor := env.IsColorEnabled()
+	useColor := colorEnabled
 
 	levelStr := level
 	switch level {
@@ -112,6 +122,10 @@ func log(level, message string, elapsedTime time.Duration, showLocation ...bool)
 		if useColor {
 			levelStr = fmt.Sprintf("%sDISCOVERY%s", lightMagenta, reset)
 		}
+	case "AUDIT":
+		if useColor {
+			levelStr = fmt.Sprintf("%sAUDIT%s", lightRed, reset)
+		}
 	}
 
 	location := ""
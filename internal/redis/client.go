@@ -2,6 +2,9 @@ package redisclient
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,7 +15,12 @@ type Client interface {
 	Get(key string) ([]byte, error)
 	Set(key string, value []byte, expiration time.Duration) error
 	Delete(key string) error
-	Add(key string, value []byte, expiration time.Duration) error
+	// CompareAndDelete deletes key only while it holds value, in one atomic
+	// step, and reports whether it was deleted. Locks are released with it.
+	CompareAndDelete(key string, value []byte) (bool, error)
+	// Add stores key only if it does not exist yet (SETNX) and reports
+	// whether it was stored.
+	Add(key string, value []byte, expiration time.Duration) (bool, error)
 	Replace(key string, value []byte, expiration time.Duration) error
 	Increment(key string, delta int64) (int64, error)
 	Decrement(key string, delta int64) (int64, error)
@@ -20,11 +28,51 @@ type Client interface {
 	Ping() error
 	Close() error
 	FindKeys(pattern string) ([]string, error)
+	// TTL returns the remaining time to live of a key. It returns 0 when the
+	// key has no expiry and a negative duration when the key does not exist.
+	TTL(key string) (time.Duration, error)
+	// StreamAdd appends values to an append-only stream and drops entries
+	// older than maxAge (no trimming when maxAge is 0). It returns the entry ID.
+	StreamAdd(stream string, values map[string]string, maxAge time.Duration) (string, error)
+	// StreamRange returns up to count entries between the start and end IDs
+	// (inclusive). IDs follow the Redis "<ms>-<seq>" format, "-" and "+" are
+	// the minimum and maximum, and a "(" prefix makes a bound exclusive.
+	StreamRange(stream string, start string, end string, count int64) ([]StreamEntry, error)
+	// SubscribeExpired calls handler with the name of every key that expires.
+	SubscribeExpired(handler func(key string)) error
+	// Pipeline returns a batch of commands sent in a single round trip.
+	Pipeline() Pipeline
+}
+
+// Pipeline queues commands and sends them to the backend together on Exec.
+type Pipeline interface {
+	Get(key string)
+	Set(key string, value []byte, expiration time.Duration)
+	Delete(key string)
+	// Exec sends the queued commands and returns one result per command in
+	// queue order. The error is set when the batch could not be sent at all.
+	Exec() ([]PipelineResult, error)
+}
+
+// PipelineResult is the outcome of a queued command. Value holds the result
+// of a Get and is nil when the key does not exist.
+type PipelineResult struct {
+	Value []byte
+	Err   error
+}
+
+// StreamEntry is a single entry of an append-only stream.
+type StreamEntry struct {
+	ID     string
+	Values map[string]string
 }
 
 type client struct {
 	rdb *redis.Client
 	ctx context.Context
+	db  int
+
+	pubsubs []*redis.PubSub
 }
 
 // New creates a new Redis client.
@@ -38,6 +86,7 @@ func New(addr string, password string, db int) Client {
 	return &client{
 		rdb: rdb,
 		ctx: context.Background(),
+		db:  db,
 	}
 }
 
@@ -57,9 +106,25 @@ func (c *client) Delete(key string) error {
 	return c.rdb.Del(c.ctx, key).Err()
 }
 
-func (c *client) Add(key string, value []byte, expiration time.Duration) error {
+// compareAndDeleteScript runs GET and DEL as one step on the server.
+var compareAndDeleteScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (c *client) CompareAndDelete(key string, value []byte) (bool, error) {
+	n, err := compareAndDeleteScript.Run(c.ctx, c.rdb, []string{key}, value).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (c *client) Add(key string, value []byte, expiration time.Duration) (bool, error) {
 	// NX: Only set the key if it does not already exist
-	return c.rdb.SetNX(c.ctx, key, value, expiration).Err()
+	return c.rdb.SetNX(c.ctx, key, value, expiration).Result()
 }
 
 func (c *client) Replace(key string, value []byte, expiration time.Duration) error {
@@ -84,6 +149,9 @@ func (c *client) Ping() error {
 }
 
 func (c *client) Close() error {
+	for _, pubsub := range c.pubsubs {
+		pubsub.Close()
+	}
 	return c.rdb.Close()
 }
 
@@ -107,3 +175,81 @@ func (c *client) FindKeys(pattern string) ([]string, error) {
 	}
 	return keys, nil
 }
+
+func (c *client) TTL(key string) (time.Duration, error) {
+	ttl, err := c.rdb.TTL(c.ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch ttl {
+	case -1: // key exists but has no associated expire
+		return 0, nil
+	case -2: // key does not exist
+		return -1, nil
+	}
+	return ttl, nil
+}
+
+func (c *client) StreamAdd(stream string, values map[string]string, maxAge time.Duration) (string, error) {
+	args := &redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}
+	if maxAge > 0 {
+		// Approximate trimming (~) lets Redis drop whole macro nodes cheaply
+		args.MinID = strconv.FormatInt(time.Now().Add(-maxAge).UnixMilli(), 10)
+		args.Approx = true
+	}
+	return c.rdb.XAdd(c.ctx, args).Result()
+}
+
+func (c *client) StreamRange(stream string, start string, end string, count int64) ([]StreamEntry, error) {
+	var (
+		messages []redis.XMessage
+		err      error
+	)
+	if count > 0 {
+		messages, err = c.rdb.XRangeN(c.ctx, stream, start, end, count).Result()
+	} else {
+		messages, err = c.rdb.XRange(c.ctx, stream, start, end).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]StreamEntry, 0, len(messages))
+	for _, msg := range messages {
+		values := make(map[string]string, len(msg.Values))
+		for k, v := range msg.Values {
+			values[k] = fmt.Sprint(v)
+		}
+		entries = append(entries, StreamEntry{ID: msg.ID, Values: values})
+	}
+	return entries, nil
+}
+
+// SubscribeExpired relies on keyspace notifications. They are enabled on a
+// best-effort basis since managed Redis services may reject CONFIG SET.
+func (c *client) SubscribeExpired(handler func(key string)) error {
+	if current, err := c.rdb.ConfigGet(c.ctx, "notify-keyspace-events").Result(); err == nil {
+		flags := current["notify-keyspace-events"]
+		hasExpired := strings.Contains(flags, "x") || strings.Contains(flags, "A")
+		if !strings.Contains(flags, "E") || !hasExpired {
+			c.rdb.ConfigSet(c.ctx, "notify-keyspace-events", flags+"Ex")
+		}
+	}
+
+	pubsub := c.rdb.PSubscribe(c.ctx, fmt.Sprintf("__keyevent@%d__:expired", c.db))
+	if _, err := pubsub.Receive(c.ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+	c.pubsubs = append(c.pubsubs, pubsub)
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			handler(msg.Payload)
+		}
+	}()
+	return nil
+}
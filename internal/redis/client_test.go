@@ -0,0 +1,41 @@
+package redisclient_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/redis/redistest"
+)
+
+func TestConformance(t *testing.T) {
+	servers := map[redisclient.Client]*miniredis.Miniredis{}
+	redistest.Run(t, redistest.Backend{
+		New: func(t *testing.T) redisclient.Client {
+			server := miniredis.RunT(t)
+			c := redisclient.New(server.Addr(), "", 0)
+			t.Cleanup(func() { c.Close() })
+			servers[c] = server
+			return c
+		},
+		Reopen: func(t *testing.T, c redisclient.Client) redisclient.Client {
+			server := servers[c]
+			c.Close()
+			// miniredis keeps its data across a restart like a persistent Redis
+			server.Close()
+			if err := server.Restart(); err != nil {
+				t.Fatalf("restart: %v", err)
+			}
+			reopened := redisclient.New(server.Addr(), "", 0)
+			t.Cleanup(func() { reopened.Close() })
+			servers[reopened] = server
+			return reopened
+		},
+		Elapse: func(t *testing.T, c redisclient.Client, d time.Duration) {
+			servers[c].FastForward(d)
+		},
+		// miniredis does not send keyspace notifications
+		ExpiryEvents: false,
+	})
+}
@@ -0,0 +1,86 @@
+package redishelper
+
+import (
+	"errors"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrServiceSuspicious is returned for heartbeats of a service that has
+	// collected more reports than tolerated.
+	ErrServiceSuspicious = errors.New("service entry is suspicious")
+)
+
+// GetServiceEntryWithTTL returns the full entry of serviceUUID together with
+// its remaining time to live.
+func GetServiceEntryWithTTL(client redisclient.Client, serviceUUID string) (bool, ServiceEntry, time.Duration) {
+	exists, entry := IsServiceExistsByUUID(client, serviceUUID)
+	if !exists {
+		return false, ServiceEntry{}, 0
+	}
+	ttl, err := client.TTL(_GenerateServiceKey(entry))
+	if err != nil || ttl < 0 {
+		return false, ServiceEntry{}, 0
+	}
+	return true, entry, ttl
+}
+
+// ListServicesFiltered works like GetServicesFiltered but spans every service
+// type when serviceType is empty and includes ejected instances.
+func ListServicesFiltered(rclient redisclient.Client, serviceType string, healthStatus ServiceStatus, provider string, region string, zone string, networkID string, subnetID string, instanceID string, version string, pageSize int, pageOffset int) ([]ServiceEntry, error) {
+	return _getServicesFiltered(rclient, serviceType, healthStatus, provider, region, zone, networkID, subnetID, instanceID, version, pageSize, pageOffset, false)
+}
+
+// ServiceEntryOverride describes an operator change to a service entry.
+// Nil / false fields are left untouched.
+type ServiceEntryOverride struct {
+	Status           *ServiceStatus
+	ResetReportCount bool
+	// Reason is recorded in the status history when the status changes.
+	Reason string
+}
+
+// OverrideServiceEntry applies an admin override to serviceUUID while keeping
+// its remaining TTL. It returns the entry before and after the change.
+func OverrideServiceEntry(client redisclient.Client, serviceUUID string, override ServiceEntryOverride) (ServiceEntry, ServiceEntry, error) {
+	var oldEntry ServiceEntry
+	newEntry, err := modifyServiceEntry(client, serviceUUID, 0, func(e *ServiceEntry) error {
+		oldEntry = *e
+		// Copy the history so oldEntry keeps its own
+		e.StatusHistory = append([]StatusTransition(nil), e.StatusHistory...)
+		if override.Status != nil {
+			e.transition(*override.Status, TriggerAdmin, override.Reason)
+		}
+		if override.ResetReportCount {
+			e.ReportCount = 0
+			e.Reports = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return ServiceEntry{}, ServiceEntry{}, err
+	}
+	return oldEntry, newEntry, nil
+}
+
+// EvictServiceEntry removes serviceUUID regardless of its status and returns
+// the evicted entry.
+func EvictServiceEntry(client redisclient.Client, serviceUUID string) (ServiceEntry, error) {
+	release, err := lockServiceEntry(client, serviceUUID)
+	if err != nil {
+		return ServiceEntry{}, err
+	}
+	defer release()
+
+	exists, entry := IsServiceExistsByUUID(client, serviceUUID)
+	if !exists {
+		return ServiceEntry{}, ErrServiceNotFound
+	}
+	if err := client.Delete(_GenerateServiceKey(entry)); err != nil {
+		return ServiceEntry{}, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,204 @@
+package redishelper
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+const auditStreamKey = "discogo:audit"
+
+// auditPageSize is how many stream entries are read per round trip while
+// filtering audit events.
+const auditPageSize = 500
+
+// auditMaxScanned bounds how many stream entries one query reads, so a UUID
+// filter over a long stream cannot turn into a full scan.
+const auditMaxScanned = 20 * auditPageSize
+
+// Expiry claims are taken with SETNX on the expired key, so each expiry is
+// audited by exactly one of the replicas that receive its event.
+const (
+	expiryClaimKeyPrefix = "discogo:lock:expire:"
+	expiryClaimTTL       = time.Minute
+)
+
+type AuditAction string
+
+const (
+	AuditRegister       AuditAction = "register"
+	AuditStatusChange   AuditAction = "status-change"
+	AuditReport         AuditAction = "report"
+	AuditOutlierEject   AuditAction = "outlier-eject"
+	AuditDeregister     AuditAction = "deregister"
+	AuditExpire         AuditAction = "expire"
+	AuditAdminOverride  AuditAction = "admin-override"
+	AuditAdminEvict     AuditAction = "admin-evict"
+	AuditSnapshotImport AuditAction = "snapshot-import"
+	AuditCatalogCreate  AuditAction = "catalog-create"
+	AuditCatalogUpdate  AuditAction = "catalog-update"
+	AuditCatalogRetire  AuditAction = "catalog-retire"
+)
+
+// auditRetention is how long audit events are kept; 0 keeps them forever.
+var auditRetention time.Duration
+
+// SetAuditRetention sets how long audit events are kept. It is called once at
+// startup from the loaded configuration.
+func SetAuditRetention(retention time.Duration) {
+	auditRetention = retention
+}
+
+// AuditActorSystem is used for events DiscoGo triggers on its own, e.g. expiry.
+const AuditActorSystem = "discogo"
+
+type AuditEvent struct {
+	ID          string        `json:"id"`
+	Time        string        `json:"time"`
+	Action      AuditAction   `json:"action"`
+	ServiceUUID string        `json:"serviceUUID,omitempty"`
+	ServiceType string        `json:"serviceType,omitempty"`
+	Actor       string        `json:"actor"`
+	SourceIP    string        `json:"sourceIP,omitempty"`
+	OldStatus   ServiceStatus `json:"oldStatus,omitempty"`
+	NewStatus   ServiceStatus `json:"newStatus,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+}
+
+// StatusChange describes the status of an entry before and after an update.
+// Trigger and Reason are set when the status changed.
+type StatusChange struct {
+	From    ServiceStatus
+	To      ServiceStatus
+	Trigger StatusTrigger
+	Reason  string
+}
+
+func (c StatusChange) Changed() bool {
+	return c.From != c.To
+}
+
+// AuditReason describes the change for an audit event.
+func (c StatusChange) AuditReason() string {
+	if c.Reason == "" {
+		return string(c.Trigger)
+	}
+	return string(c.Trigger) + ": " + c.Reason
+}
+
+func (e AuditEvent) values() map[string]string {
+	return map[string]string{
+		"time":        e.Time,
+		"action":      string(e.Action),
+		"serviceUUID": e.ServiceUUID,
+		"serviceType": e.ServiceType,
+		"actor":       e.Actor,
+		"sourceIP":    e.SourceIP,
+		"oldStatus":   string(e.OldStatus),
+		"newStatus":   string(e.NewStatus),
+		"reason":      e.Reason,
+	}
+}
+
+func auditEventFromStream(entry redisclient.StreamEntry) AuditEvent {
+	v := entry.Values
+	return AuditEvent{
+		ID:          entry.ID,
+		Time:        v["time"],
+		Action:      AuditAction(v["action"]),
+		ServiceUUID: v["serviceUUID"],
+		ServiceType: v["serviceType"],
+		Actor:       v["actor"],
+		SourceIP:    v["sourceIP"],
+		OldStatus:   ServiceStatus(v["oldStatus"]),
+		NewStatus:   ServiceStatus(v["newStatus"]),
+		Reason:      v["reason"],
+	}
+}
+
+// RecordAuditEvent appends event to the audit stream and mirrors it to the log.
+// Failures are logged but never fail the mutation being audited.
+func RecordAuditEvent(client redisclient.Client, event AuditEvent) {
+	startTime := time.Now()
+	if event.Time == "" {
+		event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+
+	logger.Audit(fmt.Sprintf("actor=%s ip=%s action=%s uuid=%s type=%s status=%s->%s reason=%q",
+		event.Actor, event.SourceIP, event.Action, event.ServiceUUID, event.ServiceType, event.OldStatus, event.NewStatus, event.Reason), 0)
+
+	if _, err := client.StreamAdd(auditStreamKey, event.values(), auditRetention); err != nil {
+		logger.Error(fmt.Sprintf("Failed to append audit event: %v", err), time.Since(startTime))
+	}
+}
+
+// QueryAuditEvents returns up to limit events between from and to (zero
+// values leave the range open), optionally restricted to one service UUID.
+// At most auditMaxScanned events of the range are read, oldest first; a
+// filtered query over a longer range may miss later matches.
+func QueryAuditEvents(client redisclient.Client, from time.Time, to time.Time, serviceUUID string, limit int) ([]AuditEvent, error) {
+	start, end := "-", "+"
+	if !from.IsZero() {
+		start = strconv.FormatInt(from.UnixMilli(), 10)
+	}
+	if !to.IsZero() {
+		end = strconv.FormatInt(to.UnixMilli(), 10)
+	}
+
+	events := []AuditEvent{}
+	for scanned := 0; len(events) < limit && scanned < auditMaxScanned; {
+		page, err := client.StreamRange(auditStreamKey, start, end, auditPageSize)
+		if err != nil {
+			return nil, err
+		}
+		scanned += len(page)
+		for _, entry := range page {
+			event := auditEventFromStream(entry)
+			if serviceUUID != "" && event.ServiceUUID != serviceUUID {
+				continue
+			}
+			events = append(events, event)
+			if len(events) >= limit {
+				break
+			}
+		}
+		if len(page) < auditPageSize {
+			break
+		}
+		start = "(" + page[len(page)-1].ID
+	}
+	return events, nil
+}
+
+// WatchExpiredServices records an audit event for every service entry whose
+// TTL runs out. Every replica receives the expiry; the one that claims it
+// records the event.
+func WatchExpiredServices(client redisclient.Client) error {
+	replicaID := uuid.New().String()
+	return client.SubscribeExpired(func(key string) {
+		parts, ok := parseServiceKey(key)
+		if !ok {
+			return
+		}
+		claimed, err := client.Add(expiryClaimKeyPrefix+key, []byte(replicaID), expiryClaimTTL)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to claim expiry of %s: %v", key, err), 0)
+			return
+		}
+		if !claimed {
+			return
+		}
+		RecordAuditEvent(client, AuditEvent{
+			Action:      AuditExpire,
+			ServiceUUID: parts[0],
+			ServiceType: parts[2],
+			Actor:       AuditActorSystem,
+			OldStatus:   ServiceStatus(parts[3]),
+			Reason:      "ttl expired",
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package redishelper
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	boltclient "github.com/tahakara/discogo/internal/bolt"
+)
+
+func TestWatchExpiredServicesAuditsOncePerExpiry(t *testing.T) {
+	client, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	// Two replicas receive every expiry
+	for i := 0; i < 2; i++ {
+		if err := WatchExpiredServices(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry := ServiceEntry{ServiceUUID: uuid.New().String(), Type: "gw", Provider: "aws", Region: "eu-west-1", Zone: "a", NetworkID: "n", SubnetID: "s", InstanceID: "i-1", Version: "1", Status: StatusRegistered}
+	if err := client.Set(_GenerateServiceKey(entry), []byte("{}"), 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	var events []AuditEvent
+	for time.Now().Before(deadline) {
+		if events, err = QueryAuditEvents(client, time.Time{}, time.Time{}, entry.ServiceUUID, 10); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// Let a second, unclaimed handler run if there is one
+	time.Sleep(200 * time.Millisecond)
+	if events, err = QueryAuditEvents(client, time.Time{}, time.Time{}, entry.ServiceUUID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Action != AuditExpire {
+		t.Fatalf("expiry audited as %+v, want one %s event", events, AuditExpire)
+	}
+}
+
+func TestQueryAuditEventsBoundsTheScan(t *testing.T) {
+	client, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	wanted := uuid.New().String()
+	for i := 0; i < auditMaxScanned; i++ {
+		RecordAuditEvent(client, AuditEvent{Action: AuditRegister, ServiceUUID: uuid.New().String(), Actor: "test"})
+	}
+	RecordAuditEvent(client, AuditEvent{Action: AuditRegister, ServiceUUID: wanted, Actor: "test"})
+
+	events, err := QueryAuditEvents(client, time.Time{}, time.Time{}, wanted, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("a filtered query read past %d events: %+v", auditMaxScanned, events)
+	}
+}
@@ -0,0 +1,463 @@
+package redishelper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Bulk operations scan the service keys once per batch and send their reads
+// and writes as pipelines instead of one round trip per instance.
+
+// BulkRegisterResult is the outcome of one entry of BulkRegister.
+type BulkRegisterResult struct {
+	// Entry is the stored entry, or the existing one when Existing is set.
+	Entry    ServiceEntry
+	Existing bool
+	// Replaced is the entry removed by a replacing registration.
+	Replaced *ServiceEntry
+	Err      error
+}
+
+// BulkHeartbeatResult is the outcome of one UUID of BulkHeartbeat. Err is
+// ErrServiceNotFound, ErrServiceSuspicious, ErrIllegalTransition or a storage
+// error.
+type BulkHeartbeatResult struct {
+	ServiceUUID string
+	Entry       ServiceEntry
+	Change      StatusChange
+	Err         error
+}
+
+// BulkDeregisterResult is the outcome of one UUID of BulkDeregister. Err is
+// ErrServiceNotFound or a storage error.
+type BulkDeregisterResult struct {
+	ServiceUUID string
+	Entry       ServiceEntry
+	Err         error
+}
+
+// scannedServiceKey is a service key split into the parts bulk operations
+// need.
+type scannedServiceKey struct {
+	key      string
+	uuid     string
+	identity string
+}
+
+func scanServiceKeys(client redisclient.Client) ([]scannedServiceKey, error) {
+	keys, err := client.FindKeys(allServiceKeysPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	scanned := make([]scannedServiceKey, 0, len(keys))
+	for _, key := range keys {
+		parts, ok := parseServiceKey(key)
+		if !ok {
+			continue
+		}
+		scanned = append(scanned, scannedServiceKey{
+			key:      key,
+			uuid:     parts[0],
+			identity: _GenerateCredentialBasedSearchKey(parts[2], parts[4], parts[5], parts[6], parts[7], parts[8], parts[9], parts[10]),
+		})
+	}
+	return scanned, nil
+}
+
+// allServiceEntries returns every registered entry, read in one round trip.
+func allServiceEntries(client redisclient.Client) ([]ServiceEntry, error) {
+	scanned, err := scanServiceKeys(client)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := client.Pipeline()
+	for _, s := range scanned {
+		pipe.Get(s.key)
+	}
+	results, err := pipe.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ServiceEntry, 0, len(results))
+	for _, result := range results {
+		var entry ServiceEntry
+		if result.Err != nil || result.Value == nil || json.Unmarshal(result.Value, &entry) != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func entryIdentity(entry ServiceEntry) string {
+	return _GenerateCredentialBasedSearchKey(entry.Type, entry.Provider, entry.Region, entry.Zone, entry.NetworkID, entry.SubnetID, entry.InstanceID, entry.Version)
+}
+
+// serviceKeysByUUID returns the keys of each of uuids found in one scan.
+func serviceKeysByUUID(client redisclient.Client, uuids []string) (map[string][]string, error) {
+	wanted := make(map[string]bool, len(uuids))
+	for _, uuid := range uuids {
+		wanted[uuid] = true
+	}
+
+	scanned, err := scanServiceKeys(client)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string][]string{}
+	for _, s := range scanned {
+		if wanted[s.uuid] {
+			found[s.uuid] = append(found[s.uuid], s.key)
+		}
+	}
+	return found, nil
+}
+
+// serviceKeysByIdentity returns the scanned service keys by identity.
+func serviceKeysByIdentity(client redisclient.Client) (map[string]scannedServiceKey, error) {
+	scanned, err := scanServiceKeys(client)
+	if err != nil {
+		return nil, err
+	}
+	byIdentity := make(map[string]scannedServiceKey, len(scanned))
+	for _, s := range scanned {
+		byIdentity[s.identity] = s
+	}
+	return byIdentity, nil
+}
+
+// BulkRegister stores entries like a registration each, holding the identity
+// lock of every entry while the batch is written. Entries whose identity is
+// already registered are returned as Existing unless replace is set, in which
+// case the entry locks of the replaced entries are held as well and
+// ErrServiceLocked is returned when one of them stays busy. An identity that
+// appears twice in the batch is stored once.
+func BulkRegister(client redisclient.Client, entries []ServiceEntry, replace bool, lockWait time.Duration) ([]BulkRegisterResult, error) {
+	startTime := time.Now()
+	results := make([]BulkRegisterResult, len(entries))
+
+	// Later duplicates of an identity share the result of the first one
+	first := map[string]int{}
+	duplicateOf := make([]int, len(entries))
+	var unique []int
+	for i, entry := range entries {
+		identity := entryIdentity(entry)
+		if j, ok := first[identity]; ok {
+			duplicateOf[i] = j
+			continue
+		}
+		first[identity] = i
+		duplicateOf[i] = -1
+		unique = append(unique, i)
+	}
+
+	// Locks are taken in key order so concurrent batches cannot wait on each other in a cycle
+	sort.Slice(unique, func(a, b int) bool {
+		return registrationLockKey(entries[unique[a]]) < registrationLockKey(entries[unique[b]])
+	})
+	var locked []int
+	for _, i := range unique {
+		release, err := AcquireRegistrationLock(client, entries[i], lockWait)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		defer release()
+		locked = append(locked, i)
+	}
+
+	if len(locked) > 0 {
+		existingKey, err := serviceKeysByIdentity(client)
+		if err != nil {
+			return nil, err
+		}
+		if replace {
+			// Replaced entries are deleted under their entry locks, like a
+			// deregistration, so no concurrent update writes them back
+			var replacedUUIDs []string
+			for _, i := range locked {
+				if s, ok := existingKey[entryIdentity(entries[i])]; ok {
+					replacedUUIDs = append(replacedUUIDs, s.uuid)
+				}
+			}
+			if len(replacedUUIDs) > 0 {
+				releaseEntries, err := lockServiceEntries(client, replacedUUIDs)
+				if err != nil {
+					return nil, err
+				}
+				defer releaseEntries()
+				// A status change may have moved an entry to a new key before the lock was taken
+				if existingKey, err = serviceKeysByIdentity(client); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Read the entries that are already registered
+		reads := client.Pipeline()
+		var readIdx []int
+		for _, i := range locked {
+			if s, ok := existingKey[entryIdentity(entries[i])]; ok {
+				reads.Get(s.key)
+				readIdx = append(readIdx, i)
+			}
+		}
+		readResults, _ := reads.Exec()
+		existing := map[int]ServiceEntry{}
+		for n, i := range readIdx {
+			var entry ServiceEntry
+			if readResults[n].Err != nil {
+				results[i].Err = readResults[n].Err
+				continue
+			}
+			if readResults[n].Value == nil || json.Unmarshal(readResults[n].Value, &entry) != nil {
+				// Expired or unreadable since the scan; register it again
+				continue
+			}
+			existing[i] = entry
+		}
+
+		writes := client.Pipeline()
+		writeIdx := map[int][]int{}
+		ops := 0
+		for _, i := range locked {
+			if results[i].Err != nil {
+				continue
+			}
+			old, found := existing[i]
+			if found && !replace {
+				results[i] = BulkRegisterResult{Entry: old, Existing: true}
+				continue
+			}
+			data, err := _GenerateNewServiceValue(entries[i])
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			if found {
+				replaced := old
+				results[i].Replaced = &replaced
+				writes.Delete(_GenerateServiceKey(old))
+				writeIdx[i] = append(writeIdx[i], ops)
+				ops++
+			}
+			writes.Set(_GenerateServiceKey(entries[i]), data, defaultTTL)
+			writeIdx[i] = append(writeIdx[i], ops)
+			ops++
+			results[i].Entry = entries[i]
+		}
+
+		writeResults, err := writes.Exec()
+		if err != nil {
+			logger.Error(fmt.Sprintf("Bulk registration pipeline failed: %v", err), time.Since(startTime))
+		}
+		for i, idx := range writeIdx {
+			for _, n := range idx {
+				if n < len(writeResults) && writeResults[n].Err != nil {
+					results[i].Err = writeResults[n].Err
+				}
+			}
+		}
+	}
+
+	for i, j := range duplicateOf {
+		if j < 0 {
+			continue
+		}
+		results[i] = BulkRegisterResult{Entry: results[j].Entry, Existing: results[j].Err == nil, Err: results[j].Err}
+	}
+	return results, nil
+}
+
+// BulkHeartbeat refreshes the entries of uuids like UpdateServiceEntry, each
+// with the payload of the same index. Nil payloads send plain heartbeats. A
+// UUID listed more than once is read once and its heartbeats are applied in
+// request order before the entry is stored.
+func BulkHeartbeat(client redisclient.Client, uuids []string, payloads []HeartbeatPayload, policy StatusPolicy) ([]BulkHeartbeatResult, error) {
+	startTime := time.Now()
+	release, err := lockServiceEntries(client, uuids)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	keys, err := serviceKeysByUUID(client, uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkHeartbeatResult, len(uuids))
+	reads := client.Pipeline()
+	var readUUIDs []string
+	queued := map[string]bool{}
+	for i, uuid := range uuids {
+		results[i].ServiceUUID = uuid
+		switch len(keys[uuid]) {
+		case 0:
+			results[i].Err = ErrServiceNotFound
+		case 1:
+			if !queued[uuid] {
+				reads.Get(keys[uuid][0])
+				readUUIDs = append(readUUIDs, uuid)
+				queued[uuid] = true
+			}
+		default:
+			results[i].Err = fmt.Errorf("multiple entries found for UUID %s", uuid)
+		}
+	}
+	readResults, err := reads.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	// heartbeatTarget is the entry of one UUID as its heartbeats are applied
+	type heartbeatTarget struct {
+		entry   ServiceEntry
+		oldKey  string
+		indexes []int
+	}
+	targets := map[string]*heartbeatTarget{}
+	readErrs := map[string]error{}
+	for n, uuid := range readUUIDs {
+		if readResults[n].Err != nil {
+			readErrs[uuid] = readResults[n].Err
+			continue
+		}
+		var entry ServiceEntry
+		if readResults[n].Value == nil || json.Unmarshal(readResults[n].Value, &entry) != nil {
+			readErrs[uuid] = ErrServiceNotFound
+			continue
+		}
+		targets[uuid] = &heartbeatTarget{entry: entry, oldKey: _GenerateServiceKey(entry)}
+	}
+
+	for i, uuid := range uuids {
+		if results[i].Err != nil {
+			continue
+		}
+		target, ok := targets[uuid]
+		if !ok {
+			results[i].Err = readErrs[uuid]
+			continue
+		}
+
+		var payload HeartbeatPayload
+		if payloads != nil {
+			payload = payloads[i]
+		}
+		before := target.entry
+		change, heartbeatErr := target.entry.applyHeartbeat(payload, policy)
+		results[i].Change = change
+		results[i].Entry = target.entry
+		results[i].Err = heartbeatErr
+		// Like a single heartbeat, an illegal one leaves the entry as it was
+		if errors.Is(heartbeatErr, ErrIllegalTransition) {
+			target.entry = before
+			continue
+		}
+		target.indexes = append(target.indexes, i)
+	}
+
+	writes := client.Pipeline()
+	writeIdx := map[string]int{}
+	ops := 0
+	for _, uuid := range readUUIDs {
+		target, ok := targets[uuid]
+		if !ok || len(target.indexes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(target.entry)
+		if err != nil {
+			for _, i := range target.indexes {
+				results[i].Err = err
+			}
+			continue
+		}
+		newKey := _GenerateServiceKey(target.entry)
+		writes.Set(newKey, data, defaultTTL)
+		writeIdx[uuid] = ops
+		ops++
+		// The status is part of the key; only drop the old key if it moved
+		if target.oldKey != newKey {
+			writes.Delete(target.oldKey)
+			ops++
+		}
+	}
+
+	writeResults, err := writes.Exec()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Bulk heartbeat pipeline failed: %v", err), time.Since(startTime))
+	}
+	for uuid, n := range writeIdx {
+		if n >= len(writeResults) || writeResults[n].Err == nil {
+			continue
+		}
+		for _, i := range targets[uuid].indexes {
+			results[i].Err = writeResults[n].Err
+			results[i].Change.To = results[i].Change.From
+		}
+	}
+	return results, nil
+}
+
+// BulkDeregister removes the entries of uuids and returns them for auditing.
+func BulkDeregister(client redisclient.Client, uuids []string) ([]BulkDeregisterResult, error) {
+	release, err := lockServiceEntries(client, uuids)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	keys, err := serviceKeysByUUID(client, uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkDeregisterResult, len(uuids))
+	pipe := client.Pipeline()
+	// Each found UUID queues a Get followed by a Delete
+	opIdx := map[int]int{}
+	ops := 0
+	for i, uuid := range uuids {
+		results[i].ServiceUUID = uuid
+		switch len(keys[uuid]) {
+		case 0:
+			results[i].Err = ErrServiceNotFound
+		case 1:
+			pipe.Get(keys[uuid][0])
+			pipe.Delete(keys[uuid][0])
+			opIdx[i] = ops
+			ops += 2
+			// A UUID listed twice is only deleted once
+			delete(keys, uuid)
+		default:
+			results[i].Err = fmt.Errorf("multiple entries found for UUID %s", uuid)
+		}
+	}
+
+	opResults, err := pipe.Exec()
+	if err != nil {
+		return nil, err
+	}
+	for i, n := range opIdx {
+		if opResults[n].Err != nil || opResults[n+1].Err != nil {
+			results[i].Err = fmt.Errorf("failed to deregister service %s", results[i].ServiceUUID)
+			continue
+		}
+		if opResults[n].Value == nil {
+			results[i].Err = ErrServiceNotFound
+			continue
+		}
+		json.Unmarshal(opResults[n].Value, &results[i].Entry)
+	}
+	return results, nil
+}
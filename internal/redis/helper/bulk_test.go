@@ -0,0 +1,53 @@
+package redishelper
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	boltclient "github.com/tahakara/discogo/internal/bolt"
+)
+
+func TestBulkRegisterReplaceWaitsForEntryLock(t *testing.T) {
+	client, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	wait := entryLockWait
+	entryLockWait = 100 * time.Millisecond
+	t.Cleanup(func() { entryLockWait = wait })
+
+	old := ServiceEntry{ServiceUUID: uuid.New().String(), Type: "gw", Provider: "aws", Region: "eu-west-1", Zone: "a", NetworkID: "n", SubnetID: "s", InstanceID: "i-1", Version: "1", Status: StatusRegistered}
+	if !RegisterNewService(client, old) {
+		t.Fatal("register failed")
+	}
+	replacement := old
+	replacement.ServiceUUID = uuid.New().String()
+
+	release, err := lockServiceEntry(client, old.ServiceUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BulkRegister(client, []ServiceEntry{replacement}, true, time.Second); !errors.Is(err, ErrServiceLocked) {
+		t.Fatalf("replace of a locked entry returned %v, want ErrServiceLocked", err)
+	}
+	if exists, _ := IsServiceExistsByUUID(client, old.ServiceUUID); !exists {
+		t.Fatal("the locked entry was replaced")
+	}
+
+	release()
+	results, err := BulkRegister(client, []ServiceEntry{replacement}, true, time.Second)
+	if err != nil || results[0].Err != nil {
+		t.Fatalf("replace returned %v, %v", err, results[0].Err)
+	}
+	if results[0].Replaced == nil || results[0].Replaced.ServiceUUID != old.ServiceUUID {
+		t.Fatalf("replaced = %+v, want %s", results[0].Replaced, old.ServiceUUID)
+	}
+	if exists, _ := IsServiceExistsByUUID(client, old.ServiceUUID); exists {
+		t.Fatal("the replaced entry is still registered")
+	}
+}
@@ -0,0 +1,47 @@
+package redishelper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// InstanceCounts holds the number of live instances per status of one service type.
+type InstanceCounts map[ServiceStatus]int
+
+func (c InstanceCounts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+// CountServicesByType scans every service key once and returns the live
+// instance counts per status, keyed by service type. Type and status are read
+// from the key itself so no entry has to be fetched.
+func CountServicesByType(client redisclient.Client) (map[string]InstanceCounts, error) {
+	startTime := time.Now()
+	keys, err := client.FindKeys(allServiceKeysPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]InstanceCounts{}
+	for _, key := range keys {
+		parts, ok := parseServiceKey(key)
+		if !ok {
+			continue
+		}
+		serviceType, status := parts[2], ServiceStatus(parts[3])
+		if counts[serviceType] == nil {
+			counts[serviceType] = InstanceCounts{}
+		}
+		counts[serviceType][status]++
+	}
+
+	logger.Debug(fmt.Sprintf("Counted %d service keys across %d types", len(keys), len(counts)), time.Since(startTime))
+	return counts, nil
+}
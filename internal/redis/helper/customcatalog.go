@@ -0,0 +1,210 @@
+package redishelper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// Custom catalog entries never expire; they are stored as JSON under these
+// prefixes followed by the short name.
+const (
+	customServiceTypeKeyPrefix = "discogo:catalog:type:"
+	customProviderKeyPrefix    = "discogo:catalog:provider:"
+)
+
+var (
+	ErrCatalogEntryExists   = errors.New("catalog entry already exists")
+	ErrCatalogEntryNotFound = errors.New("catalog entry not found")
+)
+
+// CustomServiceTypeUpdate describes a change to a custom service type.
+// Nil fields are left untouched.
+type CustomServiceTypeUpdate struct {
+	Name        *string
+	Description *string
+	Group       *string
+	Retired     *bool
+}
+
+// CustomProviderUpdate describes a change to a custom provider.
+// Nil fields are left untouched.
+type CustomProviderUpdate struct {
+	Name    *string
+	Retired *bool
+}
+
+func loadCustomEntries(client redisclient.Client, prefix string, each func(data []byte) error) error {
+	keys, err := client.FindKeys(prefix + "*")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		data, err := client.Get(key)
+		if err != nil || data == nil {
+			continue
+		}
+		if err := each(data); err != nil {
+			logger.Error(fmt.Sprintf("Skipping malformed catalog entry %s: %v", key, err), 0)
+		}
+	}
+	return nil
+}
+
+// LoadCustomCatalog reads every custom service type and provider from the
+// backend and merges them into the catalog lookups.
+func LoadCustomCatalog(client redisclient.Client) error {
+	types := []serviceconfigloader.CustomServiceType{}
+	err := loadCustomEntries(client, customServiceTypeKeyPrefix, func(data []byte) error {
+		var t serviceconfigloader.CustomServiceType
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		types = append(types, t)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	providers := []serviceconfigloader.CustomProvider{}
+	err = loadCustomEntries(client, customProviderKeyPrefix, func(data []byte) error {
+		var p serviceconfigloader.CustomProvider
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		providers = append(providers, p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	serviceconfigloader.SetCustomCatalog(types, providers)
+	return nil
+}
+
+func getCustomEntry(client redisclient.Client, key string, out interface{}) error {
+	data, err := client.Get(key)
+	if err != nil || data == nil {
+		return ErrCatalogEntryNotFound
+	}
+	return json.Unmarshal(data, out)
+}
+
+// saveCustomEntry stores v under key and refreshes the local catalog so the
+// change is visible immediately on this instance.
+func saveCustomEntry(client redisclient.Client, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := client.Set(key, data, 0); err != nil {
+		return err
+	}
+	return LoadCustomCatalog(client)
+}
+
+// CreateCustomServiceType stores a new custom service type. Short names that
+// already exist in conf.json or in the backend are rejected.
+func CreateCustomServiceType(client redisclient.Client, t serviceconfigloader.CustomServiceType) (serviceconfigloader.CustomServiceType, error) {
+	if serviceconfigloader.IsKnownServiceType(t.Short) {
+		return serviceconfigloader.CustomServiceType{}, ErrCatalogEntryExists
+	}
+	if data, err := client.Get(customServiceTypeKeyPrefix + t.Short); err == nil && data != nil {
+		return serviceconfigloader.CustomServiceType{}, ErrCatalogEntryExists
+	}
+
+	if strings.TrimSpace(t.Group) == "" {
+		t.Group = serviceconfigloader.CustomGroupKey
+	}
+	t.Retired = false
+	t.CreatedAt = utils.GetFormatedCurrentTime()
+	t.UpdatedAt = t.CreatedAt
+
+	if err := saveCustomEntry(client, customServiceTypeKeyPrefix+t.Short, t); err != nil {
+		return serviceconfigloader.CustomServiceType{}, err
+	}
+	return t, nil
+}
+
+// UpdateCustomServiceType applies update to the custom service type short and
+// returns the type before and after the change.
+func UpdateCustomServiceType(client redisclient.Client, short string, update CustomServiceTypeUpdate) (serviceconfigloader.CustomServiceType, serviceconfigloader.CustomServiceType, error) {
+	var oldType serviceconfigloader.CustomServiceType
+	if err := getCustomEntry(client, customServiceTypeKeyPrefix+short, &oldType); err != nil {
+		return serviceconfigloader.CustomServiceType{}, serviceconfigloader.CustomServiceType{}, err
+	}
+
+	newType := oldType
+	if update.Name != nil {
+		newType.Name = *update.Name
+	}
+	if update.Description != nil {
+		newType.Description = *update.Description
+	}
+	if update.Group != nil {
+		newType.Group = *update.Group
+		if strings.TrimSpace(newType.Group) == "" {
+			newType.Group = serviceconfigloader.CustomGroupKey
+		}
+	}
+	if update.Retired != nil {
+		newType.Retired = *update.Retired
+	}
+	newType.UpdatedAt = utils.GetFormatedCurrentTime()
+
+	if err := saveCustomEntry(client, customServiceTypeKeyPrefix+short, newType); err != nil {
+		return serviceconfigloader.CustomServiceType{}, serviceconfigloader.CustomServiceType{}, err
+	}
+	return oldType, newType, nil
+}
+
+// CreateCustomProvider stores a new custom provider. Short names that already
+// exist in conf.json or in the backend are rejected.
+func CreateCustomProvider(client redisclient.Client, p serviceconfigloader.CustomProvider) (serviceconfigloader.CustomProvider, error) {
+	if serviceconfigloader.IsKnownProvider(p.Short) {
+		return serviceconfigloader.CustomProvider{}, ErrCatalogEntryExists
+	}
+	if data, err := client.Get(customProviderKeyPrefix + p.Short); err == nil && data != nil {
+		return serviceconfigloader.CustomProvider{}, ErrCatalogEntryExists
+	}
+
+	p.Retired = false
+	p.CreatedAt = utils.GetFormatedCurrentTime()
+	p.UpdatedAt = p.CreatedAt
+
+	if err := saveCustomEntry(client, customProviderKeyPrefix+p.Short, p); err != nil {
+		return serviceconfigloader.CustomProvider{}, err
+	}
+	return p, nil
+}
+
+// UpdateCustomProvider applies update to the custom provider short and returns
+// the provider before and after the change.
+func UpdateCustomProvider(client redisclient.Client, short string, update CustomProviderUpdate) (serviceconfigloader.CustomProvider, serviceconfigloader.CustomProvider, error) {
+	var oldProvider serviceconfigloader.CustomProvider
+	if err := getCustomEntry(client, customProviderKeyPrefix+short, &oldProvider); err != nil {
+		return serviceconfigloader.CustomProvider{}, serviceconfigloader.CustomProvider{}, err
+	}
+
+	newProvider := oldProvider
+	if update.Name != nil {
+		newProvider.Name = *update.Name
+	}
+	if update.Retired != nil {
+		newProvider.Retired = *update.Retired
+	}
+	newProvider.UpdatedAt = utils.GetFormatedCurrentTime()
+
+	if err := saveCustomEntry(client, customProviderKeyPrefix+short, newProvider); err != nil {
+		return serviceconfigloader.CustomProvider{}, serviceconfigloader.CustomProvider{}, err
+	}
+	return oldProvider, newProvider, nil
+}
@@ -6,10 +6,8 @@ import (
 	"fmt"
 	"time"
 
-	env "github.com/tahakara/discogo/internal/config"
 	"github.com/tahakara/discogo/internal/logger"
 	redisclient "github.com/tahakara/discogo/internal/redis"
-	"github.com/tahakara/discogo/internal/utils"
 )
 
 type ServiceEntry struct {
@@ -38,6 +36,22 @@ type ServiceEntry struct {
 	ReportCount  int64             // (DISCO | Client) Count of reports received
 	LastReportAt string            // (DISCO | Client) RFC3339 Unix timestamp of last report
 	Metadata     map[string]string // (DISCO | Client) Additional metadata
+	Reports      []ServiceReport   // (Client) Reports within the report window, one per reporter
+
+	CPULoad    float64 // (Client) CPU load in percent, as of the last heartbeat
+	MemoryLoad float64 // (Client) Memory load in percent, as of the last heartbeat
+	InFlight   int64   // (Client) Requests in flight, as of the last heartbeat
+	HasLoad    bool    // (DISCO) Whether a heartbeat has reported load
+	SelfStatus string  // (Client) ok, degraded or failing, as of the last heartbeat
+
+	Check *HealthCheck // (Client | DISCO) Active health check, if one was declared
+
+	StatusHistory      []StatusTransition // (DISCO) Latest status transitions, oldest first
+	RecoveryHeartbeats int                // (DISCO) Good heartbeats in a row while suspicious
+
+	EjectedUntil  string  // (DISCO) RFC3339 end of the current outlier ejection
+	EjectionCount int     // (DISCO) Ejection backoff, up on each ejection and down on each clean interval
+	ErrorRate     float64 // (DISCO) Client-reported error rate that caused the last ejection
 
 	TTL int64 // (DISCO) Time to live in seconds
 }
@@ -54,6 +68,7 @@ const (
 	StatusHealthy      ServiceStatus = "healthy"
 	StatusDeregistered ServiceStatus = "deregistered"
 	StatusSuspicious   ServiceStatus = "suspicious"
+	StatusDegraded     ServiceStatus = "degraded"
 )
 
 const (
@@ -76,6 +91,8 @@ const (
 	defaultTTL time.Duration = 1 * time.Minute // Default TTL for service entries in minutes
 )
 
+// _generateServiceKey joins key segments that are already encoded with
+// keySegment, or are anySegment in a pattern.
 func _generateServiceKey(serviceUUID string, serviceName string, serviceType string, status ServiceStatus, provider string, region string, zone string, networkID string, subnetID string, instanceID string, version string) string {
 	return fmt.Sprintf(ServiceKeyPattern,
 		serviceUUID,
@@ -94,41 +111,52 @@ func _generateServiceKey(serviceUUID string, serviceName string, serviceType str
 
 func _GenerateServiceKey(serviceEntry ServiceEntry) string {
 	return _generateServiceKey(
-		serviceEntry.ServiceUUID,
-		serviceEntry.Name,
-		serviceEntry.Type,
-		serviceEntry.Status,
-		serviceEntry.Provider,
-		serviceEntry.Region,
-		serviceEntry.Zone,
-		serviceEntry.NetworkID,
-		serviceEntry.SubnetID,
-		serviceEntry.InstanceID,
-		serviceEntry.Version,
+		keySegment(serviceEntry.ServiceUUID),
+		keySegment(serviceEntry.Name),
+		keySegment(serviceEntry.Type),
+		ServiceStatus(keySegment(string(serviceEntry.Status))),
+		keySegment(serviceEntry.Provider),
+		keySegment(serviceEntry.Region),
+		keySegment(serviceEntry.Zone),
+		keySegment(serviceEntry.NetworkID),
+		keySegment(serviceEntry.SubnetID),
+		keySegment(serviceEntry.InstanceID),
+		keySegment(serviceEntry.Version),
 	)
 }
 
 func _GenerateCredentialBasedSearchKey(serviceType string, provider string, region string, zone string, networkID string, subnetID string, instanceID string, version string) string {
 	return _generateServiceKey(
-		"*",
-		"*",
-		serviceType,
-		"*",
-		provider,
-		region,
-		zone,
-		networkID,
-		subnetID,
-		instanceID,
-		version,
+		anySegment,
+		anySegment,
+		keySegment(serviceType),
+		anySegment,
+		keySegment(provider),
+		keySegment(region),
+		keySegment(zone),
+		keySegment(networkID),
+		keySegment(subnetID),
+		keySegment(instanceID),
+		keySegment(version),
 	)
 }
 
+// serviceUUIDPattern matches the key of serviceUUID.
+func serviceUUIDPattern(serviceUUID string) string {
+	return _generateServiceKey(keySegment(serviceUUID), anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment)
+}
+
+// allServiceKeysPattern matches every service key, and possibly other keys;
+// scan it with findServiceKeys.
+var allServiceKeysPattern = _generateServiceKey(anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment, anySegment)
+
 func _GenerateNewServiceValue(serviceEntry ServiceEntry) ([]byte, error) {
 	now := time.Now().Format(time.RFC3339)
 	serviceEntry.CreatedAt = now
 	serviceEntry.LastHeardAt = now
-	serviceEntry.Status = StatusRegistered
+	serviceEntry.Status = ""
+	serviceEntry.StatusHistory = nil
+	serviceEntry.transition(StatusRegistered, TriggerRegister, "")
 	serviceEntry.HeardCount = 0
 	serviceEntry.ReportCount = 0
 	serviceEntry.LastReportAt = now
@@ -158,7 +186,7 @@ func IsServiceExists(client redisclient.Client, entry ServiceEntry) (bool, Servi
 	searchKey := _GenerateCredentialBasedSearchKey(entry.Type, entry.Provider, entry.Region, entry.Zone, entry.NetworkID, entry.SubnetID, entry.InstanceID, entry.Version)
 	var foundEntry ServiceEntry
 
-	keys, err := client.FindKeys(searchKey)
+	keys, err := findServiceKeys(client, searchKey)
 	if err != nil {
 		return false, ServiceEntry{}
 	}
@@ -182,10 +210,10 @@ func IsServiceExists(client redisclient.Client, entry ServiceEntry) (bool, Servi
 }
 
 func IsServiceExistsByUUID(client redisclient.Client, serviceUUID string) (bool, ServiceEntry) {
-	searchKey := _generateServiceKey(serviceUUID, "*", "*", "*", "*", "*", "*", "*", "*", "*", "*")
+	searchKey := serviceUUIDPattern(serviceUUID)
 	var foundEntry ServiceEntry
 
-	keys, err := client.FindKeys(searchKey)
+	keys, err := findServiceKeys(client, searchKey)
 	if err != nil {
 		return false, ServiceEntry{}
 	}
@@ -210,101 +238,94 @@ func IsServiceExistsByUUID(client redisclient.Client, serviceUUID string) (bool,
 	return false, ServiceEntry{}
 }
 
-func UpdateServiceEntry(client redisclient.Client, uuid string) (bool, error) {
+// UpdateServiceEntry refreshes the entry of uuid on heartbeat, applies the
+// optional payload and moves the entry through the status state machine. It
+// returns ErrServiceSuspicious while the instance is suspicious; the heartbeat
+// is stored anyway so the instance can recover. The entry is returned as
+// stored, or empty when nothing was stored.
+func UpdateServiceEntry(client redisclient.Client, uuid string, policy StatusPolicy, payload HeartbeatPayload) (ServiceEntry, StatusChange, error) {
 	startTime := time.Now()
 
-	// Fetch the existing entry to preserve CreatedAt and HeardCount, etc.
-	exists, existingEntry := IsServiceExistsByUUID(client, uuid)
-	oldKey := _GenerateServiceKey(existingEntry)
-	if exists {
-		// Preserve CreatedAt, HeardCount, ReportCount, etc.
-		existingEntry.LastHeardAt = utils.GetFormatedCurrentTime()
-		existingEntry.HeardCount++
-		existingEntry.Status = StatusHealthy // Update status to healthy on heartbeat
-
-		if existingEntry.ReportCount > env.GetReportToleranceCount() {
-			existingEntry.Status = StatusSuspicious
-			logger.HeartBeat(fmt.Sprintf("Service with UUID %s is marked as suspicious", uuid), time.Since(startTime))
-			return false, errors.New("service entry is suspicious")
+	var change StatusChange
+	updated, err := modifyServiceEntry(client, uuid, defaultTTL, func(e *ServiceEntry) error {
+		var heartbeatErr error
+		change, heartbeatErr = e.applyHeartbeat(payload, policy)
+		if errors.Is(heartbeatErr, ErrIllegalTransition) {
+			return heartbeatErr
 		}
-
-	} else {
-		// logger.Error(fmt.Sprintf("Service with UUID %s does not exist", uuid), time.Since(startTime))
-		return false, errors.New("key not found")
-	}
-
-	updatedData, err := json.Marshal(existingEntry)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to marshal updated service entry: %v", err), time.Since(startTime))
-		return false, errors.New("failed to marshal updated service entry")
-	}
-
-	serviceKey := _GenerateServiceKey(existingEntry)
-	err = client.Set(serviceKey, updatedData, defaultTTL)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to update service entry: %v", err), time.Since(startTime))
-		return false, errors.New("failed to update service entry")
-	}
-
-	err = client.Delete(oldKey)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to delete old service entry: %v", err), time.Since(startTime))
-		// return false, errors.New("failed to delete old service entry")
-	}
-
-	return true, nil
+		return keepEntry(heartbeatErr)
+	})
+	switch {
+	case errors.Is(err, ErrServiceSuspicious):
+		logger.HeartBeat(fmt.Sprintf("Service with UUID %s is suspicious (%d good heartbeats towards recovery)", uuid, updated.RecoveryHeartbeats), time.Since(startTime))
+		return updated, change, err
+	case err != nil:
+		return ServiceEntry{}, change, err
+	}
+	return updated, change, nil
 }
 
+// GetServicesFiltered returns a page of the services of serviceType matching
+// the filters. Instances ejected as outliers are left out until their
+// ejection ends.
 func GetServicesFiltered(rclient redisclient.Client, serviceType string, healthStatus ServiceStatus, provider string, region string, zone string, networkID string, subnetID string, instanceID string, version string, pageSize int, pageOffset int) ([]ServiceEntry, error) {
-	startTime := time.Now()
-
 	if serviceType == "" {
 		return nil, errors.New("serviceType is required")
 	}
-	if healthStatus == "" {
-		healthStatus = StatusAny
-	}
-	if provider == "" {
-		provider = "*"
-	}
-	if region == "" {
-		region = "*"
-	}
-	if zone == "" {
-		zone = "*"
-	}
-	if networkID == "" {
-		networkID = "*"
-	}
-	if subnetID == "" {
-		subnetID = "*"
-	}
-	if instanceID == "" {
-		instanceID = "*"
-	}
-	if version == "" {
-		version = "*"
-	}
+	return _getServicesFiltered(rclient, serviceType, healthStatus, provider, region, zone, networkID, subnetID, instanceID, version, pageSize, pageOffset, true)
+}
 
-	searchKey := _generateServiceKey("*", "*", serviceType, healthStatus, provider, region, zone, networkID, subnetID, instanceID, version)
-	keys, err := rclient.FindKeys(searchKey)
+// _getServicesFiltered treats every empty filter, including serviceType, as a
+// wildcard. With excludeEjected, ejected instances do not count towards the
+// page, so a page is only short when the matches run out.
+func _getServicesFiltered(rclient redisclient.Client, serviceType string, healthStatus ServiceStatus, provider string, region string, zone string, networkID string, subnetID string, instanceID string, version string, pageSize int, pageOffset int, excludeEjected bool) ([]ServiceEntry, error) {
+	startTime := time.Now()
+
+	if healthStatus == StatusAny {
+		healthStatus = ""
+	}
+
+	// Filter values are encoded like the stored segments, so they match
+	// literally and cannot widen the pattern
+	searchKey := _generateServiceKey(
+		anySegment,
+		anySegment,
+		patternSegment(serviceType),
+		ServiceStatus(patternSegment(string(healthStatus))),
+		patternSegment(provider),
+		patternSegment(region),
+		patternSegment(zone),
+		patternSegment(networkID),
+		patternSegment(subnetID),
+		patternSegment(instanceID),
+		patternSegment(version),
+	)
+	keys, err := findServiceKeys(rclient, searchKey)
 	if err != nil {
 		return nil, err
 	}
 
-	// Apply pagination to keys before fetching data
 	start := pageOffset * pageSize
-	end := start + pageSize
 	if start > len(keys) {
 		return []ServiceEntry{}, nil
 	}
-	if end > len(keys) {
-		end = len(keys)
+	// Without the ejection filter the page is known from the keys alone
+	if !excludeEjected {
+		end := start + pageSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		keys = keys[start:end]
+		start = 0
 	}
-	keys = keys[start:end]
 
+	now := time.Now()
 	var services []ServiceEntry
+	skipped := 0
 	for _, key := range keys {
+		if len(services) == pageSize {
+			break
+		}
 		data, err := rclient.Get(key)
 		if err != nil {
 			continue
@@ -314,6 +335,13 @@ func GetServicesFiltered(rclient redisclient.Client, serviceType string, healthS
 		if err := json.Unmarshal(data, &entry); err != nil {
 			continue
 		}
+		if excludeEjected && entry.Ejected(now) {
+			continue
+		}
+		if skipped < start {
+			skipped++
+			continue
+		}
 
 		services = append(services, entry)
 	}
@@ -323,10 +351,13 @@ func GetServicesFiltered(rclient redisclient.Client, serviceType string, healthS
 }
 
 func DeregisterServiceEntry(rclient redisclient.Client, serviceUUID string) (bool, error) {
+	release, err := lockServiceEntry(rclient, serviceUUID)
+	if err != nil {
+		return false, err
+	}
+	defer release()
 
-	keys, err := rclient.FindKeys(
-		_generateServiceKey(serviceUUID, "*", "*", "*", "*", "*", "*", "*", "*", "*", "*"),
-	)
+	keys, err := findServiceKeys(rclient, serviceUUIDPattern(serviceUUID))
 	if err != nil {
 		return false, err
 	}
@@ -351,7 +382,7 @@ func DeregisterServiceEntry(rclient redisclient.Client, serviceUUID string) (boo
 
 func IsValidServiceStatus(status string) bool {
 	switch status {
-	case string(StatusHealthy), string(StatusUnknown), string(StatusSuspicious), string(StatusAny), string(StatusRegistered), string(StatusDeregistered):
+	case string(StatusHealthy), string(StatusUnknown), string(StatusSuspicious), string(StatusAny), string(StatusRegistered), string(StatusDeregistered), string(StatusDegraded):
 		return true
 	default:
 		return false
@@ -373,6 +404,8 @@ func DecideStatus(status string) ServiceStatus {
 		return StatusRegistered
 	case string(StatusDeregistered):
 		return StatusDeregistered
+	case string(StatusDegraded):
+		return StatusDegraded
 	default:
 		return StatusUnknown
 	}
@@ -386,5 +419,6 @@ func GetAllServiceStatuses() []string {
 		string(StatusAny),
 		string(StatusRegistered),
 		string(StatusDeregistered),
+		string(StatusDegraded),
 	}
 }
@@ -0,0 +1,139 @@
+package redishelper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Entry locks serialise the read-modify-write updates of one service entry.
+// Heartbeats, health checks, reports, outlier ejections and admin changes all
+// rewrite the whole entry, and a status change moves it to a new key, so two
+// unserialised updates would lose one of them or leave two keys behind.
+const (
+	entryLockKeyPrefix = "discogo:lock:service:"
+	// entryLockTTL bounds how long a crashed holder blocks the entry.
+	entryLockTTL = 5 * time.Second
+)
+
+// entryLockWait is how long an update waits for the one before it. Tests
+// shorten it.
+var entryLockWait = 5 * time.Second
+
+// ErrServiceLocked is returned when another update of the same service entry
+// holds its lock for longer than entryLockWait.
+var ErrServiceLocked = errors.New("service entry is being updated")
+
+// lockServiceEntry takes the entry lock of serviceUUID. The returned release
+// func must be called once the update is stored.
+func lockServiceEntry(client redisclient.Client, serviceUUID string) (func(), error) {
+	release, acquired, err := acquireLock(client, entryLockKeyPrefix+keySegment(serviceUUID), entryLockTTL, entryLockWait)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrServiceLocked
+	}
+	return release, nil
+}
+
+// lockServiceEntries takes the entry locks of every UUID in uuids, in sorted
+// order so two bulk requests cannot deadlock. On error no lock is held.
+func lockServiceEntries(client redisclient.Client, uuids []string) (func(), error) {
+	sorted := append([]string(nil), uuids...)
+	sort.Strings(sorted)
+
+	var releases []func()
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	for i, serviceUUID := range sorted {
+		if i > 0 && serviceUUID == sorted[i-1] {
+			continue
+		}
+		release, err := lockServiceEntry(client, serviceUUID)
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+	return releaseAll, nil
+}
+
+// modifyServiceEntry applies modify to the entry of serviceUUID under its
+// entry lock and stores the result. A ttl of 0 keeps the entry's remaining
+// TTL. When modify fails nothing is stored, unless it also asks to keep the
+// change by returning an error wrapped by keepEntry.
+func modifyServiceEntry(client redisclient.Client, serviceUUID string, ttl time.Duration, modify func(e *ServiceEntry) error) (ServiceEntry, error) {
+	startTime := time.Now()
+
+	release, err := lockServiceEntry(client, serviceUUID)
+	if err != nil {
+		return ServiceEntry{}, err
+	}
+	defer release()
+
+	exists, entry, remaining := GetServiceEntryWithTTL(client, serviceUUID)
+	if !exists {
+		return ServiceEntry{}, ErrServiceNotFound
+	}
+	if ttl == 0 {
+		ttl = remaining
+	}
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	oldKey := _GenerateServiceKey(entry)
+	modifyErr := modify(&entry)
+	var kept keptEntryError
+	if modifyErr != nil && !errors.As(modifyErr, &kept) {
+		return ServiceEntry{}, modifyErr
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return ServiceEntry{}, err
+	}
+	newKey := _GenerateServiceKey(entry)
+	if err := client.Set(newKey, data, ttl); err != nil {
+		logger.Error(fmt.Sprintf("Failed to store service entry: %v", err), time.Since(startTime))
+		return ServiceEntry{}, err
+	}
+	// The status is part of the key; only drop the old key if it moved
+	if newKey != oldKey {
+		if err := client.Delete(oldKey); err != nil {
+			logger.Error(fmt.Sprintf("Failed to delete old service entry: %v", err), time.Since(startTime))
+		}
+	}
+
+	if modifyErr != nil {
+		return entry, kept.err
+	}
+	return entry, nil
+}
+
+// keptEntryError marks an error of a modify func whose change is stored
+// anyway, such as a heartbeat of a suspicious service.
+type keptEntryError struct{ err error }
+
+func (e keptEntryError) Error() string { return e.err.Error() }
+
+func (e keptEntryError) Unwrap() error { return e.err }
+
+// keepEntry wraps err so modifyServiceEntry stores the change and then
+// returns err.
+func keepEntry(err error) error {
+	if err == nil {
+		return nil
+	}
+	return keptEntryError{err: err}
+}
@@ -0,0 +1,86 @@
+package redishelper
+
+import (
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Active health check types.
+const (
+	CheckHTTP = "http"
+	CheckTCP  = "tcp"
+	CheckGRPC = "grpc"
+)
+
+// Probe claims are taken with SETNX and expire after the check interval, so
+// each due check is run by exactly one DiscoGo replica per interval.
+const probeClaimKeyPrefix = "discogo:lock:probe:"
+
+// HealthCheck is an active check declared at registration together with the
+// outcome of its latest runs.
+type HealthCheck struct {
+	Type           string // (Client) http, tcp or grpc
+	Path           string // (Client) HTTP path
+	ExpectedStatus int    // (Client) HTTP status that counts as passing
+	Service        string // (Client) gRPC service name, empty for the whole server
+	Interval       int    // (Client) seconds between checks, 0 uses the configured default
+	Timeout        int    // (Client) seconds before a check fails, 0 uses the configured default
+
+	LastCheckedAt       string // (DISCO) RFC3339 timestamp of the last check
+	LastOutput          string // (DISCO) Error of the last failed check
+	ConsecutiveFailures int    // (DISCO) Failed checks since the last passing one
+	Failing             bool   // (DISCO) Whether the failure threshold was reached
+}
+
+// Due reports whether a check run every interval should run again at now.
+func (c HealthCheck) Due(now time.Time, interval time.Duration) bool {
+	last, err := time.Parse(time.RFC3339, c.LastCheckedAt)
+	if err != nil {
+		return true
+	}
+	return !now.Before(last.Add(interval))
+}
+
+// ServicesWithChecks returns every registered entry that declares an active
+// health check.
+func ServicesWithChecks(client redisclient.Client) ([]ServiceEntry, error) {
+	entries, err := allServiceEntries(client)
+	if err != nil {
+		return nil, err
+	}
+
+	var withChecks []ServiceEntry
+	for _, entry := range entries {
+		if entry.Check != nil {
+			withChecks = append(withChecks, entry)
+		}
+	}
+	return withChecks, nil
+}
+
+// ClaimHealthCheck reports whether this replica won the right to run the
+// check of serviceUUID for the next interval.
+func ClaimHealthCheck(client redisclient.Client, serviceUUID, replicaID string, interval time.Duration) (bool, error) {
+	return client.Add(probeClaimKeyPrefix+serviceUUID, []byte(replicaID), interval)
+}
+
+// RecordHealthCheckResult stores the outcome of a check of serviceUUID (see
+// applyCheckResult) while keeping the entry's remaining TTL; checks do not
+// keep an instance registered, heartbeats do. checkErr is nil for a passing
+// check.
+func RecordHealthCheckResult(client redisclient.Client, serviceUUID string, checkErr error, failureThreshold int) (ServiceEntry, StatusChange, error) {
+	var change StatusChange
+	entry, err := modifyServiceEntry(client, serviceUUID, 0, func(e *ServiceEntry) error {
+		if e.Check == nil {
+			return ErrServiceNotFound
+		}
+		var err error
+		change, err = e.applyCheckResult(checkErr, failureThreshold)
+		return err
+	})
+	if err != nil {
+		return ServiceEntry{}, StatusChange{}, err
+	}
+	return entry, change, nil
+}
@@ -0,0 +1,78 @@
+package redishelper
+
+import "github.com/tahakara/discogo/internal/utils"
+
+// Self-reported statuses a heartbeat may carry.
+const (
+	SelfStatusOK       = "ok"
+	SelfStatusDegraded = "degraded"
+	SelfStatusFailing  = "failing"
+)
+
+// HeartbeatPayload is the optional load and health report of a heartbeat.
+// Nil fields keep their last reported value.
+type HeartbeatPayload struct {
+	CPULoad    *float64
+	MemoryLoad *float64
+	InFlight   *int64
+	// SelfStatus is ok, degraded or failing; empty means ok.
+	SelfStatus string
+	// Metadata is merged into ServiceEntry.Metadata; an empty value removes the key.
+	Metadata map[string]string
+}
+
+// MaxMetadataEntries bounds ServiceEntry.Metadata across heartbeats; keys
+// beyond it are dropped until others are removed.
+const MaxMetadataEntries = 32
+
+// heartbeatStatus maps a self-reported status onto the registry status. A
+// failing instance is kept but no longer discovered as healthy or degraded.
+func heartbeatStatus(selfStatus string) ServiceStatus {
+	switch selfStatus {
+	case SelfStatusDegraded:
+		return StatusDegraded
+	case SelfStatusFailing:
+		return StatusUnknown
+	default:
+		return StatusHealthy
+	}
+}
+
+// recordHeartbeat stores the details of a heartbeat carrying payload on e.
+// The status is left to applyHeartbeat.
+func (e *ServiceEntry) recordHeartbeat(payload HeartbeatPayload) {
+	e.LastHeardAt = utils.GetFormatedCurrentTime()
+	e.HeardCount++
+
+	if payload.SelfStatus == "" {
+		payload.SelfStatus = SelfStatusOK
+	}
+	e.SelfStatus = payload.SelfStatus
+
+	if payload.CPULoad != nil {
+		e.CPULoad = *payload.CPULoad
+		e.HasLoad = true
+	}
+	if payload.MemoryLoad != nil {
+		e.MemoryLoad = *payload.MemoryLoad
+		e.HasLoad = true
+	}
+	if payload.InFlight != nil {
+		e.InFlight = *payload.InFlight
+		e.HasLoad = true
+	}
+
+	for k, v := range payload.Metadata {
+		if v == "" {
+			delete(e.Metadata, k)
+			continue
+		}
+		if e.Metadata == nil {
+			e.Metadata = map[string]string{}
+		}
+		if _, ok := e.Metadata[k]; !ok && len(e.Metadata) >= MaxMetadataEntries {
+			continue
+		}
+		e.Metadata[k] = v
+	}
+}
@@ -0,0 +1,79 @@
+package redishelper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// Idempotency records are stored as JSON under this prefix followed by the
+// client supplied key, encoded with keySegment, and expire after the
+// configured TTL.
+const idempotencyKeyPrefix = "discogo:idempotency:"
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again
+// with a different request body.
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+
+// ErrIdempotencyRecordInvalid is returned when the record stored for a key
+// cannot be decoded.
+var ErrIdempotencyRecordInvalid = errors.New("idempotency record is invalid")
+
+// IdempotencyRecord remembers which registration an idempotency key produced.
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	ServiceUUID string `json:"serviceUUID"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// RequestFingerprint returns a stable hash of the JSON encoding of v.
+func RequestFingerprint(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// LookupIdempotencyKey returns the record stored for key. It returns false
+// when the key is unknown or has expired, ErrIdempotencyKeyReused when the
+// key was first used with a different fingerprint, and the storage error or
+// ErrIdempotencyRecordInvalid when the record cannot be read.
+func LookupIdempotencyKey(client redisclient.Client, key, fingerprint string) (IdempotencyRecord, bool, error) {
+	data, err := client.Get(idempotencyKeyPrefix + keySegment(key))
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	if data == nil {
+		return IdempotencyRecord{}, false, nil
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("%w: %v", ErrIdempotencyRecordInvalid, err)
+	}
+	if record.Fingerprint != fingerprint {
+		return record, true, ErrIdempotencyKeyReused
+	}
+	return record, true, nil
+}
+
+// SaveIdempotencyKey stores the registration produced for key for ttl.
+func SaveIdempotencyKey(client redisclient.Client, key, fingerprint, serviceUUID string, ttl time.Duration) error {
+	data, err := json.Marshal(IdempotencyRecord{
+		Fingerprint: fingerprint,
+		ServiceUUID: serviceUUID,
+		CreatedAt:   utils.GetFormatedCurrentTime(),
+	})
+	if err != nil {
+		return err
+	}
+	return client.Set(idempotencyKeyPrefix+keySegment(key), data, ttl)
+}
@@ -0,0 +1,120 @@
+package redishelper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// internalKeyPrefix starts every key that is not a service key. A service key
+// pattern can match such keys, because '*' also matches ':'.
+const internalKeyPrefix = "discogo:"
+
+// anySegment matches any value of one key segment in a key pattern.
+const anySegment = "*"
+
+// keySegmentReserved lists the characters keySegment encodes besides spaces
+// and control characters: the escape itself, the segment separator and the
+// glob characters of key patterns.
+const keySegmentReserved = "%:*?[]\\"
+
+// keySegment encodes value for use as one segment of a key. Reserved bytes
+// are written as %XX, so no value can add a segment or act as a wildcard, and
+// the encoded value matches only itself in a key pattern.
+func keySegment(value string) string {
+	escapes := 0
+	for i := 0; i < len(value); i++ {
+		if escapeKeyByte(value[i]) {
+			escapes++
+		}
+	}
+	if escapes == 0 {
+		return value
+	}
+	b := make([]byte, 0, len(value)+2*escapes)
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; escapeKeyByte(c) {
+			b = append(b, '%', hexDigits[c>>4], hexDigits[c&0xf])
+		} else {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+const hexDigits = "0123456789ABCDEF"
+
+func escapeKeyByte(c byte) bool {
+	return c <= ' ' || c == 0x7f || strings.IndexByte(keySegmentReserved, c) >= 0
+}
+
+// decodeKeySegment reverses keySegment.
+func decodeKeySegment(segment string) (string, error) {
+	if !strings.Contains(segment, "%") {
+		return segment, nil
+	}
+	b := make([]byte, 0, len(segment))
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '%' {
+			b = append(b, segment[i])
+			continue
+		}
+		if i+2 >= len(segment) {
+			return "", fmt.Errorf("truncated escape in key segment %q", segment)
+		}
+		c, err := strconv.ParseUint(segment[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in key segment %q", segment)
+		}
+		b = append(b, byte(c))
+		i += 2
+	}
+	return string(b), nil
+}
+
+// patternSegment returns the key pattern segment matching exactly value, or
+// any value when value is empty.
+func patternSegment(value string) string {
+	if value == "" {
+		return anySegment
+	}
+	return keySegment(value)
+}
+
+// parseServiceKey splits a service key into its decoded segments, in the
+// order of ServiceKeyPattern. It returns false for any other key.
+func parseServiceKey(key string) ([]string, bool) {
+	if strings.HasPrefix(key, internalKeyPrefix) {
+		return nil, false
+	}
+	parts := strings.Split(key, ":")
+	if len(parts) != strings.Count(ServiceKeyPattern, "%s") {
+		return nil, false
+	}
+	for i, part := range parts {
+		decoded, err := decodeKeySegment(part)
+		if err != nil {
+			return nil, false
+		}
+		parts[i] = decoded
+	}
+	return parts, true
+}
+
+// findServiceKeys returns the service keys matching pattern, leaving out the
+// other keys a glob can match.
+func findServiceKeys(client redisclient.Client, pattern string) ([]string, error) {
+	keys, err := client.FindKeys(pattern)
+	if err != nil {
+		return nil, err
+	}
+	serviceKeys := keys[:0]
+	for _, key := range keys {
+		if _, ok := parseServiceKey(key); ok {
+			serviceKeys = append(serviceKeys, key)
+		}
+	}
+	return serviceKeys, nil
+}
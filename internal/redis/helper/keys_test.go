@@ -0,0 +1,115 @@
+package redishelper
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	boltclient "github.com/tahakara/discogo/internal/bolt"
+)
+
+func FuzzKeySegment(f *testing.F) {
+	for _, seed := range []string{"", "gw", "eu-west-1", "a:b", "*", "?", "[a-z]", `\`, "%", "%3A", "a b", "\x00\x7f", "ü"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		segment := keySegment(value)
+		if i := strings.IndexAny(segment, ":*?[]\\"); i >= 0 {
+			t.Fatalf("keySegment(%q) = %q keeps reserved byte %q", value, segment, segment[i])
+		}
+		for i := 0; i < len(segment); i++ {
+			if c := segment[i]; c <= ' ' || c == 0x7f {
+				t.Fatalf("keySegment(%q) = %q keeps control byte %#x", value, segment, c)
+			}
+		}
+		decoded, err := decodeKeySegment(segment)
+		if err != nil {
+			t.Fatalf("decodeKeySegment(%q): %v", segment, err)
+		}
+		if decoded != value {
+			t.Fatalf("decodeKeySegment(keySegment(%q)) = %q", value, decoded)
+		}
+	})
+}
+
+func FuzzParseServiceKey(f *testing.F) {
+	entry := ServiceEntry{ServiceUUID: "6f1c", Name: "api:1", Type: "gw", Status: StatusHealthy, Provider: "aws", Region: "r*", Zone: "z?", NetworkID: "[n]", SubnetID: "s", InstanceID: "i-1", Version: "1.0.0"}
+	for _, seed := range []string{
+		_GenerateServiceKey(entry),
+		"a:b:c:d:e:f:g:h:i:j:k",
+		"a:b:c",
+		"discogo:lock:register:x:e:f:g:h:i:j:k",
+		"a:b:c:d:e:f:g:h:i:j:%",
+		"a:b:c:d:e:f:g:h:i:j:%zz",
+		"discog%6F:b:c:d:e:f:g:h:i:j:k",
+	} {
+		f.Add(seed)
+	}
+	segments := strings.Count(ServiceKeyPattern, "%s")
+	f.Fuzz(func(t *testing.T, key string) {
+		parts, ok := parseServiceKey(key)
+		if !ok {
+			return
+		}
+		if strings.HasPrefix(key, internalKeyPrefix) {
+			t.Fatalf("parseServiceKey accepted internal key %q", key)
+		}
+		if len(parts) != segments {
+			t.Fatalf("parseServiceKey(%q) returned %d segments, want %d", key, len(parts), segments)
+		}
+		// Re-encoding the decoded segments gives a key with the same meaning
+		encoded := make([]string, len(parts))
+		for i, part := range parts {
+			encoded[i] = keySegment(part)
+		}
+		reencoded := strings.Join(encoded, ":")
+		if strings.HasPrefix(reencoded, internalKeyPrefix) {
+			// Only an escaped first segment decodes to the internal prefix;
+			// stored keys start with a UUID and never do.
+			return
+		}
+		again, ok := parseServiceKey(reencoded)
+		if !ok {
+			t.Fatalf("parseServiceKey rejected the re-encoded segments of %q", key)
+		}
+		for i := range parts {
+			if again[i] != parts[i] {
+				t.Fatalf("segment %d of %q is %q after re-encoding, want %q", i, key, again[i], parts[i])
+			}
+		}
+	})
+}
+
+func TestServicesFilteredMatchesGlobBytesLiterally(t *testing.T) {
+	client, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	// Registration rejects these values; stored anyway, a filter for one
+	// still matches it and nothing else.
+	regions := []string{"r*", "r?", "r[1]", "r:1", "r1"}
+	for i, region := range regions {
+		entry := ServiceEntry{ServiceUUID: string(rune('a' + i)), Type: "gw", Status: StatusHealthy, Region: region}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Set(_GenerateServiceKey(entry), data, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, region := range regions {
+		services, err := GetServicesFiltered(client, "gw", StatusAny, "", region, "", "", "", "", "", 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(services) != 1 || services[0].Region != region {
+			t.Errorf("region filter %q matched %+v, want only that region", region, services)
+		}
+	}
+}
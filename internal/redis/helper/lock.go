@@ -0,0 +1,46 @@
+package redishelper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Locks are keys taken with SETNX that hold a random token and expire after
+// their TTL, so a crashed holder blocks the others only that long.
+const lockPoll = 20 * time.Millisecond
+
+// acquireLock takes the lock at key for ttl, polling for up to wait while
+// another holder has it. It returns false when the wait ran out. The release
+// func must be called once the protected work is done.
+func acquireLock(client redisclient.Client, key string, ttl, wait time.Duration) (func(), bool, error) {
+	token := []byte(uuid.New().String())
+	deadline := time.Now().Add(wait)
+
+	for {
+		stored, err := client.Add(key, token, ttl)
+		if err != nil {
+			return nil, false, err
+		}
+		if stored {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, false, nil
+		}
+		time.Sleep(lockPoll)
+	}
+
+	release := func() {
+		// Only drop the lock while it is still ours; after ttl it may belong
+		// to the next holder. The check and the delete are one step, so the
+		// lock cannot change hands in between.
+		if _, err := client.CompareAndDelete(key, token); err != nil {
+			logger.Error(fmt.Sprintf("Failed to release lock %s: %v", key, err), 0)
+		}
+	}
+	return release, true, nil
+}
@@ -0,0 +1,307 @@
+package redishelper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Outcome counters live at discogo:outlier:<window>:<uuid>:ok|err, where
+// window is the Unix start of the aggregation interval. They are created with
+// a TTL and incremented in place (INCRBY keeps the TTL), so they need no
+// cleanup. Each interval is evaluated once, by the replica that claims it.
+const (
+	outlierKeyPrefix      = "discogo:outlier:"
+	outlierClaimKeyPrefix = "discogo:lock:outlier:"
+	outlierSuccessSuffix  = ":ok"
+	outlierFailureSuffix  = ":err"
+)
+
+// OutlierPolicy holds the thresholds of outlier ejection.
+type OutlierPolicy struct {
+	// MinRequests is how many requests an instance needs in an interval to
+	// be evaluated.
+	MinRequests int64
+	// MinHosts is how many instances of a type need MinRequests before any
+	// of them is ejected.
+	MinHosts int
+	// StdevFactor is how many standard deviations above the mean error rate
+	// of its type make an instance an outlier.
+	StdevFactor float64
+	// BaseEjectionTime is the first ejection; each further one doubles it.
+	BaseEjectionTime time.Duration
+	// MaxEjectionTime caps the ejection time.
+	MaxEjectionTime time.Duration
+	// MaxEjectionPercent caps the share of a type's instances ejected at
+	// once. At least one and never all instances may be ejected.
+	MaxEjectionPercent int
+}
+
+// OutlierWindow returns the Unix start of the aggregation interval of length
+// interval that holds t.
+func OutlierWindow(t time.Time, interval time.Duration) int64 {
+	seconds := int64(interval / time.Second)
+	return t.Unix() / seconds * seconds
+}
+
+// ejectionTime returns how long the ejectionCount-th ejection lasts.
+func (p OutlierPolicy) ejectionTime(ejectionCount int) time.Duration {
+	d := p.BaseEjectionTime
+	for i := 1; i < ejectionCount && d < p.MaxEjectionTime; i++ {
+		d *= 2
+	}
+	if d > p.MaxEjectionTime {
+		d = p.MaxEjectionTime
+	}
+	return d
+}
+
+// maxEjected returns how many of n instances may be ejected at once.
+func (p OutlierPolicy) maxEjected(n int) int {
+	allowed := n * p.MaxEjectionPercent / 100
+	if allowed < 1 {
+		allowed = 1
+	}
+	if allowed > n-1 {
+		allowed = n - 1
+	}
+	return allowed
+}
+
+// Ejected reports whether e is ejected as an outlier at now.
+func (e ServiceEntry) Ejected(now time.Time) bool {
+	until, err := time.Parse(time.RFC3339, e.EjectedUntil)
+	return err == nil && now.Before(until)
+}
+
+// RequestOutcome is the number of requests a client saw succeed and fail
+// against one instance.
+type RequestOutcome struct {
+	ServiceUUID string
+	Successes   int64
+	Failures    int64
+}
+
+func outlierCounterKey(window int64, serviceUUID, suffix string) string {
+	return outlierKeyPrefix + strconv.FormatInt(window, 10) + ":" + serviceUUID + suffix
+}
+
+// RecordRequestOutcomes adds outcomes to the counters of the current
+// aggregation interval.
+func RecordRequestOutcomes(client redisclient.Client, outcomes []RequestOutcome, interval time.Duration) error {
+	window := OutlierWindow(time.Now(), interval)
+	// Counters outlive their interval long enough to be evaluated
+	ttl := 3 * interval
+	add := func(key string, delta int64) error {
+		if delta == 0 {
+			return nil
+		}
+		if _, err := client.Add(key, []byte("0"), ttl); err != nil {
+			return err
+		}
+		_, err := client.Increment(key, delta)
+		return err
+	}
+	for _, outcome := range outcomes {
+		if err := add(outlierCounterKey(window, outcome.ServiceUUID, outlierSuccessSuffix), outcome.Successes); err != nil {
+			return err
+		}
+		if err := add(outlierCounterKey(window, outcome.ServiceUUID, outlierFailureSuffix), outcome.Failures); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimOutlierWindow reports whether this replica won the right to evaluate
+// the aggregation interval starting at window.
+func ClaimOutlierWindow(client redisclient.Client, window int64, replicaID string, interval time.Duration) (bool, error) {
+	return client.Add(outlierClaimKeyPrefix+strconv.FormatInt(window, 10), []byte(replicaID), 3*interval)
+}
+
+type requestCounts struct {
+	successes int64
+	failures  int64
+}
+
+func (c requestCounts) total() int64 {
+	return c.successes + c.failures
+}
+
+func (c requestCounts) errorRate() float64 {
+	return float64(c.failures) / float64(c.total())
+}
+
+// outlierCounts reads the counters of the interval starting at window.
+func outlierCounts(client redisclient.Client, window int64) (map[string]requestCounts, error) {
+	prefix := outlierKeyPrefix + strconv.FormatInt(window, 10) + ":"
+	keys, err := client.FindKeys(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		pipe.Get(key)
+	}
+	results, err := pipe.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]requestCounts{}
+	for i, result := range results {
+		if result.Err != nil || result.Value == nil {
+			continue
+		}
+		n, err := strconv.ParseInt(string(result.Value), 10, 64)
+		if err != nil {
+			continue
+		}
+		rest := strings.TrimPrefix(keys[i], prefix)
+		switch {
+		case strings.HasSuffix(rest, outlierSuccessSuffix):
+			uuid := strings.TrimSuffix(rest, outlierSuccessSuffix)
+			c := counts[uuid]
+			c.successes += n
+			counts[uuid] = c
+		case strings.HasSuffix(rest, outlierFailureSuffix):
+			uuid := strings.TrimSuffix(rest, outlierFailureSuffix)
+			c := counts[uuid]
+			c.failures += n
+			counts[uuid] = c
+		}
+	}
+	return counts, nil
+}
+
+// OutlierEjection describes an instance ejected by EvaluateOutliers.
+type OutlierEjection struct {
+	Entry     ServiceEntry
+	ErrorRate float64
+	// Threshold is the error rate above which an instance of its type was
+	// an outlier in the evaluated interval.
+	Threshold float64
+	Duration  time.Duration
+}
+
+// EvaluateOutliers compares the error rates reported in the interval
+// starting at window between instances of the same type and ejects the
+// outliers, keeping each type within policy.MaxEjectionPercent. Instances
+// that were not ejected step their ejection backoff down by one.
+func EvaluateOutliers(client redisclient.Client, window int64, policy OutlierPolicy) ([]OutlierEjection, error) {
+	startTime := time.Now()
+	counts, err := outlierCounts(client, window)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := allServiceEntries(client)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byType := map[string][]ServiceEntry{}
+	for _, entry := range entries {
+		byType[entry.Type] = append(byType[entry.Type], entry)
+	}
+
+	var ejections []OutlierEjection
+	ejectedNow := map[string]bool{}
+	for _, siblings := range byType {
+		for _, ejection := range typeOutliers(siblings, counts, policy, now) {
+			ejectedNow[ejection.Entry.ServiceUUID] = true
+			entry, err := updateOutlierState(client, ejection.Entry.ServiceUUID, func(e *ServiceEntry) {
+				e.EjectionCount++
+				ejection.Duration = policy.ejectionTime(e.EjectionCount)
+				e.EjectedUntil = now.Add(ejection.Duration).UTC().Format(time.RFC3339)
+				e.ErrorRate = ejection.ErrorRate
+			})
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to eject %s: %v", ejection.Entry.ServiceUUID, err), time.Since(startTime))
+				continue
+			}
+			ejection.Entry = entry
+			ejections = append(ejections, ejection)
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.EjectionCount == 0 || ejectedNow[entry.ServiceUUID] || entry.Ejected(now) {
+			continue
+		}
+		_, err := updateOutlierState(client, entry.ServiceUUID, func(e *ServiceEntry) {
+			e.EjectionCount--
+			if e.EjectionCount == 0 {
+				e.EjectedUntil = ""
+			}
+		})
+		if err != nil && !errors.Is(err, ErrServiceNotFound) {
+			logger.Error(fmt.Sprintf("Failed to update ejection backoff of %s: %v", entry.ServiceUUID, err), time.Since(startTime))
+		}
+	}
+	return ejections, nil
+}
+
+// typeOutliers returns the instances among siblings, all of one type, whose
+// error rate is an outlier, worst first and within the ejection cap.
+func typeOutliers(siblings []ServiceEntry, counts map[string]requestCounts, policy OutlierPolicy, now time.Time) []OutlierEjection {
+	ejected := 0
+	var candidates []OutlierEjection
+	var sum float64
+	for _, entry := range siblings {
+		if entry.Ejected(now) {
+			ejected++
+			continue
+		}
+		c := counts[entry.ServiceUUID]
+		if c.total() < policy.MinRequests {
+			continue
+		}
+		candidates = append(candidates, OutlierEjection{Entry: entry, ErrorRate: c.errorRate()})
+		sum += c.errorRate()
+	}
+	if len(candidates) < policy.MinHosts {
+		return nil
+	}
+	allowed := policy.maxEjected(len(siblings)) - ejected
+	if allowed <= 0 {
+		return nil
+	}
+
+	mean := sum / float64(len(candidates))
+	var variance float64
+	for _, c := range candidates {
+		variance += (c.ErrorRate - mean) * (c.ErrorRate - mean)
+	}
+	threshold := mean + policy.StdevFactor*math.Sqrt(variance/float64(len(candidates)))
+
+	var outliers []OutlierEjection
+	for _, c := range candidates {
+		if c.ErrorRate > threshold {
+			c.Threshold = threshold
+			outliers = append(outliers, c)
+		}
+	}
+	sort.Slice(outliers, func(i, j int) bool { return outliers[i].ErrorRate > outliers[j].ErrorRate })
+	if len(outliers) > allowed {
+		outliers = outliers[:allowed]
+	}
+	return outliers
+}
+
+// updateOutlierState applies update to the entry of serviceUUID under its
+// entry lock while keeping its remaining TTL.
+func updateOutlierState(client redisclient.Client, serviceUUID string, update func(e *ServiceEntry)) (ServiceEntry, error) {
+	return modifyServiceEntry(client, serviceUUID, 0, func(e *ServiceEntry) error {
+		update(e)
+		return nil
+	})
+}
@@ -0,0 +1,121 @@
+package redishelper
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEjectionTime(t *testing.T) {
+	policy := OutlierPolicy{BaseEjectionTime: 30 * time.Second, MaxEjectionTime: 300 * time.Second}
+	tests := []struct {
+		ejections int
+		want      time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, 60 * time.Second},
+		{3, 120 * time.Second},
+		{4, 240 * time.Second},
+		{5, 300 * time.Second},
+		{50, 300 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.ejectionTime(tt.ejections); got != tt.want {
+			t.Errorf("ejectionTime(%d) = %v, want %v", tt.ejections, got, tt.want)
+		}
+	}
+
+	capped := OutlierPolicy{BaseEjectionTime: time.Minute, MaxEjectionTime: 30 * time.Second}
+	if got := capped.ejectionTime(1); got != 30*time.Second {
+		t.Errorf("ejectionTime with a base above the cap = %v, want the cap", got)
+	}
+}
+
+func TestMaxEjected(t *testing.T) {
+	tests := []struct {
+		percent   int
+		instances int
+		want      int
+	}{
+		{10, 1, 0}, // never all instances
+		{10, 2, 1}, // at least one
+		{10, 10, 1},
+		{10, 25, 2},
+		{10, 100, 10},
+		{0, 5, 1},
+		{100, 5, 4},
+	}
+	for _, tt := range tests {
+		policy := OutlierPolicy{MaxEjectionPercent: tt.percent}
+		if got := policy.maxEjected(tt.instances); got != tt.want {
+			t.Errorf("maxEjected(%d) at %d%% = %d, want %d", tt.instances, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestTypeOutliers(t *testing.T) {
+	now := time.Now()
+	policy := OutlierPolicy{MinRequests: 100, MinHosts: 3, StdevFactor: 1.9, MaxEjectionPercent: 50}
+
+	// instances builds one entry per error rate, each with requests requests
+	instances := func(requests int64, rates ...float64) ([]ServiceEntry, map[string]requestCounts) {
+		var siblings []ServiceEntry
+		counts := map[string]requestCounts{}
+		for i, rate := range rates {
+			uuid := fmt.Sprintf("i-%d", i)
+			siblings = append(siblings, ServiceEntry{ServiceUUID: uuid})
+			failures := int64(math.Round(rate * float64(requests)))
+			counts[uuid] = requestCounts{successes: requests - failures, failures: failures}
+		}
+		return siblings, counts
+	}
+
+	tests := []struct {
+		name     string
+		policy   OutlierPolicy
+		requests int64
+		rates    []float64
+		ejected  []int // indexes of siblings already ejected
+		want     []string
+	}{
+		{"one outlier", policy, 100, []float64{0, 0, 0, 0, 0.5}, nil, []string{"i-4"}},
+		{"equal error rates", policy, 100, []float64{0.5, 0.5, 0.5, 0.5}, nil, nil},
+		{"within the deviation", policy, 100, []float64{0.1, 0.12, 0.09, 0.11}, nil, nil},
+		{"too few requests", policy, 99, []float64{0, 0, 0, 0, 0.5}, nil, nil},
+		{"fewer than MinHosts", policy, 100, []float64{0, 0.9}, nil, nil},
+		{"single instance", OutlierPolicy{MinRequests: 100, MinHosts: 1, StdevFactor: 0, MaxEjectionPercent: 100}, 100, []float64{1}, nil, nil},
+		{
+			"worst first within the cap",
+			OutlierPolicy{MinRequests: 100, MinHosts: 3, StdevFactor: 1, MaxEjectionPercent: 20},
+			100, []float64{0, 0, 0, 0, 0, 0, 0, 0.7, 0.9, 0.8}, nil,
+			[]string{"i-8", "i-9"},
+		},
+		{
+			"ejected instances count against the cap",
+			OutlierPolicy{MinRequests: 100, MinHosts: 3, StdevFactor: 1, MaxEjectionPercent: 20},
+			100, []float64{0, 0, 0, 0, 0, 0, 0, 0.7, 0.9, 0.8}, []int{0},
+			[]string{"i-8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			siblings, counts := instances(tt.requests, tt.rates...)
+			for _, i := range tt.ejected {
+				siblings[i].EjectedUntil = now.Add(time.Minute).UTC().Format(time.RFC3339)
+			}
+			outliers := typeOutliers(siblings, counts, tt.policy, now)
+			var got []string
+			for _, o := range outliers {
+				got = append(got, o.Entry.ServiceUUID)
+				if o.ErrorRate <= o.Threshold {
+					t.Errorf("%s ejected at error rate %.2f under threshold %.2f", o.Entry.ServiceUUID, o.ErrorRate, o.Threshold)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("outliers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package redishelper
+
+import (
+	"strconv"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Shared rate limit buckets are counters at discogo:ratelimit:<key>:<window>,
+// created with a TTL and incremented in place like the outlier counters.
+const rateLimitKeyPrefix = "discogo:ratelimit:"
+
+// TakeRateLimitToken counts one request of key against a bucket that refills
+// rate tokens per second up to burst. Replicas share the bucket through the
+// backend, which only offers counters, so it is approximated by fixed
+// windows of ceil(burst/rate) seconds. A window allows the larger of burst
+// and what the rate refills in it, so neither the burst nor the sustained
+// rate is cut short. When the bucket is empty it returns how long until the
+// next window.
+func TakeRateLimitToken(client redisclient.Client, key string, rate, burst int) (bool, time.Duration, error) {
+	seconds := int64((burst + rate - 1) / rate)
+	if seconds < 1 {
+		seconds = 1
+	}
+	allowance := max(int64(burst), int64(rate)*seconds)
+	now := time.Now()
+	window := now.Unix() / seconds * seconds
+	// key holds client addresses, IPv6 ones full of ':'
+	counterKey := rateLimitKeyPrefix + keySegment(key) + ":" + strconv.FormatInt(window, 10)
+
+	if _, err := client.Add(counterKey, []byte("0"), time.Duration(seconds+1)*time.Second); err != nil {
+		return false, 0, err
+	}
+	n, err := client.Increment(counterKey, 1)
+	if err != nil {
+		return false, 0, err
+	}
+	if n <= allowance {
+		return true, 0, nil
+	}
+	return false, time.Unix(window+seconds, 0).Sub(now), nil
+}
@@ -0,0 +1,29 @@
+package redishelper
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	boltclient "github.com/tahakara/discogo/internal/bolt"
+)
+
+func TestTakeRateLimitTokenAllowsRateAboveBurst(t *testing.T) {
+	client, err := boltclient.New(filepath.Join(t.TempDir(), "discogo.db"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	// A one second window refills rate tokens, more than the burst
+	const rate, burst = 5, 2
+	for i := 0; i < rate; i++ {
+		allowed, _, err := TakeRateLimitToken(client, "ip:203.0.113.7", rate, burst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !allowed {
+			t.Fatalf("request %d of a window was limited, want %d allowed per second", i+1, rate)
+		}
+	}
+}
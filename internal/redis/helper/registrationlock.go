@@ -0,0 +1,42 @@
+package redishelper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Registration locks serialise registrations of one instance identity. The
+// identity is hashed so the lock key never matches a service key pattern.
+const (
+	registrationLockKeyPrefix = "discogo:lock:register:"
+	// registrationLockTTL bounds how long a crashed holder blocks the identity.
+	registrationLockTTL = 10 * time.Second
+)
+
+// ErrRegistrationLocked is returned when another registration of the same
+// identity holds the lock for longer than the caller is willing to wait.
+var ErrRegistrationLocked = errors.New("registration of this identity is in progress")
+
+func registrationLockKey(entry ServiceEntry) string {
+	identity := _GenerateCredentialBasedSearchKey(entry.Type, entry.Provider, entry.Region, entry.Zone, entry.NetworkID, entry.SubnetID, entry.InstanceID, entry.Version)
+	sum := sha256.Sum256([]byte(identity))
+	return registrationLockKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// AcquireRegistrationLock takes the lock of entry's identity with SETNX,
+// polling for up to wait while another registration holds it. The returned
+// release func must be called once the registration is stored.
+func AcquireRegistrationLock(client redisclient.Client, entry ServiceEntry, wait time.Duration) (func(), error) {
+	release, acquired, err := acquireLock(client, registrationLockKey(entry), registrationLockTTL, wait)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrRegistrationLocked
+	}
+	return release, nil
+}
@@ -0,0 +1,130 @@
+package redishelper
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// ServiceReport is a complaint about an instance by another registered
+// instance. An entry keeps at most one report per reporter, the latest.
+type ServiceReport struct {
+	ReporterUUID string  // (Client) UUID of the reporting instance
+	Weight       float64 // (DISCO) Reporter reputation at report time, 0-1
+	Reason       string  // (Client) Free-form reason
+	At           string  // (DISCO) RFC3339 timestamp of the report
+}
+
+// reporterWeights is the reputation of a reporter by its own status. A
+// suspicious reporter cannot push others over the threshold.
+var reporterWeights = map[ServiceStatus]float64{
+	StatusHealthy:    1,
+	StatusDegraded:   0.5,
+	StatusRegistered: 0.5,
+	StatusUnknown:    0.25,
+	StatusSuspicious: 0,
+}
+
+// ReporterWeight returns the weight of reports sent by reporter. Without
+// weighting every reporter counts fully.
+func ReporterWeight(reporter ServiceEntry, weighted bool) float64 {
+	if !weighted {
+		return 1
+	}
+	return reporterWeights[reporter.Status]
+}
+
+// reportScore drops the reports older than the policy window and returns the
+// decayed, weighted sum of the rest. Each reporter counts at most once.
+func (e *ServiceEntry) reportScore(now time.Time, policy StatusPolicy) float64 {
+	var score float64
+	kept := e.Reports[:0]
+	for _, report := range e.Reports {
+		at, err := time.Parse(time.RFC3339, report.At)
+		if err != nil {
+			continue
+		}
+		age := now.Sub(at)
+		if age > policy.ReportWindow {
+			continue
+		}
+		kept = append(kept, report)
+
+		weight := report.Weight
+		if policy.ReportHalfLife > 0 && age > 0 {
+			weight *= math.Exp2(-age.Seconds() / policy.ReportHalfLife.Seconds())
+		}
+		score += weight
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	e.Reports = kept
+	return score
+}
+
+// overReportThreshold reports whether e collected enough recent reports to
+// become suspicious, and describes them.
+func (e *ServiceEntry) overReportThreshold(policy StatusPolicy) (bool, string) {
+	score := e.reportScore(time.Now(), policy)
+	reason := fmt.Sprintf("%d reporters within %s (score %.2f)", len(e.Reports), policy.ReportWindow, score)
+	return score >= float64(policy.ReportThreshold), reason
+}
+
+// applyReport records report on e, replacing an earlier report of the same
+// reporter, and moves a live instance to suspicious once the threshold is
+// reached. A report interrupts the recovery of a suspicious instance.
+func (e *ServiceEntry) applyReport(report ServiceReport, policy StatusPolicy) (StatusChange, error) {
+	report.At = utils.GetFormatedCurrentTime()
+	e.ReportCount++
+	e.LastReportAt = report.At
+	e.RecoveryHeartbeats = 0
+
+	reports := e.Reports[:0]
+	for _, existing := range e.Reports {
+		if existing.ReporterUUID != report.ReporterUUID {
+			reports = append(reports, existing)
+		}
+	}
+	e.Reports = append(reports, report)
+
+	over, reason := e.overReportThreshold(policy)
+	if !over || e.Status == StatusSuspicious || e.Status == StatusDeregistered {
+		return StatusChange{From: e.Status, To: e.Status}, nil
+	}
+	return e.transition(StatusSuspicious, TriggerReports, reason)
+}
+
+// ReportSummary describes the reports an entry holds after a new one.
+type ReportSummary struct {
+	Reporters int
+	Score     float64
+}
+
+// RecordReport stores report against serviceUUID while keeping the entry's
+// remaining TTL, and returns the updated entry with its status change.
+func RecordReport(client redisclient.Client, serviceUUID string, report ServiceReport, policy StatusPolicy) (ServiceEntry, ReportSummary, StatusChange, error) {
+	var (
+		change  StatusChange
+		summary ReportSummary
+	)
+	entry, err := modifyServiceEntry(client, serviceUUID, 0, func(e *ServiceEntry) error {
+		var err error
+		change, err = e.applyReport(report, policy)
+		if err != nil {
+			return err
+		}
+		summary = ReportSummary{
+			Reporters: len(e.Reports),
+			Score:     e.reportScore(time.Now(), policy),
+		}
+		return nil
+	})
+	if err != nil {
+		return ServiceEntry{}, ReportSummary{}, StatusChange{}, err
+	}
+	return entry, summary, change, nil
+}
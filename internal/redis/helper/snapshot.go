@@ -0,0 +1,196 @@
+package redishelper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// SnapshotVersion is bumped whenever the snapshot layout changes incompatibly.
+const SnapshotVersion = 1
+
+type SnapshotEntry struct {
+	Entry ServiceEntry `json:"entry"`
+	TTL   int64        `json:"ttl"` // remaining time to live in seconds at export time
+}
+
+type Snapshot struct {
+	Version   int             `json:"version"`
+	CreatedAt string          `json:"createdAt"`
+	Entries   []SnapshotEntry `json:"entries"`
+}
+
+type SnapshotImportMode string
+
+// An imported entry conflicts with the stored entries that have its UUID or
+// its instance identity.
+const (
+	SnapshotImportSkip      SnapshotImportMode = "skip"      // keep the existing entries on conflict
+	SnapshotImportOverwrite SnapshotImportMode = "overwrite" // replace the existing entries on conflict
+)
+
+type SnapshotImportResult struct {
+	Imported    int `json:"imported"`
+	Skipped     int `json:"skipped"`
+	Overwritten int `json:"overwritten"`
+	Failed      int `json:"failed"`
+}
+
+func IsValidSnapshotImportMode(mode string) bool {
+	return mode == string(SnapshotImportSkip) || mode == string(SnapshotImportOverwrite)
+}
+
+// ExportSnapshot dumps every service entry together with its remaining TTL.
+func ExportSnapshot(client redisclient.Client) (Snapshot, error) {
+	startTime := time.Now()
+	snapshot := Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: utils.GetFormatedCurrentTime(),
+		Entries:   []SnapshotEntry{},
+	}
+
+	keys, err := findServiceKeys(client, allServiceKeysPattern)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	for _, key := range keys {
+		data, err := client.Get(key)
+		if err != nil || data == nil {
+			continue
+		}
+		ttl, err := client.TTL(key)
+		if err != nil || ttl < 0 {
+			// Expired between the scan and the lookup
+			continue
+		}
+
+		var entry ServiceEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+
+		snapshot.Entries = append(snapshot.Entries, SnapshotEntry{
+			Entry: entry,
+			TTL:   int64(ttl.Round(time.Second) / time.Second),
+		})
+	}
+
+	logger.Info(fmt.Sprintf("Exported snapshot with %d entries", len(snapshot.Entries)), time.Since(startTime))
+	return snapshot, nil
+}
+
+// ImportSnapshot restores the entries of a snapshot, resolving conflicts with
+// stored entries of the same UUID or instance identity according to mode.
+// Each entry is imported under its identity lock, waiting up to lockWait, so
+// it cannot race a registration of the same instance.
+func ImportSnapshot(client redisclient.Client, snapshot Snapshot, mode SnapshotImportMode, lockWait time.Duration) (SnapshotImportResult, error) {
+	startTime := time.Now()
+	var result SnapshotImportResult
+
+	if snapshot.Version != SnapshotVersion {
+		return result, fmt.Errorf("unsupported snapshot version %d (expected %d)", snapshot.Version, SnapshotVersion)
+	}
+	if !IsValidSnapshotImportMode(string(mode)) {
+		return result, errors.New("invalid snapshot import mode")
+	}
+
+	for _, item := range snapshot.Entries {
+		if item.Entry.ServiceUUID == "" {
+			result.Failed++
+			continue
+		}
+		outcome, err := importSnapshotEntry(client, item, mode, lockWait)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to import service entry %s: %v", item.Entry.ServiceUUID, err), time.Since(startTime))
+			result.Failed++
+			continue
+		}
+		switch outcome {
+		case importSkipped:
+			result.Skipped++
+		case importOverwritten:
+			result.Overwritten++
+		default:
+			result.Imported++
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Imported snapshot: %d imported, %d overwritten, %d skipped, %d failed", result.Imported, result.Overwritten, result.Skipped, result.Failed), time.Since(startTime))
+	return result, nil
+}
+
+type importOutcome int
+
+const (
+	importImported importOutcome = iota
+	importSkipped
+	importOverwritten
+)
+
+// importSnapshotEntry stores one snapshot entry. It holds the identity lock of
+// the entry and the entry locks of every conflicting UUID, so neither a
+// registration nor a heartbeat can bring a replaced entry back.
+func importSnapshotEntry(client redisclient.Client, item SnapshotEntry, mode SnapshotImportMode, lockWait time.Duration) (importOutcome, error) {
+	entry := item.Entry
+	releaseIdentity, err := AcquireRegistrationLock(client, entry, lockWait)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseIdentity()
+
+	// The identity lock keeps other entries from taking the identity, so
+	// the UUID found here stays the one holding it.
+	uuids := []string{entry.ServiceUUID}
+	if found, existing := IsServiceExists(client, entry); found && existing.ServiceUUID != entry.ServiceUUID {
+		uuids = append(uuids, existing.ServiceUUID)
+	}
+	releaseEntries, err := lockServiceEntries(client, uuids)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseEntries()
+
+	var conflicting []string
+	for _, serviceUUID := range uuids {
+		keys, err := findServiceKeys(client, serviceUUIDPattern(serviceUUID))
+		if err != nil {
+			return 0, err
+		}
+		conflicting = append(conflicting, keys...)
+	}
+	if len(conflicting) > 0 && mode == SnapshotImportSkip {
+		return importSkipped, nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	ttl := time.Duration(item.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	newKey := _GenerateServiceKey(entry)
+	for _, key := range conflicting {
+		if key == newKey {
+			continue
+		}
+		if err := client.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	if err := client.Set(newKey, data, ttl); err != nil {
+		return 0, err
+	}
+
+	if len(conflicting) > 0 {
+		return importOverwritten, nil
+	}
+	return importImported, nil
+}
@@ -0,0 +1,201 @@
+package redishelper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tahakara/discogo/internal/utils"
+)
+
+// Every status change of a service entry goes through transition, which
+// checks it against legalTransitions and appends it to the entry's history.
+//
+//	registered ──heartbeat──▶ healthy ◀──▶ degraded
+//	    │                        │  ▲          │
+//	    │        check failing / │  │ check passing,
+//	    │        self "failing"  ▼  │ heartbeat
+//	    └──────────────────────▶ unknown
+//
+//	any live status ──reports──▶ suspicious ──recovery──▶ healthy / degraded
+//
+// Recovery: a suspicious instance that sends StatusPolicy.RecoveryHeartbeats
+// good heartbeats in a row without new reports returns to the status its
+// heartbeats report, with its reports cleared. Admin overrides may move an
+// entry to any status.
+
+// StatusTrigger is what caused a status transition.
+type StatusTrigger string
+
+const (
+	TriggerRegister    StatusTrigger = "register"
+	TriggerHeartbeat   StatusTrigger = "heartbeat"
+	TriggerHealthCheck StatusTrigger = "health-check"
+	TriggerReports     StatusTrigger = "reports"
+	TriggerRecovery    StatusTrigger = "recovery"
+	TriggerAdmin       StatusTrigger = "admin"
+)
+
+// maxStatusHistory bounds the transitions kept per entry; older ones are dropped.
+const maxStatusHistory = 20
+
+// ErrIllegalTransition is returned for a status change the state machine does
+// not allow.
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// StatusTransition is one entry of a service's status history.
+type StatusTransition struct {
+	From    ServiceStatus // (DISCO) Status before the transition, empty for the initial one
+	To      ServiceStatus // (DISCO) Status after the transition
+	Trigger StatusTrigger // (DISCO) What caused the transition
+	Reason  string        // (DISCO) Details, e.g. the failing check output
+	At      string        // (DISCO) RFC3339 timestamp of the transition
+}
+
+// StatusPolicy holds the thresholds the state machine applies on heartbeats.
+type StatusPolicy struct {
+	// ReportThreshold is the weighted number of distinct reporters within
+	// ReportWindow that makes an instance suspicious.
+	ReportThreshold int64
+	// ReportWindow is how long a report counts.
+	ReportWindow time.Duration
+	// ReportHalfLife halves the weight of a report every interval of its
+	// age. 0 keeps reports at full weight for the whole window.
+	ReportHalfLife time.Duration
+	// RecoveryHeartbeats is how many good heartbeats in a row bring a
+	// suspicious instance back. 0 keeps suspicious instances suspicious.
+	RecoveryHeartbeats int
+}
+
+var legalTransitions = map[ServiceStatus][]ServiceStatus{
+	"":                 {StatusRegistered},
+	StatusRegistered:   {StatusHealthy, StatusDegraded, StatusUnknown, StatusSuspicious, StatusDeregistered},
+	StatusHealthy:      {StatusDegraded, StatusUnknown, StatusSuspicious, StatusDeregistered},
+	StatusDegraded:     {StatusHealthy, StatusUnknown, StatusSuspicious, StatusDeregistered},
+	StatusUnknown:      {StatusRegistered, StatusHealthy, StatusDegraded, StatusSuspicious, StatusDeregistered},
+	StatusSuspicious:   {StatusHealthy, StatusDegraded, StatusDeregistered},
+	StatusDeregistered: {},
+}
+
+// CanTransition reports whether the state machine allows from -> to outside
+// of admin overrides.
+func CanTransition(from, to ServiceStatus) bool {
+	for _, allowed := range legalTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transition moves e to status to and records it in e.StatusHistory. Staying
+// in the same status is a no-op.
+func (e *ServiceEntry) transition(to ServiceStatus, trigger StatusTrigger, reason string) (StatusChange, error) {
+	change := StatusChange{From: e.Status, To: to, Trigger: trigger, Reason: reason}
+	if !change.Changed() {
+		return change, nil
+	}
+	if trigger != TriggerAdmin && !CanTransition(e.Status, to) {
+		return StatusChange{From: e.Status, To: e.Status}, fmt.Errorf("%w: %s -> %s on %s", ErrIllegalTransition, e.Status, to, trigger)
+	}
+
+	e.Status = to
+	if to == StatusSuspicious {
+		e.RecoveryHeartbeats = 0
+	}
+	e.StatusHistory = append(e.StatusHistory, StatusTransition{
+		From:    change.From,
+		To:      to,
+		Trigger: trigger,
+		Reason:  reason,
+		At:      utils.GetFormatedCurrentTime(),
+	})
+	if len(e.StatusHistory) > maxStatusHistory {
+		e.StatusHistory = e.StatusHistory[len(e.StatusHistory)-maxStatusHistory:]
+	}
+	return change, nil
+}
+
+// liveStatus is the status the latest heartbeat and active check report,
+// before any report or suspicion is taken into account.
+func (e *ServiceEntry) liveStatus() ServiceStatus {
+	if e.Check != nil && e.Check.Failing {
+		// A live heartbeat does not outvote a failing active check
+		return StatusUnknown
+	}
+	if e.HeardCount == 0 {
+		return StatusRegistered
+	}
+	return heartbeatStatus(e.SelfStatus)
+}
+
+// applyHeartbeat records a heartbeat carrying payload on e and moves it to
+// the status the heartbeat reports. It returns ErrServiceSuspicious while the
+// instance is or becomes suspicious; the heartbeat is recorded regardless so
+// the instance can recover.
+func (e *ServiceEntry) applyHeartbeat(payload HeartbeatPayload, policy StatusPolicy) (StatusChange, error) {
+	e.recordHeartbeat(payload)
+
+	if e.Status != StatusSuspicious {
+		if over, reason := e.overReportThreshold(policy); over {
+			change, err := e.transition(StatusSuspicious, TriggerReports, reason)
+			if err != nil {
+				return change, err
+			}
+			return change, ErrServiceSuspicious
+		}
+		return e.transition(e.liveStatus(), TriggerHeartbeat, "")
+	}
+
+	// A heartbeat is good when neither the instance nor its check report a failure
+	if e.liveStatus() == StatusUnknown {
+		e.RecoveryHeartbeats = 0
+	} else {
+		e.RecoveryHeartbeats++
+	}
+	if policy.RecoveryHeartbeats == 0 || e.RecoveryHeartbeats < policy.RecoveryHeartbeats {
+		return StatusChange{From: e.Status, To: e.Status}, ErrServiceSuspicious
+	}
+
+	change, err := e.transition(e.liveStatus(), TriggerRecovery, fmt.Sprintf("%d good heartbeats without reports", e.RecoveryHeartbeats))
+	if err != nil {
+		return change, err
+	}
+	e.ReportCount = 0
+	e.Reports = nil
+	e.RecoveryHeartbeats = 0
+	return change, nil
+}
+
+// applyCheckResult records the outcome of an active check on e. After
+// failureThreshold failures in a row a live instance becomes unknown, and the
+// next passing check restores the status its heartbeats report. checkErr is
+// nil for a passing check.
+func (e *ServiceEntry) applyCheckResult(checkErr error, failureThreshold int) (StatusChange, error) {
+	check := e.Check
+	check.LastCheckedAt = utils.GetFormatedCurrentTime()
+	if checkErr == nil {
+		check.LastOutput = ""
+		check.ConsecutiveFailures = 0
+		if !check.Failing {
+			return StatusChange{From: e.Status, To: e.Status}, nil
+		}
+		check.Failing = false
+		if e.Status != StatusUnknown {
+			return StatusChange{From: e.Status, To: e.Status}, nil
+		}
+		return e.transition(e.liveStatus(), TriggerHealthCheck, "check passed")
+	}
+
+	check.LastOutput = checkErr.Error()
+	check.ConsecutiveFailures++
+	if check.ConsecutiveFailures < failureThreshold || check.Failing {
+		return StatusChange{From: e.Status, To: e.Status}, nil
+	}
+	check.Failing = true
+	switch e.Status {
+	case StatusHealthy, StatusDegraded, StatusRegistered:
+		return e.transition(StatusUnknown, TriggerHealthCheck, checkErr.Error())
+	}
+	return StatusChange{From: e.Status, To: e.Status}, nil
+}
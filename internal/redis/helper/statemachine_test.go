@@ -0,0 +1,148 @@
+package redishelper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    ServiceStatus
+		to      ServiceStatus
+		trigger StatusTrigger
+		wantErr bool
+	}{
+		{"register", "", StatusRegistered, TriggerRegister, false},
+		{"first heartbeat", StatusRegistered, StatusHealthy, TriggerHeartbeat, false},
+		{"self-reported degraded", StatusHealthy, StatusDegraded, TriggerHeartbeat, false},
+		{"failing check", StatusHealthy, StatusUnknown, TriggerHealthCheck, false},
+		{"passing check", StatusUnknown, StatusHealthy, TriggerHealthCheck, false},
+		{"reports", StatusDegraded, StatusSuspicious, TriggerReports, false},
+		{"recovery", StatusSuspicious, StatusHealthy, TriggerRecovery, false},
+		{"back to registered", StatusHealthy, StatusRegistered, TriggerHeartbeat, true},
+		{"suspicious to unknown", StatusSuspicious, StatusUnknown, TriggerHealthCheck, true},
+		{"out of deregistered", StatusDeregistered, StatusHealthy, TriggerHeartbeat, true},
+		{"skip registration", "", StatusHealthy, TriggerHeartbeat, true},
+		{"admin overrides suspicious", StatusSuspicious, StatusUnknown, TriggerAdmin, false},
+		{"admin revives deregistered", StatusDeregistered, StatusHealthy, TriggerAdmin, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := ServiceEntry{Status: tt.from, RecoveryHeartbeats: 2}
+			change, err := e.transition(tt.to, tt.trigger, "because")
+			if tt.wantErr {
+				if !errors.Is(err, ErrIllegalTransition) {
+					t.Fatalf("transition %q -> %q on %s = %v, want ErrIllegalTransition", tt.from, tt.to, tt.trigger, err)
+				}
+				if e.Status != tt.from || change.Changed() || len(e.StatusHistory) != 0 {
+					t.Fatalf("illegal transition changed the entry: status %q, change %+v, history %+v", e.Status, change, e.StatusHistory)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("transition %q -> %q on %s: %v", tt.from, tt.to, tt.trigger, err)
+			}
+			if e.Status != tt.to || change.From != tt.from || change.To != tt.to || change.Trigger != tt.trigger {
+				t.Fatalf("status %q, change %+v, want %q -> %q on %s", e.Status, change, tt.from, tt.to, tt.trigger)
+			}
+			if len(e.StatusHistory) != 1 {
+				t.Fatalf("history has %d transitions, want 1", len(e.StatusHistory))
+			}
+			if h := e.StatusHistory[0]; h.From != tt.from || h.To != tt.to || h.Trigger != tt.trigger || h.Reason != "because" || h.At == "" {
+				t.Fatalf("history entry %+v does not match the transition", h)
+			}
+			if tt.to == StatusSuspicious && e.RecoveryHeartbeats != 0 {
+				t.Fatalf("becoming suspicious kept %d recovery heartbeats", e.RecoveryHeartbeats)
+			}
+		})
+	}
+}
+
+func TestTransitionToSameStatusIsNoop(t *testing.T) {
+	e := ServiceEntry{Status: StatusHealthy}
+	change, err := e.transition(StatusHealthy, TriggerHeartbeat, "")
+	if err != nil || change.Changed() || len(e.StatusHistory) != 0 {
+		t.Fatalf("transition to the same status = %+v, %v, history %+v", change, err, e.StatusHistory)
+	}
+}
+
+func TestTransitionHistoryIsCapped(t *testing.T) {
+	e := ServiceEntry{Status: StatusHealthy}
+	const transitions = maxStatusHistory + 5
+	for i := 0; i < transitions; i++ {
+		to := []ServiceStatus{StatusDegraded, StatusHealthy}[i%2]
+		if _, err := e.transition(to, TriggerHeartbeat, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(e.StatusHistory) != maxStatusHistory {
+		t.Fatalf("history has %d transitions after %d, want %d", len(e.StatusHistory), transitions, maxStatusHistory)
+	}
+	// The oldest transitions are dropped, the latest kept
+	if last := e.StatusHistory[maxStatusHistory-1]; last.From != StatusHealthy || last.To != StatusDegraded {
+		t.Fatalf("latest transition %+v, want healthy -> degraded", last)
+	}
+	if first := e.StatusHistory[0]; first.From != StatusDegraded || first.To != StatusHealthy {
+		t.Fatalf("oldest kept transition %+v, want degraded -> healthy", first)
+	}
+}
+
+func TestApplyHeartbeatRecovery(t *testing.T) {
+	suspicious := func() ServiceEntry {
+		return ServiceEntry{
+			Status:      StatusSuspicious,
+			HeardCount:  10,
+			ReportCount: 3,
+			Reports:     []ServiceReport{{ReporterUUID: "r1"}, {ReporterUUID: "r2"}, {ReporterUUID: "r3"}},
+		}
+	}
+
+	t.Run("recovers after good heartbeats", func(t *testing.T) {
+		e := suspicious()
+		policy := StatusPolicy{ReportThreshold: 3, RecoveryHeartbeats: 3}
+		for i := 1; i < policy.RecoveryHeartbeats; i++ {
+			if _, err := e.applyHeartbeat(HeartbeatPayload{}, policy); !errors.Is(err, ErrServiceSuspicious) {
+				t.Fatalf("heartbeat %d = %v, want ErrServiceSuspicious", i, err)
+			}
+			if e.Status != StatusSuspicious || e.RecoveryHeartbeats != i {
+				t.Fatalf("after heartbeat %d: status %q, %d recovery heartbeats", i, e.Status, e.RecoveryHeartbeats)
+			}
+		}
+		change, err := e.applyHeartbeat(HeartbeatPayload{SelfStatus: SelfStatusDegraded}, policy)
+		if err != nil {
+			t.Fatalf("recovering heartbeat: %v", err)
+		}
+		if change.To != StatusDegraded || change.Trigger != TriggerRecovery || e.Status != StatusDegraded {
+			t.Fatalf("recovery change %+v, status %q, want degraded on recovery", change, e.Status)
+		}
+		if e.ReportCount != 0 || len(e.Reports) != 0 || e.RecoveryHeartbeats != 0 {
+			t.Fatalf("recovery kept reports %d/%v and %d recovery heartbeats", e.ReportCount, e.Reports, e.RecoveryHeartbeats)
+		}
+	})
+
+	t.Run("failing heartbeat restarts the count", func(t *testing.T) {
+		e := suspicious()
+		policy := StatusPolicy{ReportThreshold: 3, RecoveryHeartbeats: 2}
+		e.applyHeartbeat(HeartbeatPayload{}, policy)
+		if _, err := e.applyHeartbeat(HeartbeatPayload{SelfStatus: SelfStatusFailing}, policy); !errors.Is(err, ErrServiceSuspicious) {
+			t.Fatalf("failing heartbeat = %v, want ErrServiceSuspicious", err)
+		}
+		if e.RecoveryHeartbeats != 0 {
+			t.Fatalf("a failing heartbeat kept %d recovery heartbeats", e.RecoveryHeartbeats)
+		}
+	})
+
+	t.Run("stays suspicious without recovery", func(t *testing.T) {
+		e := suspicious()
+		policy := StatusPolicy{ReportThreshold: 3}
+		for i := 0; i < 5; i++ {
+			if _, err := e.applyHeartbeat(HeartbeatPayload{}, policy); !errors.Is(err, ErrServiceSuspicious) {
+				t.Fatalf("heartbeat %d = %v, want ErrServiceSuspicious", i, err)
+			}
+		}
+		if e.Status != StatusSuspicious {
+			t.Fatalf("status %q, want suspicious", e.Status)
+		}
+	})
+}
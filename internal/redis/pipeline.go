@@ -0,0 +1,58 @@
+package redisclient
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type pipeline struct {
+	c    *client
+	pipe redis.Pipeliner
+	cmds []redis.Cmder
+}
+
+func (c *client) Pipeline() Pipeline {
+	return &pipeline{c: c, pipe: c.rdb.Pipeline()}
+}
+
+func (p *pipeline) Get(key string) {
+	p.cmds = append(p.cmds, p.pipe.Get(p.c.ctx, key))
+}
+
+func (p *pipeline) Set(key string, value []byte, expiration time.Duration) {
+	p.cmds = append(p.cmds, p.pipe.Set(p.c.ctx, key, value, expiration))
+}
+
+func (p *pipeline) Delete(key string) {
+	p.cmds = append(p.cmds, p.pipe.Del(p.c.ctx, key))
+}
+
+func (p *pipeline) Exec() ([]PipelineResult, error) {
+	if len(p.cmds) == 0 {
+		return nil, nil
+	}
+	// Exec reports the first failed command; missing keys are not failures
+	_, err := p.pipe.Exec(p.c.ctx)
+
+	results := make([]PipelineResult, len(p.cmds))
+	sent := false
+	for i, cmd := range p.cmds {
+		cmdErr := cmd.Err()
+		if getCmd, ok := cmd.(*redis.StringCmd); ok {
+			if cmdErr == redis.Nil {
+				cmdErr = nil
+			} else if cmdErr == nil {
+				results[i].Value, _ = getCmd.Bytes()
+			}
+		}
+		results[i].Err = cmdErr
+		if cmdErr == nil {
+			sent = true
+		}
+	}
+	if err != nil && err != redis.Nil && !sent {
+		return results, err
+	}
+	return results, nil
+}
@@ -0,0 +1,434 @@
+// Package redistest holds the behavioural test suite every storage backend
+// implementing redisclient.Client has to pass.
+package redistest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	redisclient "github.com/tahakara/discogo/internal/redis"
+)
+
+// Backend describes how the suite drives one storage backend.
+type Backend struct {
+	// New returns a client on an empty store.
+	New func(t *testing.T) redisclient.Client
+	// Reopen closes c and opens its store again, as after a restart.
+	Reopen func(t *testing.T, c redisclient.Client) redisclient.Client
+	// Elapse lets d pass for the expiry of keys stored through c.
+	Elapse func(t *testing.T, c redisclient.Client, d time.Duration)
+	// ExpiryEvents is set when expired keys are reported to SubscribeExpired
+	// handlers.
+	ExpiryEvents bool
+}
+
+// TTL is the expiration the suite stores keys with. Redis reports TTLs in
+// whole seconds, so it cannot be shorter.
+const TTL = 2 * time.Second
+
+// Run runs the suite against b.
+func Run(t *testing.T, b Backend) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b Backend)
+	}{
+		{"GetSetDelete", testGetSetDelete},
+		{"Expiry", testExpiry},
+		{"Add", testAdd},
+		{"AddConcurrent", testAddConcurrent},
+		{"CompareAndDelete", testCompareAndDelete},
+		{"Replace", testReplace},
+		{"Increment", testIncrement},
+		{"FindKeys", testFindKeys},
+		{"Streams", testStreams},
+		{"Pipeline", testPipeline},
+		{"Persistence", testPersistence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, b)
+		})
+	}
+}
+
+func mustGet(t *testing.T, c redisclient.Client, key string) []byte {
+	t.Helper()
+	value, err := c.Get(key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return value
+}
+
+func mustSet(t *testing.T, c redisclient.Client, key, value string, expiration time.Duration) {
+	t.Helper()
+	if err := c.Set(key, []byte(value), expiration); err != nil {
+		t.Fatalf("Set(%q): %v", key, err)
+	}
+}
+
+func mustTTL(t *testing.T, c redisclient.Client, key string) time.Duration {
+	t.Helper()
+	ttl, err := c.TTL(key)
+	if err != nil {
+		t.Fatalf("TTL(%q): %v", key, err)
+	}
+	return ttl
+}
+
+func testGetSetDelete(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	if value := mustGet(t, c, "missing"); value != nil {
+		t.Fatalf("Get(missing) = %q, want nil", value)
+	}
+	mustSet(t, c, "k", "v1", 0)
+	mustSet(t, c, "k", "v2", 0)
+	if value := mustGet(t, c, "k"); string(value) != "v2" {
+		t.Fatalf("Get(k) = %q, want v2", value)
+	}
+	if ttl := mustTTL(t, c, "k"); ttl != 0 {
+		t.Fatalf("TTL of a key without expiry = %v, want 0", ttl)
+	}
+	if ttl := mustTTL(t, c, "missing"); ttl >= 0 {
+		t.Fatalf("TTL of a missing key = %v, want < 0", ttl)
+	}
+
+	if err := c.Delete("k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if value := mustGet(t, c, "k"); value != nil {
+		t.Fatalf("Get after Delete = %q, want nil", value)
+	}
+	if err := c.Delete("k"); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+}
+
+func testExpiry(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	var (
+		mu      sync.Mutex
+		expired = map[string]bool{}
+	)
+	if b.ExpiryEvents {
+		err := c.SubscribeExpired(func(key string) {
+			mu.Lock()
+			expired[key] = true
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("SubscribeExpired: %v", err)
+		}
+	}
+
+	mustSet(t, c, "short", "v", TTL)
+	mustSet(t, c, "long", "v", 10*TTL)
+	if ttl := mustTTL(t, c, "short"); ttl <= 0 || ttl > TTL {
+		t.Fatalf("TTL(short) = %v, want (0, %v]", ttl, TTL)
+	}
+
+	b.Elapse(t, c, TTL+200*time.Millisecond)
+
+	if value := mustGet(t, c, "short"); value != nil {
+		t.Fatalf("Get of an expired key = %q, want nil", value)
+	}
+	if ttl := mustTTL(t, c, "short"); ttl >= 0 {
+		t.Fatalf("TTL of an expired key = %v, want < 0", ttl)
+	}
+	keys, err := c.FindKeys("*")
+	if err != nil {
+		t.Fatalf("FindKeys: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "long" {
+		t.Fatalf("FindKeys after expiry = %v, want [long]", keys)
+	}
+	stored, err := c.Add("short", []byte("again"), 0)
+	if err != nil || !stored {
+		t.Fatalf("Add over an expired key = %v, %v, want true", stored, err)
+	}
+
+	if b.ExpiryEvents {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			mu.Lock()
+			got := expired["short"]
+			mu.Unlock()
+			if got {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("the expiry of short was not reported")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if expired["long"] {
+			t.Fatal("a live key was reported as expired")
+		}
+	}
+}
+
+func testAdd(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	stored, err := c.Add("lock", []byte("a"), TTL)
+	if err != nil || !stored {
+		t.Fatalf("first Add = %v, %v, want true", stored, err)
+	}
+	stored, err = c.Add("lock", []byte("b"), TTL)
+	if err != nil || stored {
+		t.Fatalf("second Add = %v, %v, want false", stored, err)
+	}
+	if value := mustGet(t, c, "lock"); string(value) != "a" {
+		t.Fatalf("Get after a refused Add = %q, want a", value)
+	}
+	if ttl := mustTTL(t, c, "lock"); ttl <= 0 || ttl > TTL {
+		t.Fatalf("TTL of an added key = %v, want (0, %v]", ttl, TTL)
+	}
+}
+
+func testAddConcurrent(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	const writers = 20
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		stored int
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := c.Add("lock", []byte(strconv.Itoa(i)), TTL)
+			if err != nil {
+				t.Errorf("Add: %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				stored++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if stored != 1 {
+		t.Fatalf("%d concurrent Adds stored the key %d times, want once", writers, stored)
+	}
+}
+
+func testCompareAndDelete(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	if deleted, err := c.CompareAndDelete("missing", []byte("a")); err != nil || deleted {
+		t.Fatalf("CompareAndDelete of a missing key = %v, %v, want false", deleted, err)
+	}
+	mustSet(t, c, "lock", "theirs", TTL)
+	if deleted, err := c.CompareAndDelete("lock", []byte("mine")); err != nil || deleted {
+		t.Fatalf("CompareAndDelete with another value = %v, %v, want false", deleted, err)
+	}
+	if value := mustGet(t, c, "lock"); string(value) != "theirs" {
+		t.Fatalf("a refused CompareAndDelete left %q, want theirs", value)
+	}
+	if deleted, err := c.CompareAndDelete("lock", []byte("theirs")); err != nil || !deleted {
+		t.Fatalf("CompareAndDelete with the stored value = %v, %v, want true", deleted, err)
+	}
+	if value := mustGet(t, c, "lock"); value != nil {
+		t.Fatalf("CompareAndDelete left %q", value)
+	}
+}
+
+func testReplace(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	if err := c.Replace("k", []byte("v"), 0); err != nil {
+		t.Fatalf("Replace of a missing key: %v", err)
+	}
+	if value := mustGet(t, c, "k"); value != nil {
+		t.Fatalf("Replace created a missing key: %q", value)
+	}
+	mustSet(t, c, "k", "v1", 0)
+	if err := c.Replace("k", []byte("v2"), 0); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if value := mustGet(t, c, "k"); string(value) != "v2" {
+		t.Fatalf("Get after Replace = %q, want v2", value)
+	}
+}
+
+func testIncrement(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	n, err := c.Increment("fresh", 3)
+	if err != nil || n != 3 {
+		t.Fatalf("Increment of a missing key = %d, %v, want 3", n, err)
+	}
+
+	stored, err := c.Add("counter", []byte("0"), TTL)
+	if err != nil || !stored {
+		t.Fatalf("Add: %v, %v", stored, err)
+	}
+	for i := int64(1); i <= 3; i++ {
+		if n, err := c.Increment("counter", 2); err != nil || n != 2*i {
+			t.Fatalf("Increment #%d = %d, %v, want %d", i, n, err, 2*i)
+		}
+	}
+	if n, err := c.Decrement("counter", 1); err != nil || n != 5 {
+		t.Fatalf("Decrement = %d, %v, want 5", n, err)
+	}
+	// Counters are created with a TTL and incremented in place
+	if ttl := mustTTL(t, c, "counter"); ttl <= 0 || ttl > TTL {
+		t.Fatalf("TTL after Increment = %v, want the TTL of the Add", ttl)
+	}
+
+	mustSet(t, c, "text", "abc", 0)
+	if _, err := c.Increment("text", 1); err == nil {
+		t.Fatal("Increment of a non-integer value succeeded")
+	}
+}
+
+func testFindKeys(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	for _, key := range []string{"svc:a:1", "svc:b:2", "svc:ab:3", "other:a", "svc:c*:4", "x?y"} {
+		mustSet(t, c, key, "v", 0)
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"svc:*", []string{"svc:a:1", "svc:ab:3", "svc:b:2", "svc:c*:4"}},
+		{"svc:?:*", []string{"svc:a:1", "svc:b:2"}},
+		{"svc:[ab]:*", []string{"svc:a:1", "svc:b:2"}},
+		{"svc:[^a]:*", []string{"svc:b:2"}},
+		{"svc:[a-b]b:*", []string{"svc:ab:3"}},
+		// '*' matches ':' too
+		{"*:*:*", []string{"svc:a:1", "svc:ab:3", "svc:b:2", "svc:c*:4"}},
+		{`svc:c\*:*`, []string{"svc:c*:4"}},
+		{`x\?y`, []string{"x?y"}},
+		{"nothing*", nil},
+	}
+	for _, tt := range tests {
+		keys, err := c.FindKeys(tt.pattern)
+		if err != nil {
+			t.Fatalf("FindKeys(%q): %v", tt.pattern, err)
+		}
+		sort.Strings(keys)
+		if fmt.Sprint(keys) != fmt.Sprint(tt.want) {
+			t.Errorf("FindKeys(%q) = %v, want %v", tt.pattern, keys, tt.want)
+		}
+	}
+}
+
+func testStreams(t *testing.T, b Backend) {
+	c := b.New(t)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		id, err := c.StreamAdd("events", map[string]string{"n": strconv.Itoa(i)}, 0)
+		if err != nil {
+			t.Fatalf("StreamAdd: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	all, err := c.StreamRange("events", "-", "+", 0)
+	if err != nil {
+		t.Fatalf("StreamRange: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("StreamRange returned %d entries, want 5", len(all))
+	}
+	for i, entry := range all {
+		if entry.ID != ids[i] || entry.Values["n"] != strconv.Itoa(i) {
+			t.Fatalf("entry %d = %+v, want ID %s and n=%d", i, entry, ids[i], i)
+		}
+	}
+
+	page, err := c.StreamRange("events", "-", "+", 2)
+	if err != nil || len(page) != 2 || page[1].ID != ids[1] {
+		t.Fatalf("StreamRange with count 2 = %+v, %v", page, err)
+	}
+	next, err := c.StreamRange("events", "("+page[1].ID, "+", 2)
+	if err != nil || len(next) != 2 || next[0].ID != ids[2] {
+		t.Fatalf("StreamRange after an exclusive bound = %+v, %v", next, err)
+	}
+	window, err := c.StreamRange("events", ids[1], "("+ids[3], 0)
+	if err != nil || len(window) != 2 || window[0].ID != ids[1] || window[1].ID != ids[2] {
+		t.Fatalf("StreamRange between %s and (%s = %+v, %v", ids[1], ids[3], window, err)
+	}
+
+	empty, err := c.StreamRange("missing", "-", "+", 0)
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("StreamRange of a missing stream = %+v, %v", empty, err)
+	}
+}
+
+func testPipeline(t *testing.T, b Backend) {
+	c := b.New(t)
+	mustSet(t, c, "a", "1", 0)
+	mustSet(t, c, "gone", "x", 0)
+
+	pipe := c.Pipeline()
+	pipe.Get("a")
+	pipe.Get("missing")
+	pipe.Set("b", []byte("2"), TTL)
+	pipe.Delete("gone")
+	pipe.Get("b")
+	results, err := pipe.Exec()
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("Exec returned %d results, want 5", len(results))
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("result %d: %v", i, result.Err)
+		}
+	}
+	if string(results[0].Value) != "1" || results[1].Value != nil || string(results[4].Value) != "2" {
+		t.Fatalf("pipeline Gets = %q, %q, %q, want 1, nil, 2", results[0].Value, results[1].Value, results[4].Value)
+	}
+	if value := mustGet(t, c, "gone"); value != nil {
+		t.Fatalf("pipeline Delete left %q", value)
+	}
+	if ttl := mustTTL(t, c, "b"); ttl <= 0 || ttl > TTL {
+		t.Fatalf("TTL of a pipeline Set = %v, want (0, %v]", ttl, TTL)
+	}
+
+	if results, err := c.Pipeline().Exec(); err != nil || len(results) != 0 {
+		t.Fatalf("empty Exec = %v, %v", results, err)
+	}
+}
+
+func testPersistence(t *testing.T, b Backend) {
+	c := b.New(t)
+	mustSet(t, c, "kept", "v", 0)
+	mustSet(t, c, "expiring", "v", 10*TTL)
+	if _, err := c.StreamAdd("events", map[string]string{"n": "1"}, 0); err != nil {
+		t.Fatalf("StreamAdd: %v", err)
+	}
+
+	c = b.Reopen(t, c)
+
+	if value := mustGet(t, c, "kept"); string(value) != "v" {
+		t.Fatalf("Get after restart = %q, want v", value)
+	}
+	if ttl := mustTTL(t, c, "expiring"); ttl <= 0 || ttl > 10*TTL {
+		t.Fatalf("TTL after restart = %v, want (0, %v]", ttl, 10*TTL)
+	}
+	entries, err := c.StreamRange("events", "-", "+", 0)
+	if err != nil || len(entries) != 1 || entries[0].Values["n"] != "1" {
+		t.Fatalf("stream after restart = %+v, %v", entries, err)
+	}
+}
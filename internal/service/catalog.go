@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	env "github.com/tahakara/discogo/internal/config"
+	serviceconfigloader "github.com/tahakara/discogo/internal/config/serviceconfiguration"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+// StartCatalogWatcher loads the custom service types and providers from the
+// backend and keeps the catalog current: the catalog file is reloaded on SIGHUP and,
+// unless cfg.WatchInterval is 0, whenever its modification time changes.
+// The custom entries are re-read on the same interval so changes made through
+// another DiscoGo instance are picked up. A failed reload keeps the current catalog.
+func StartCatalogWatcher(cfg env.CatalogConfig, rclient redisclient.Client) {
+	syncCustomCatalog(rclient)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval := cfg.WatchInterval; interval > 0 {
+		tick = time.NewTicker(time.Duration(interval) * time.Second).C
+	}
+
+	go func() {
+		for {
+			select {
+			case <-hup:
+				serviceconfigloader.ReloadConfigs()
+				syncCustomCatalog(rclient)
+			case <-tick:
+				if serviceconfigloader.HasConfigChanged() {
+					serviceconfigloader.ReloadConfigs()
+				}
+				syncCustomCatalog(rclient)
+			}
+		}
+	}()
+}
+
+func syncCustomCatalog(rclient redisclient.Client) {
+	startTime := time.Now()
+	if err := redishelper.LoadCustomCatalog(rclient); err != nil {
+		logger.Error(fmt.Sprintf("Failed to load custom catalog entries: %v", err), time.Since(startTime))
+	}
+}
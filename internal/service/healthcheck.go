@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+// healthCheckScanInterval is how often registrations are scanned for due checks.
+const healthCheckScanInterval = time.Second
+
+// StartHealthChecker runs the active health checks declared by registrations
+// on a pool of cfg.Workers goroutines. Replicas sharing a backend split the
+// work: a due check is claimed with SETNX for one interval, so only the
+// replica that claimed it probes the instance. Disabled when cfg.Workers is 0.
+func StartHealthChecker(cfg env.ProbeConfig, rclient redisclient.Client) {
+	if cfg.Workers == 0 {
+		return
+	}
+	replicaID := uuid.New().String()
+	jobs := make(chan redishelper.ServiceEntry, cfg.Workers)
+
+	for i := 0; i < cfg.Workers; i++ {
+		go func() {
+			for entry := range jobs {
+				runHealthCheck(cfg, rclient, entry)
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(healthCheckScanInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			scheduleHealthChecks(cfg, rclient, replicaID, jobs)
+		}
+	}()
+}
+
+// scheduleHealthChecks queues every due check this replica manages to claim.
+// It blocks while all workers are busy.
+func scheduleHealthChecks(cfg env.ProbeConfig, rclient redisclient.Client, replicaID string, jobs chan<- redishelper.ServiceEntry) {
+	startTime := time.Now()
+	entries, err := redishelper.ServicesWithChecks(rclient)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to list health checks: %v", err), time.Since(startTime))
+		return
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		interval := checkInterval(entry.Check, cfg.Interval)
+		if !entry.Check.Due(now, interval) {
+			continue
+		}
+		claimed, err := redishelper.ClaimHealthCheck(rclient, entry.ServiceUUID, replicaID, interval)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to claim health check of %s: %v", entry.ServiceUUID, err), time.Since(startTime))
+			continue
+		}
+		if claimed {
+			jobs <- entry
+		}
+	}
+}
+
+func runHealthCheck(cfg env.ProbeConfig, rclient redisclient.Client, entry redishelper.ServiceEntry) {
+	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(entry.Check, cfg.Timeout))
+	checkErr := runCheck(ctx, entry)
+	cancel()
+
+	updated, change, err := redishelper.RecordHealthCheckResult(rclient, entry.ServiceUUID, checkErr, cfg.FailureThreshold)
+	if err != nil {
+		// The instance expired or deregistered while it was being probed
+		if !errors.Is(err, redishelper.ErrServiceNotFound) {
+			logger.Error(fmt.Sprintf("Failed to record health check of %s: %v", entry.ServiceUUID, err), time.Since(startTime))
+		}
+		return
+	}
+
+	if checkErr != nil {
+		logger.HealthCheck(fmt.Sprintf("%s %s check failed (%d in a row): %v", entry.ServiceUUID, entry.Check.Type, updated.Check.ConsecutiveFailures, checkErr), time.Since(startTime))
+	}
+	if change.Changed() {
+		redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+			Action:      redishelper.AuditStatusChange,
+			ServiceUUID: entry.ServiceUUID,
+			ServiceType: entry.Type,
+			Actor:       redishelper.AuditActorSystem,
+			OldStatus:   change.From,
+			NewStatus:   change.To,
+			Reason:      change.AuditReason(),
+		})
+		logger.HealthCheck(fmt.Sprintf("%s %s -> %s", entry.ServiceUUID, change.From, change.To), time.Since(startTime))
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	env "github.com/tahakara/discogo/internal/config"
+	"github.com/tahakara/discogo/internal/logger"
+	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+// outlierScanInterval is how often the detector looks for a finished
+// aggregation interval.
+const outlierScanInterval = time.Second
+
+// outlierPolicy converts the outlier settings for the detector.
+func outlierPolicy(cfg env.OutlierConfig) redishelper.OutlierPolicy {
+	return redishelper.OutlierPolicy{
+		MinRequests:        cfg.MinRequests,
+		MinHosts:           cfg.MinHosts,
+		StdevFactor:        float64(cfg.StdevFactor) / 1000,
+		BaseEjectionTime:   time.Duration(cfg.BaseEjectionTime) * time.Second,
+		MaxEjectionTime:    time.Duration(cfg.MaxEjectionTime) * time.Second,
+		MaxEjectionPercent: cfg.MaxEjectionPercent,
+	}
+}
+
+// StartOutlierDetector evaluates the request outcomes clients report once per
+// aggregation interval and ejects the outliers (see
+// redishelper.EvaluateOutliers). Replicas sharing a backend claim each
+// interval with SETNX, so it is evaluated once. Disabled when cfg.Interval is 0.
+func StartOutlierDetector(cfg env.OutlierConfig, rclient redisclient.Client) {
+	if cfg.Interval == 0 {
+		return
+	}
+	replicaID := uuid.New().String()
+	interval := cfg.AggregationInterval()
+	policy := outlierPolicy(cfg)
+
+	go func() {
+		ticker := time.NewTicker(outlierScanInterval)
+		defer ticker.Stop()
+		lastWindow := redishelper.OutlierWindow(time.Now(), interval)
+		for range ticker.C {
+			current := redishelper.OutlierWindow(time.Now(), interval)
+			if current == lastWindow {
+				continue
+			}
+			// Evaluate the interval that just ended
+			window := current - int64(cfg.Interval)
+			lastWindow = current
+			evaluateOutliers(rclient, window, replicaID, interval, policy)
+		}
+	}()
+}
+
+func evaluateOutliers(rclient redisclient.Client, window int64, replicaID string, interval time.Duration, policy redishelper.OutlierPolicy) {
+	startTime := time.Now()
+	claimed, err := redishelper.ClaimOutlierWindow(rclient, window, replicaID, interval)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to claim outlier interval %d: %v", window, err), time.Since(startTime))
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	ejections, err := redishelper.EvaluateOutliers(rclient, window, policy)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to evaluate outliers: %v", err), time.Since(startTime))
+		return
+	}
+	for _, ejection := range ejections {
+		entry := ejection.Entry
+		reason := fmt.Sprintf("error rate %.2f above %.2f, ejected for %s (ejection %d)", ejection.ErrorRate, ejection.Threshold, ejection.Duration, entry.EjectionCount)
+		redishelper.RecordAuditEvent(rclient, redishelper.AuditEvent{
+			Action:      redishelper.AuditOutlierEject,
+			ServiceUUID: entry.ServiceUUID,
+			ServiceType: entry.Type,
+			Actor:       redishelper.AuditActorSystem,
+			Reason:      reason,
+		})
+		logger.HealthCheck(fmt.Sprintf("%s ejected as an outlier: %s", entry.ServiceUUID, reason), time.Since(startTime))
+	}
+}
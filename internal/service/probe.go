@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
+)
+
+// grpcServing is the SERVING value of grpc.health.v1.HealthCheckResponse.
+const grpcServing = 1
+
+// probeClient does not follow redirects so a check can expect a 3xx status.
+var probeClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// grpcClient speaks HTTP/2 without TLS (h2c), as plaintext gRPC servers do.
+var grpcClient = func() *http.Client {
+	transport := &http.Transport{Protocols: new(http.Protocols)}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: transport}
+}()
+
+// checkAddr returns the address to probe: Addr4:Port4, or Addr6:Port6 when
+// no IPv4 address is registered.
+func checkAddr(entry redishelper.ServiceEntry) (string, error) {
+	if entry.Addr4 != "" && entry.Port4 > 0 {
+		return net.JoinHostPort(entry.Addr4, strconv.Itoa(entry.Port4)), nil
+	}
+	if entry.Addr6 != "" && entry.Port6 > 0 {
+		return net.JoinHostPort(entry.Addr6, strconv.Itoa(entry.Port6)), nil
+	}
+	return "", errors.New("no address to probe")
+}
+
+// runCheck runs the active check of entry once. It returns nil when the
+// check passes.
+func runCheck(ctx context.Context, entry redishelper.ServiceEntry) error {
+	addr, err := checkAddr(entry)
+	if err != nil {
+		return err
+	}
+	check := entry.Check
+	switch check.Type {
+	case redishelper.CheckHTTP:
+		return probeHTTP(ctx, addr, check.Path, check.ExpectedStatus)
+	case redishelper.CheckTCP:
+		return probeTCP(ctx, addr)
+	case redishelper.CheckGRPC:
+		return probeGRPC(ctx, addr, check.Service)
+	default:
+		return fmt.Errorf("unknown check type %q", check.Type)
+	}
+}
+
+func probeHTTP(ctx context.Context, addr, path string, expectedStatus int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("HTTP status %d, expected %d", resp.StatusCode, expectedStatus)
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, addr string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeGRPC calls grpc.health.v1.Health/Check. The protobuf messages are
+// small enough to encode by hand: the request has the service name as
+// field 1, the response the serving status as field 1.
+func probeGRPC(ctx context.Context, addr, service string) error {
+	var msg []byte
+	if service != "" {
+		msg = append(msg, 0x0a)
+		msg = binary.AppendUvarint(msg, uint64(len(service)))
+		msg = append(msg, service...)
+	}
+	// gRPC length-prefixed message: uncompressed flag and big endian length
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	frame = append(frame, msg...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+addr+"/grpc.health.v1.Health/Check", bytes.NewReader(frame))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := grpcClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+
+	// Errors may come as a trailers-only response, i.e. in the headers
+	grpcStatus := resp.Trailer.Get("Grpc-Status")
+	if grpcStatus == "" {
+		grpcStatus = resp.Header.Get("Grpc-Status")
+	}
+	if grpcStatus != "0" {
+		message := resp.Trailer.Get("Grpc-Message")
+		if message == "" {
+			message = resp.Header.Get("Grpc-Message")
+		}
+		return fmt.Errorf("gRPC status %q: %s", grpcStatus, message)
+	}
+
+	if len(body) < 5 {
+		return errors.New("gRPC health response is empty")
+	}
+	status, err := grpcServingStatus(body[5:])
+	if err != nil {
+		return err
+	}
+	if status != grpcServing {
+		return fmt.Errorf("gRPC serving status %d", status)
+	}
+	return nil
+}
+
+// grpcServingStatus reads field 1 of a HealthCheckResponse. A missing field
+// is UNKNOWN (0).
+func grpcServingStatus(msg []byte) (uint64, error) {
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0, errors.New("malformed gRPC health response")
+		}
+		msg = msg[n:]
+		if tag&7 != 0 {
+			return 0, errors.New("unexpected field in gRPC health response")
+		}
+		value, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0, errors.New("malformed gRPC health response")
+		}
+		msg = msg[n:]
+		if tag>>3 == 1 {
+			return value, nil
+		}
+	}
+	return 0, nil
+}
+
+// checkTimeout returns the timeout of check, falling back to def seconds.
+func checkTimeout(check *redishelper.HealthCheck, def int) time.Duration {
+	if check.Timeout > 0 {
+		return time.Duration(check.Timeout) * time.Second
+	}
+	return time.Duration(def) * time.Second
+}
+
+// checkInterval returns the interval of check, falling back to def seconds.
+func checkInterval(check *redishelper.HealthCheck, def int) time.Duration {
+	if check.Interval > 0 {
+		return time.Duration(check.Interval) * time.Second
+	}
+	return time.Duration(def) * time.Second
+}
@@ -6,25 +6,54 @@ import (
 	"time"
 
 	"github.com/tahakara/discogo/internal/api"
+	boltclient "github.com/tahakara/discogo/internal/bolt"
 	env "github.com/tahakara/discogo/internal/config"
 	"github.com/tahakara/discogo/internal/logger"
 	redisclient "github.com/tahakara/discogo/internal/redis"
+	redishelper "github.com/tahakara/discogo/internal/redis/helper"
 )
 
-func StartHTTPServer(rclient redisclient.Client) {
+func StartHTTPServer(cfg env.Config, rclient redisclient.Client) {
 	startTime := time.Now()
-	addr := env.GetDiscoGoHTTPAddr()
-	router := api.NewRouter(rclient)
+	addr := cfg.HTTP.Addr()
+	router := api.NewRouter(rclient, cfg)
+	if err := redishelper.WatchExpiredServices(rclient); err != nil {
+		logger.Error(fmt.Sprintf("Expiry events unavailable, expirations will not be audited: %v", err), time.Since(startTime))
+	}
 	// Define your HTTP routes here
 	logger.Info(fmt.Sprintf("HTTP server listening on %s", addr), time.Since(startTime))
 	http.ListenAndServe(addr, router)
 }
 
-func StartRedisService() redisclient.Client {
+// StartStorageService connects to the registry backend selected in cfg.
+func StartStorageService(cfg env.StorageConfig) redisclient.Client {
+	if cfg.Backend == env.StorageBackendBolt {
+		return StartBoltService(cfg.Bolt)
+	}
+	return StartRedisService(cfg.Redis)
+}
+
+// StartBoltService opens the embedded database. It logs the error and returns
+// nil when the file cannot be opened, e.g. while another process holds it.
+func StartBoltService(cfg env.BoltConfig) redisclient.Client {
+	startTime := time.Now()
+	path := cfg.Path
+	sweepInterval := time.Duration(cfg.SweepInterval) * time.Second
+
+	rclient, err := boltclient.New(path, sweepInterval)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Embedded database open failed: %v", err), time.Since(startTime))
+		return nil
+	}
+	logger.Info(fmt.Sprintf("Embedded database opened at %s", path), time.Since(startTime))
+	return rclient
+}
+
+func StartRedisService(cfg env.RedisConfig) redisclient.Client {
 	startTime := time.Now()
-	addr := env.GetRedisServerAddr()
-	password := env.GetRedisPassword() // Şifre yoksa "" döndürsün
-	db := env.GetRedisDB()             // Örn: 0
+	addr := cfg.Addr()
+	password := cfg.Password // Şifre yoksa ""
+	db := cfg.DB             // Örn: 0
 
 	var rclient redisclient.Client = redisclient.New(addr, password, db)
 	// Set dummy data for testing
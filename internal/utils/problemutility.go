@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses.
+const ProblemContentType = "application/problem+json"
+
+// Stable error codes. Clients branch on these rather than on titles or
+// details, which may change or be localised.
+const (
+	CodeInvalidBody            = "INVALID_BODY"
+	CodeBodyTooLarge           = "BODY_TOO_LARGE"
+	CodeUnsupportedMediaType   = "UNSUPPORTED_MEDIA_TYPE"
+	CodeBatchTooLarge          = "BATCH_TOO_LARGE"
+	CodeValidationFailed       = "VALIDATION_FAILED"
+	CodeInvalidParameter       = "INVALID_PARAMETER"
+	CodeInvalidUUID            = "INVALID_UUID"
+	CodeServiceNotFound        = "SERVICE_NOT_FOUND"
+	CodeServiceSuspicious      = "SERVICE_SUSPICIOUS"
+	CodeIllegalTransition      = "ILLEGAL_TRANSITION"
+	CodeReporterNotFound       = "REPORTER_NOT_FOUND"
+	CodeIdempotencyKeyReused   = "IDEMPOTENCY_KEY_REUSED"
+	CodeRegistrationInProgress = "REGISTRATION_IN_PROGRESS"
+	CodeServiceBusy            = "SERVICE_BUSY"
+	CodeUnknownServiceType     = "UNKNOWN_SERVICE_TYPE"
+	CodeCatalogEntryExists     = "CATALOG_ENTRY_EXISTS"
+	CodeCatalogEntryNotFound   = "CATALOG_ENTRY_NOT_FOUND"
+	CodeCatalogInvalid         = "CATALOG_INVALID"
+	CodeUnauthorized           = "UNAUTHORIZED"
+	CodeAdminDisabled          = "ADMIN_DISABLED"
+	CodeOutliersDisabled       = "OUTLIERS_DISABLED"
+	CodeNotAcceptable          = "NOT_ACCEPTABLE"
+	CodeRouteNotFound          = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed       = "METHOD_NOT_ALLOWED"
+	CodeRateLimited            = "RATE_LIMITED"
+	CodeStorageUnavailable     = "STORAGE_UNAVAILABLE"
+	CodeInternal               = "INTERNAL_ERROR"
+)
+
+// Problem is an RFC 7807 problem details object. Code, Param, Allowed and
+// Errors are DiscoGo extension members.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the stable error code clients branch on.
+	Code string `json:"code" enums:"INVALID_BODY,BODY_TOO_LARGE,UNSUPPORTED_MEDIA_TYPE,BATCH_TOO_LARGE,VALIDATION_FAILED,INVALID_PARAMETER,INVALID_UUID,SERVICE_NOT_FOUND,SERVICE_SUSPICIOUS,ILLEGAL_TRANSITION,REPORTER_NOT_FOUND,IDEMPOTENCY_KEY_REUSED,REGISTRATION_IN_PROGRESS,SERVICE_BUSY,UNKNOWN_SERVICE_TYPE,CATALOG_ENTRY_EXISTS,CATALOG_ENTRY_NOT_FOUND,CATALOG_INVALID,UNAUTHORIZED,ADMIN_DISABLED,OUTLIERS_DISABLED,NOT_ACCEPTABLE,ROUTE_NOT_FOUND,METHOD_NOT_ALLOWED,RATE_LIMITED,STORAGE_UNAVAILABLE,INTERNAL_ERROR"`
+	// Param names the offending query or path parameter.
+	Param string `json:"param,omitempty"`
+	// Allowed lists the accepted values of Param.
+	Allowed []string `json:"allowed,omitempty"`
+	// Errors holds per-field validation errors.
+	Errors interface{} `json:"errors,omitempty"`
+}
+
+// ProblemTypeURI returns the problem type URI of code,
+// e.g. "urn:discogo:problem:service-not-found".
+func ProblemTypeURI(code string) string {
+	return "urn:discogo:problem:" + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
+
+// NewProblem returns a problem for status and code with the standard members
+// filled in.
+func NewProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   ProblemTypeURI(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// WriteProblem writes p as application/problem+json. Instance defaults to
+// the request path.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	WriteJSONBody(w, ProblemContentType, p.Status, p)
+}
+
+// WriteError writes a problem without extension members.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	WriteProblem(w, r, NewProblem(status, code, detail))
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ActorHeader carries the identity of the operator performing an admin call.
+const ActorHeader = "X-DiscoGo-Actor"
+
+// APIKeyHeader identifies a client for rate limiting when it carries one of
+// the configured keys; other clients are told apart by source IP.
+const APIKeyHeader = "X-API-Key"
+
+type clientIPKey struct{}
+
+// WithClientIP returns r carrying ip as the caller's address, as resolved by
+// ForwardedClientIP.
+func WithClientIP(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+}
+
+// GetClientIP returns the caller's IP: the address recorded by WithClientIP,
+// or else the peer of the connection.
+func GetClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ForwardedClientIP resolves the caller of r behind the trusted proxies.
+// X-Forwarded-For is only read when the connection comes from a trusted
+// proxy, and then from the right: the last hop that is not a trusted proxy
+// is the caller, as everything left of it could have been sent by the
+// caller itself.
+func ForwardedClientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip := remoteIP(r)
+	if !isTrustedProxy(ip, trusted) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// A malformed hop ends the chain we can trust
+			return ip
+		}
+		ip = hop
+		if !isTrustedProxy(hop, trusted) {
+			break
+		}
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetActor returns the caller identity sent in ActorHeader, or fallback.
+func GetActor(r *http.Request, fallback string) string {
+	if actor := strings.TrimSpace(r.Header.Get(ActorHeader)); actor != "" {
+		return actor
+	}
+	return fallback
+}
@@ -1,31 +1,125 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
+
+	"github.com/tahakara/discogo/internal/logger"
 )
 
+// JSONResponse wraps every successful response. Errors are written as
+// problem details instead, see WriteProblem.
 type JSONResponse struct {
-	Status  int         `json:"status"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
 }
 
 func WriteJSONResponse(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(JSONResponse{
-		Status:  status,
-		Message: "",
-		Data:    data,
+	WriteJSONBody(w, "application/json", status, JSONResponse{
+		Status: status,
+		Data:   data,
 	})
 }
 
+// WriteJSONBody writes v as-is with contentType. It encodes v before writing
+// the header so an encoding failure can still be reported as a 500 instead
+// of a truncated body.
+func WriteJSONBody(w http.ResponseWriter, contentType string, status int, v interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		logger.Error(fmt.Sprintf("Failed to encode response: %v", err), 0)
+		w.Header().Set("Content-Type", ProblemContentType)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `{"type":%q,"title":%q,"status":500,"code":%q}`+"\n",
+			ProblemTypeURI(CodeInternal), http.StatusText(http.StatusInternalServerError), CodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.Error(fmt.Sprintf("Failed to write response: %v", err), 0)
+	}
+}
+
+// maxBodyBytes caps request bodies read by DecodeJSONBody.
+var maxBodyBytes int64 = 1 << 20
+
+// SetMaxBodyBytes sets the largest request body DecodeJSONBody accepts. It is
+// called once at startup from the loaded configuration.
+func SetMaxBodyBytes(n int64) {
+	maxBodyBytes = n
+}
+
+// ErrUnsupportedMediaType is returned by DecodeJSONBody for a body that is
+// not declared as JSON.
+var ErrUnsupportedMediaType = errors.New("Content-Type must be application/json")
+
+// DecodeJSONBody decodes the JSON request body into dst the same way on every
+// route: at most SetMaxBodyBytes bytes, declared as application/json (or a
+// +json type), unknown fields rejected and nothing after the value. An empty
+// body returns io.EOF, so optional bodies can be told apart. Report errors
+// with WriteDecodeError.
 func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
-	decoder := json.NewDecoder(r.Body)
+	return DecodeJSONBodyLimit(w, r, dst, maxBodyBytes)
+}
+
+// DecodeJSONBodyLimit is DecodeJSONBody with its own size limit, for routes
+// that take larger bodies.
+func DecodeJSONBodyLimit(w http.ResponseWriter, r *http.Request, dst interface{}, limit int64) error {
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, limit))
+	if _, err := body.Peek(1); err != nil {
+		return err
+	}
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		return ErrUnsupportedMediaType
+	}
+
+	decoder := json.NewDecoder(body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		if err == nil {
+			err = errors.New("unexpected data after the JSON value")
+		}
 		return err
 	}
 	return nil
 }
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// WriteDecodeError answers a DecodeJSONBody error: 413 for a body over the
+// limit, 415 for a body that is not JSON and 400 otherwise.
+func WriteDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		WriteError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+			fmt.Sprintf("The request body exceeds %d bytes", tooLarge.Limit))
+	case errors.Is(err, ErrUnsupportedMediaType):
+		WriteError(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, err.Error())
+	case errors.Is(err, io.EOF):
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidBody, "The request body is empty")
+	default:
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request payload: "+err.Error())
+	}
+}
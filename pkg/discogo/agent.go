@@ -0,0 +1,214 @@
+package discogo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultHeartbeatInterval = 30 * time.Second
+	defaultJitter            = 0.1
+	defaultRetryInterval     = 5 * time.Second
+	defaultDeregisterTimeout = 5 * time.Second
+)
+
+// Agent keeps a single service instance registered: it registers, sends
+// heartbeats at the server provided HealthCheckCycle, re-registers when the
+// server forgot the instance and deregisters when its context is cancelled.
+type Agent struct {
+	client  *Client
+	request RegisterRequest
+
+	jitter            float64
+	retryInterval     time.Duration
+	deregisterTimeout time.Duration
+	onError           func(error)
+	onRegister        func(serviceUUID string)
+	heartbeatPayload  func() *HeartbeatRequest
+
+	mu          sync.RWMutex
+	serviceUUID string
+}
+
+// AgentOption customises an Agent.
+type AgentOption func(*Agent)
+
+// WithJitter randomises each heartbeat interval by ±fraction (0.1 = ±10%).
+func WithJitter(fraction float64) AgentOption {
+	return func(a *Agent) {
+		if fraction >= 0 && fraction < 1 {
+			a.jitter = fraction
+		}
+	}
+}
+
+// WithRetryInterval sets the delay before retrying a failed registration.
+func WithRetryInterval(d time.Duration) AgentOption {
+	return func(a *Agent) {
+		if d > 0 {
+			a.retryInterval = d
+		}
+	}
+}
+
+// WithDeregisterTimeout bounds the deregistration call made on shutdown.
+func WithDeregisterTimeout(d time.Duration) AgentOption {
+	return func(a *Agent) {
+		if d > 0 {
+			a.deregisterTimeout = d
+		}
+	}
+}
+
+// WithErrorHandler is called for every registration or heartbeat failure.
+func WithErrorHandler(fn func(error)) AgentOption {
+	return func(a *Agent) {
+		a.onError = fn
+	}
+}
+
+// WithRegisterHandler is called every time the agent (re-)registers.
+func WithRegisterHandler(fn func(serviceUUID string)) AgentOption {
+	return func(a *Agent) {
+		a.onRegister = fn
+	}
+}
+
+// WithHeartbeatPayload is called before every heartbeat to report the
+// instance's load and health. Returning nil sends a plain heartbeat.
+func WithHeartbeatPayload(fn func() *HeartbeatRequest) AgentOption {
+	return func(a *Agent) {
+		a.heartbeatPayload = fn
+	}
+}
+
+// NewAgent creates an agent that registers req through client.
+func NewAgent(client *Client, req RegisterRequest, opts ...AgentOption) *Agent {
+	a := &Agent{
+		client:            client,
+		request:           req,
+		jitter:            defaultJitter,
+		retryInterval:     defaultRetryInterval,
+		deregisterTimeout: defaultDeregisterTimeout,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// ServiceUUID returns the UUID of the current registration, or "" before the
+// first successful registration.
+func (a *Agent) ServiceUUID() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.serviceUUID
+}
+
+func (a *Agent) setServiceUUID(serviceUUID string) {
+	a.mu.Lock()
+	a.serviceUUID = serviceUUID
+	a.mu.Unlock()
+}
+
+func (a *Agent) reportError(err error) {
+	if a.onError != nil && err != nil {
+		a.onError(err)
+	}
+}
+
+// Run blocks until ctx is cancelled. The instance is deregistered before Run
+// returns; the returned error is the deregistration error, if any.
+func (a *Agent) Run(ctx context.Context) error {
+	interval, ok := a.register(ctx)
+	if !ok {
+		return nil
+	}
+
+	timer := time.NewTimer(a.withJitter(interval))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return a.deregister()
+		case <-timer.C:
+		}
+
+		var payload *HeartbeatRequest
+		if a.heartbeatPayload != nil {
+			payload = a.heartbeatPayload()
+		}
+		_, err := a.client.HeartbeatWithPayload(ctx, a.ServiceUUID(), payload)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrServiceNotFound):
+			// The registration expired on the server, start over
+			a.reportError(err)
+			if interval, ok = a.register(ctx); !ok {
+				return nil
+			}
+		default:
+			a.reportError(err)
+		}
+
+		timer.Reset(a.withJitter(interval))
+	}
+}
+
+// register retries until the instance is registered or ctx is cancelled and
+// returns the heartbeat interval to use.
+func (a *Agent) register(ctx context.Context) (time.Duration, bool) {
+	// Retries share a key so a lost response does not register twice
+	opts := RegisterOptions{IdempotencyKey: uuid.New().String()}
+	for {
+		resp, err := a.client.RegisterWithOptions(ctx, a.request, opts)
+		if err == nil {
+			a.setServiceUUID(resp.ServiceUUID)
+			if a.onRegister != nil {
+				a.onRegister(resp.ServiceUUID)
+			}
+			interval := time.Duration(resp.HealthCheckCycle) * time.Second
+			if interval <= 0 {
+				interval = defaultHeartbeatInterval
+			}
+			return interval, true
+		}
+		a.reportError(err)
+
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-time.After(a.withJitter(a.retryInterval)):
+		}
+	}
+}
+
+func (a *Agent) deregister() error {
+	serviceUUID := a.ServiceUUID()
+	if serviceUUID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.deregisterTimeout)
+	defer cancel()
+
+	_, err := a.client.Deregister(ctx, serviceUUID)
+	if err == nil || errors.Is(err, ErrServiceNotFound) {
+		a.setServiceUUID("")
+		return nil
+	}
+	return err
+}
+
+func (a *Agent) withJitter(d time.Duration) time.Duration {
+	if a.jitter == 0 {
+		return d
+	}
+	delta := (rand.Float64()*2 - 1) * a.jitter * float64(d)
+	return d + time.Duration(delta)
+}
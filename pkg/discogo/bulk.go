@@ -0,0 +1,107 @@
+package discogo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// BulkResult is the outcome of one item of a bulk request, in request order.
+type BulkResult struct {
+	Index        int
+	ServiceUUID  string
+	Existing     bool
+	ReplacedUUID string
+	// Err is an *APIError when the item failed.
+	Err error
+}
+
+// BulkResponse lists the per-item results of a bulk request.
+type BulkResponse struct {
+	Succeeded int
+	Failed    int
+	// HealthCheckCycle is set for registrations.
+	HealthCheckCycle int
+	Results          []BulkResult
+}
+
+type bulkResponse struct {
+	Succeeded        int `json:"succeeded"`
+	Failed           int `json:"failed"`
+	HealthCheckCycle int `json:"healthCheckCycle"`
+	Results          []struct {
+		Index        int      `json:"index"`
+		ServiceUUID  string   `json:"serviceUUID"`
+		Status       int      `json:"status"`
+		Existing     bool     `json:"existing"`
+		ReplacedUUID string   `json:"replacedUUID"`
+		Error        *problem `json:"error"`
+	} `json:"results"`
+}
+
+func (c *Client) bulk(ctx context.Context, path string, query url.Values, body interface{}) (*BulkResponse, error) {
+	var raw bulkResponse
+	if err := c.do(ctx, http.MethodPost, path, query, body, &raw); err != nil {
+		return nil, err
+	}
+
+	resp := &BulkResponse{Succeeded: raw.Succeeded, Failed: raw.Failed, HealthCheckCycle: raw.HealthCheckCycle}
+	for _, item := range raw.Results {
+		result := BulkResult{
+			Index:        item.Index,
+			ServiceUUID:  item.ServiceUUID,
+			Existing:     item.Existing,
+			ReplacedUUID: item.ReplacedUUID,
+		}
+		if item.Error != nil {
+			result.Err = item.Error.apiError(item.Status)
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// BulkRegister registers reqs in one request. Each item behaves like
+// RegisterWithOptions with Replace set to replace.
+func (c *Client) BulkRegister(ctx context.Context, reqs []RegisterRequest, replace bool) (*BulkResponse, error) {
+	query := url.Values{}
+	if replace {
+		query.Set("replace", "true")
+	}
+	body := struct {
+		Services []RegisterRequest `json:"services"`
+	}{Services: reqs}
+	return c.bulk(ctx, "/v1/bulk/register", query, body)
+}
+
+// BulkHeartbeat refreshes the registrations of serviceUUIDs in one request.
+func (c *Client) BulkHeartbeat(ctx context.Context, serviceUUIDs []string) (*BulkResponse, error) {
+	body := struct {
+		ServiceUUIDs []string `json:"serviceUUIDs"`
+	}{ServiceUUIDs: serviceUUIDs}
+	return c.bulk(ctx, "/v1/bulk/heartbeat", nil, body)
+}
+
+// BulkHeartbeatItem is one heartbeat of BulkHeartbeatWithPayloads. A nil
+// Payload sends a plain heartbeat.
+type BulkHeartbeatItem struct {
+	ServiceUUID string            `json:"serviceUUID"`
+	Payload     *HeartbeatRequest `json:"payload,omitempty"`
+}
+
+// BulkHeartbeatWithPayloads refreshes several registrations in one request,
+// each reporting its own load, status and metadata like HeartbeatWithPayload.
+func (c *Client) BulkHeartbeatWithPayloads(ctx context.Context, items []BulkHeartbeatItem) (*BulkResponse, error) {
+	body := struct {
+		Heartbeats []BulkHeartbeatItem `json:"heartbeats"`
+	}{Heartbeats: items}
+	return c.bulk(ctx, "/v1/bulk/heartbeat", nil, body)
+}
+
+// BulkDeregister removes serviceUUIDs from the registry in one request.
+func (c *Client) BulkDeregister(ctx context.Context, serviceUUIDs []string) (*BulkResponse, error) {
+	body := struct {
+		ServiceUUIDs []string `json:"serviceUUIDs"`
+	}{ServiceUUIDs: serviceUUIDs}
+	return c.bulk(ctx, "/v1/bulk/deregister", nil, body)
+}
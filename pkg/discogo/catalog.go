@@ -0,0 +1,80 @@
+package discogo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// InstanceCounts holds the number of live instances of a service type.
+type InstanceCounts struct {
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+type ServiceType struct {
+	Name        string         `json:"name"`
+	Short       string         `json:"short"`
+	Description string         `json:"description"`
+	Group       string         `json:"group"`
+	Custom      bool           `json:"custom,omitempty"`
+	Retired     bool           `json:"retired,omitempty"`
+	Instances   InstanceCounts `json:"instances"`
+}
+
+type ServiceTypeGroup struct {
+	Key      string        `json:"key"`
+	Name     string        `json:"name"`
+	Services []ServiceType `json:"services"`
+}
+
+type Provider struct {
+	Name    string `json:"name"`
+	Short   string `json:"short"`
+	Custom  bool   `json:"custom,omitempty"`
+	Retired bool   `json:"retired,omitempty"`
+}
+
+type serviceTypesResponse struct {
+	Status string             `json:"status"`
+	Groups []ServiceTypeGroup `json:"groups"`
+}
+
+type serviceTypeResponse struct {
+	Status      string       `json:"status"`
+	ServiceType *ServiceType `json:"serviceType,omitempty"`
+}
+
+type providersResponse struct {
+	Status    string     `json:"status"`
+	Providers []Provider `json:"providers"`
+}
+
+// ServiceTypes lists the service type groups of the catalog with live
+// instance counts.
+func (c *Client) ServiceTypes(ctx context.Context) ([]ServiceTypeGroup, error) {
+	var resp serviceTypesResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/catalog/types", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Groups, nil
+}
+
+// ServiceType returns a single catalog entry. It fails with
+// ErrUnknownServiceType if short is not in the catalog.
+func (c *Client) ServiceType(ctx context.Context, short string) (*ServiceType, error) {
+	var resp serviceTypeResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/catalog/types/"+url.PathEscape(short), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.ServiceType, nil
+}
+
+// Providers lists the providers of the catalog.
+func (c *Client) Providers(ctx context.Context) ([]Provider, error) {
+	var resp providersResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/catalog/providers", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Providers, nil
+}
@@ -0,0 +1,504 @@
+// Package discogo is the Go client SDK for the DiscoGo HTTP API.
+//
+// Client wraps every route, Agent runs the register → heartbeat → deregister
+// lifecycle for a service instance and Resolver caches discovery results.
+package discogo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tahakara/discogo/internal/api/dtos/requestdto"
+)
+
+// RegisterRequest is the registration payload accepted by the server.
+type RegisterRequest = requestdto.RegisterRequestDTO
+
+// Check is an active health check declared with a registration.
+type Check = requestdto.CheckDTO
+
+// HeartbeatRequest is the optional load and health report of a heartbeat.
+type HeartbeatRequest = requestdto.HeartbeatRequestDTO
+
+// ValidationError describes one rejected field of a registration request.
+// Allowed lists the accepted values for catalog-backed fields such as Type.
+type ValidationError struct {
+	Field   string   `json:"field"`
+	Rule    string   `json:"rule"`
+	Param   string   `json:"param,omitempty"`
+	Message string   `json:"message"`
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+type RegisterResponse struct {
+	Status           string `json:"status"`
+	ServiceUUID      string `json:"serviceUUID"`
+	HealthCheckCycle int    `json:"healthCheckCycle"`
+	// Existing is set when the identity was already registered and its UUID
+	// was returned.
+	Existing bool `json:"existing,omitempty"`
+	// ReplacedUUID is the registration removed by RegisterOptions.Replace.
+	ReplacedUUID string `json:"replacedUUID,omitempty"`
+}
+
+// RegisterOptions change how Register treats an identity that is already
+// registered and retried requests.
+type RegisterOptions struct {
+	// Replace removes an existing registration of the same identity instead
+	// of returning it.
+	Replace bool
+	// IdempotencyKey makes retries of the same request return the first
+	// registration. Reusing a key with a different request fails with
+	// CodeIdempotencyKeyReused.
+	IdempotencyKey string
+}
+
+type HeartbeatResponse struct {
+	Status string `json:"status"`
+}
+
+// ReportRequest is a report by a registered instance about another one.
+type ReportRequest = requestdto.ReportRequestDTO
+
+type ReportResponse struct {
+	Status        string  `json:"status"`
+	ServiceUUID   string  `json:"serviceUUID"`
+	ServiceStatus string  `json:"serviceStatus"`
+	Reporters     int     `json:"reporters"`
+	Score         float64 `json:"score"`
+	Threshold     int64   `json:"threshold"`
+}
+
+// OutcomesRequest carries the request outcomes a registered instance saw
+// against the instances it calls.
+type OutcomesRequest = requestdto.OutcomesRequestDTO
+
+// Outcome counts the requests to one instance that succeeded and failed.
+type Outcome = requestdto.OutcomeDTO
+
+type OutcomesResponse struct {
+	Status   string `json:"status"`
+	Accepted int    `json:"accepted"`
+	Interval int    `json:"interval"`
+}
+
+type DeregisterResponse struct {
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type ServiceInfo struct {
+	ServiceID   string `json:"serviceID"`
+	ServiceAddr string `json:"serviceAddr"`
+	Status      string `json:"status,omitempty"`
+	// Load is the last load reported by the instance's heartbeats, if any.
+	Load *Load `json:"load,omitempty"`
+}
+
+// Load is the load an instance reported with its heartbeats.
+type Load struct {
+	CPU      float64 `json:"cpu"`
+	Memory   float64 `json:"memory"`
+	InFlight int64   `json:"inFlight"`
+}
+
+type DiscoverResponse struct {
+	Status   string        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Services []ServiceInfo `json:"services"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type VersionResponse struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	VersionName string `json:"versionName"`
+}
+
+// DiscoverQuery holds the discovery filters. Empty fields are not sent.
+type DiscoverQuery struct {
+	ServiceType string
+	Status      string
+	Provider    string
+	Region      string
+	Zone        string
+	NetworkID   string
+	SubnetID    string
+	InstanceID  string
+	Version     string
+	PageSize    int
+	PageOffset  int
+}
+
+func (q DiscoverQuery) values() url.Values {
+	v := url.Values{}
+	set := func(key, val string) {
+		if val != "" {
+			v.Set(key, val)
+		}
+	}
+	set("servicetype", q.ServiceType)
+	set("status", q.Status)
+	set("provider", q.Provider)
+	set("region", q.Region)
+	set("zone", q.Zone)
+	set("networkid", q.NetworkID)
+	set("subnetid", q.SubnetID)
+	set("instanceid", q.InstanceID)
+	set("version", q.Version)
+	if q.PageSize > 0 {
+		v.Set("pagesize", strconv.Itoa(q.PageSize))
+	}
+	if q.PageOffset > 0 {
+		v.Set("pageoffset", strconv.Itoa(q.PageOffset))
+	}
+	return v
+}
+
+// Error codes returned by the server in problem responses.
+const (
+	CodeInvalidBody            = "INVALID_BODY"
+	CodeBodyTooLarge           = "BODY_TOO_LARGE"
+	CodeUnsupportedMediaType   = "UNSUPPORTED_MEDIA_TYPE"
+	CodeValidationFailed       = "VALIDATION_FAILED"
+	CodeInvalidParameter       = "INVALID_PARAMETER"
+	CodeInvalidUUID            = "INVALID_UUID"
+	CodeServiceNotFound        = "SERVICE_NOT_FOUND"
+	CodeServiceSuspicious      = "SERVICE_SUSPICIOUS"
+	CodeIllegalTransition      = "ILLEGAL_TRANSITION"
+	CodeReporterNotFound       = "REPORTER_NOT_FOUND"
+	CodeOutliersDisabled       = "OUTLIERS_DISABLED"
+	CodeIdempotencyKeyReused   = "IDEMPOTENCY_KEY_REUSED"
+	CodeRegistrationInProgress = "REGISTRATION_IN_PROGRESS"
+	CodeServiceBusy            = "SERVICE_BUSY"
+	// Deprecated: registering an existing identity returns it instead.
+	CodeServiceExists      = "SERVICE_ALREADY_EXISTS"
+	CodeUnknownServiceType = "UNKNOWN_SERVICE_TYPE"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeStorageUnavailable = "STORAGE_UNAVAILABLE"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+var (
+	// ErrServiceNotFound is returned when the server no longer knows the
+	// service UUID, e.g. because its TTL expired.
+	ErrServiceNotFound = errors.New("discogo: service not found")
+	// ErrServiceSuspicious is returned while the service is suspicious
+	// because it has been reported too often. The server still counts the
+	// heartbeats towards its recovery.
+	ErrServiceSuspicious = errors.New("discogo: service is suspicious")
+	// ErrUnknownServiceType is returned when a service type is not in the
+	// server's catalog.
+	ErrUnknownServiceType = errors.New("discogo: unknown service type")
+	// ErrRateLimited is returned when the client exceeded the server's rate
+	// limit. APIError.RetryAfter tells how long to wait.
+	ErrRateLimited = errors.New("discogo: rate limited")
+)
+
+// APIError is returned for every non-2xx response. It carries the fields of
+// the server's application/problem+json body.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Title      string
+	Detail     string
+	// Param and Allowed describe a rejected query parameter and the values it
+	// accepts.
+	Param   string
+	Allowed []string
+	// Errors lists the rejected fields of a registration request.
+	Errors []ValidationError
+	// RetryAfter is the wait the server asked for with Retry-After, e.g.
+	// when rate limited.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		return fmt.Sprintf("discogo: server returned %d", e.StatusCode)
+	}
+	if e.Code == "" {
+		return fmt.Sprintf("discogo: server returned %d: %s", e.StatusCode, msg)
+	}
+	return fmt.Sprintf("discogo: server returned %d %s: %s", e.StatusCode, e.Code, msg)
+}
+
+// Is maps error codes onto the package sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrServiceNotFound:
+		return e.Code == CodeServiceNotFound
+	case ErrServiceSuspicious:
+		return e.Code == CodeServiceSuspicious
+	case ErrUnknownServiceType:
+		return e.Code == CodeUnknownServiceType
+	case ErrRateLimited:
+		return e.Code == CodeRateLimited
+	}
+	return false
+}
+
+// envelope mirrors the {status, data} wrapper of every successful response.
+type envelope struct {
+	Status int             `json:"status"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// problem mirrors the problem details body of every error response.
+type problem struct {
+	Title   string            `json:"title"`
+	Status  int               `json:"status"`
+	Detail  string            `json:"detail"`
+	Code    string            `json:"code"`
+	Param   string            `json:"param"`
+	Allowed []string          `json:"allowed"`
+	Errors  []ValidationError `json:"errors"`
+}
+
+func (p problem) apiError(statusCode int) *APIError {
+	return &APIError{
+		StatusCode: statusCode,
+		Code:       p.Code,
+		Title:      p.Title,
+		Detail:     p.Detail,
+		Param:      p.Param,
+		Allowed:    p.Allowed,
+		Errors:     p.Errors,
+	}
+}
+
+// Client is a typed client for the DiscoGo HTTP API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+}
+
+// ClientOption customises a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient replaces the default http.Client.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header, which the server's rate
+// limits use to tell clients apart instead of the source IP. The server only
+// honours keys listed in its RATE_LIMIT_API_KEYS.
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// NewClient creates a client for the server at addr, e.g. "http://127.0.0.1:8080".
+// The scheme defaults to http when omitted.
+func NewClient(addr string, opts ...ClientOption) *Client {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	c := &Client{
+		baseURL:    strings.TrimRight(addr, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends a request and decodes the envelope's data into out. Non-2xx
+// responses are returned as *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, out interface{}) error {
+	return c.doWithHeader(ctx, method, path, query, nil, body, out)
+}
+
+// doWithHeader is do with extra request headers.
+func (c *Client) doWithHeader(ctx context.Context, method, path string, query url.Values, header http.Header, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var p problem
+		apiErr := &APIError{StatusCode: resp.StatusCode, Detail: strings.TrimSpace(string(raw))}
+		if err := json.Unmarshal(raw, &p); err == nil && p.Status != 0 {
+			apiErr = p.apiError(resp.StatusCode)
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return &APIError{StatusCode: resp.StatusCode, Detail: strings.TrimSpace(string(raw))}
+	}
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register registers a service instance. When the identity is already
+// registered the existing UUID is returned with Existing set. Rejected fields
+// are listed in the Errors of the returned *APIError.
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*RegisterResponse, error) {
+	return c.RegisterWithOptions(ctx, req, RegisterOptions{})
+}
+
+// RegisterWithOptions is Register with opts applied.
+func (c *Client) RegisterWithOptions(ctx context.Context, req RegisterRequest, opts RegisterOptions) (*RegisterResponse, error) {
+	query := url.Values{}
+	if opts.Replace {
+		query.Set("replace", "true")
+	}
+	header := http.Header{}
+	if opts.IdempotencyKey != "" {
+		header.Set("Idempotency-Key", opts.IdempotencyKey)
+	}
+
+	var resp RegisterResponse
+	if err := c.doWithHeader(ctx, http.MethodPost, "/v1/register", query, header, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Heartbeat refreshes the registration of serviceUUID.
+func (c *Client) Heartbeat(ctx context.Context, serviceUUID string) (*HeartbeatResponse, error) {
+	return c.HeartbeatWithPayload(ctx, serviceUUID, nil)
+}
+
+// HeartbeatWithPayload sends a heartbeat carrying req. A nil req sends a
+// plain heartbeat.
+func (c *Client) HeartbeatWithPayload(ctx context.Context, serviceUUID string, req *HeartbeatRequest) (*HeartbeatResponse, error) {
+	var body interface{}
+	if req != nil {
+		body = req
+	}
+	var resp HeartbeatResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/heartbeat/"+url.PathEscape(serviceUUID), nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Report files a report by req.ReporterUUID, which must be registered,
+// against req.ServiceUUID.
+func (c *Client) Report(ctx context.Context, req ReportRequest) (*ReportResponse, error) {
+	var resp ReportResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/report", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ReportOutcomes adds the request outcomes seen by req.ReporterUUID, which
+// must be registered, to the server's outlier detection. Send the counts
+// since the previous call about once per returned Interval.
+func (c *Client) ReportOutcomes(ctx context.Context, req OutcomesRequest) (*OutcomesResponse, error) {
+	var resp OutcomesResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/outcomes", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Deregister removes serviceUUID from the registry.
+func (c *Client) Deregister(ctx context.Context, serviceUUID string) (*DeregisterResponse, error) {
+	var resp DeregisterResponse
+	body := struct {
+		ServiceUUID string `json:"serviceUUID"`
+	}{ServiceUUID: serviceUUID}
+	if err := c.do(ctx, http.MethodPost, "/v1/deregister", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Discover lists services matching q. When a filter is rejected the returned
+// *APIError names it in Param and lists the accepted values in Allowed.
+func (c *Client) Discover(ctx context.Context, q DiscoverQuery) (*DiscoverResponse, error) {
+	var resp DiscoverResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/discover", q.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health reports the health of the server and its storage backend.
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var resp HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/health", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Version returns the server version information.
+func (c *Client) Version(ctx context.Context) (*VersionResponse, error) {
+	var resp VersionResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/version", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,141 @@
+package discogo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const defaultResolverTTL = 10 * time.Second
+
+// ErrNoInstances is returned by Resolve when no instance matches the query.
+var ErrNoInstances = errors.New("discogo: no instances found")
+
+type resolverEntry struct {
+	services  []ServiceInfo
+	fetchedAt time.Time
+	next      int
+}
+
+// Resolver caches discovery results for a short TTL and hands out instances
+// round-robin. When the server cannot be reached, stale results are served
+// rather than failing.
+type Resolver struct {
+	client *Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*resolverEntry
+}
+
+// NewResolver creates a resolver whose results are cached for ttl
+// (10 seconds when ttl <= 0).
+func NewResolver(client *Client, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = defaultResolverTTL
+	}
+	return &Resolver{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]*resolverEntry),
+	}
+}
+
+// Lookup returns every instance matching q. Status defaults to "healthy".
+func (r *Resolver) Lookup(ctx context.Context, q DiscoverQuery) ([]ServiceInfo, error) {
+	entry, err := r.entry(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ServiceInfo(nil), entry.services...), nil
+}
+
+// Resolve returns a single instance matching q, rotating through the cached
+// instances on every call. Of the next two instances in the rotation the
+// less loaded one is preferred when both report load. Status defaults to
+// "healthy".
+func (r *Resolver) Resolve(ctx context.Context, q DiscoverQuery) (ServiceInfo, error) {
+	entry, err := r.entry(ctx, q)
+	if err != nil {
+		return ServiceInfo{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(entry.services) == 0 {
+		return ServiceInfo{}, ErrNoInstances
+	}
+	svc := entry.services[entry.next%len(entry.services)]
+	if len(entry.services) > 1 {
+		if other := entry.services[(entry.next+1)%len(entry.services)]; LessLoaded(other, svc) {
+			svc = other
+		}
+	}
+	entry.next++
+	return svc, nil
+}
+
+// LessLoaded reports whether a reported a lower load than b: the higher of
+// its CPU and memory load is lower, or equal with fewer requests in flight.
+// Instances without a load report are never less loaded.
+func LessLoaded(a, b ServiceInfo) bool {
+	if a.Load == nil || b.Load == nil {
+		return false
+	}
+	la, lb := math.Max(a.Load.CPU, a.Load.Memory), math.Max(b.Load.CPU, b.Load.Memory)
+	if la != lb {
+		return la < lb
+	}
+	return a.Load.InFlight < b.Load.InFlight
+}
+
+// Invalidate drops the cached result for q, e.g. after a failed call to one
+// of its instances.
+func (r *Resolver) Invalidate(q DiscoverQuery) {
+	r.mu.Lock()
+	delete(r.cache, resolverKey(q))
+	r.mu.Unlock()
+}
+
+func resolverKey(q DiscoverQuery) string {
+	if q.Status == "" {
+		q.Status = "healthy"
+	}
+	return q.values().Encode()
+}
+
+func (r *Resolver) entry(ctx context.Context, q DiscoverQuery) (*resolverEntry, error) {
+	if q.Status == "" {
+		q.Status = "healthy"
+	}
+	key := resolverKey(q)
+
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	if ok && time.Since(cached.fetchedAt) < r.ttl {
+		r.mu.Unlock()
+		return cached, nil
+	}
+	r.mu.Unlock()
+
+	resp, err := r.client.Discover(ctx, q)
+	if err != nil {
+		if ok {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := &resolverEntry{services: resp.Services, fetchedAt: time.Now()}
+	if ok {
+		entry.next = cached.next
+	}
+	r.cache[key] = entry
+	return entry, nil
+}